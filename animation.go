@@ -0,0 +1,583 @@
+package imgedit
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/gif"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Disposal is how the area of the frame is treated before the next frame is drawn
+type Disposal int
+
+const (
+	// DisposalNone leave the frame as it is
+	DisposalNone Disposal = iota
+	// DisposalBackground clear the area of the frame to transparent
+	DisposalBackground
+	// DisposalPrevious restore the area of the frame to the state before the frame
+	DisposalPrevious
+)
+
+// Frame is one image of the animation
+type Frame struct {
+	// Image of the whole canvas at the frame
+	Image image.Image
+	// Delay to display the frame
+	Delay time.Duration
+	// Disposal of the frame before the next frame is drawn
+	Disposal Disposal
+}
+
+// DefaultFrameDelay delay of the frames used when the delay is not specified
+const DefaultFrameDelay = 100 * time.Millisecond
+
+// AnimationOptions options for NewAnimation
+type AnimationOptions struct {
+	// Delay of the frames, default 100ms
+	Delay time.Duration
+	// Delays of each frame in the order of images, Delay is used for the missing or zero delays
+	Delays []time.Duration
+	// LoopCount same as AnimationConverter, default 0 loops forever
+	LoopCount int
+	// Width px of the frames, default max width of images
+	Width int
+	// Height px of the frames, default max height of images
+	Height int
+	// Fit how to resize the images to the frame size, default FitContain
+	Fit FitMode
+	// Background of the frames, default transparent
+	Background color.Color
+}
+
+func (o *AnimationOptions) setDefault(images []image.Image) {
+	if o.Delay <= 0 {
+		o.Delay = DefaultFrameDelay
+	}
+	var maxWidth, maxHeight int
+	for _, img := range images {
+		size := img.Bounds().Size()
+		if size.X > maxWidth {
+			maxWidth = size.X
+		}
+		if size.Y > maxHeight {
+			maxHeight = size.Y
+		}
+	}
+	if o.Width <= 0 {
+		o.Width = maxWidth
+	}
+	if o.Height <= 0 {
+		o.Height = maxHeight
+	}
+	if o.Background == nil {
+		o.Background = color.Transparent
+	}
+}
+
+// GifOptions options for WriteGif
+type GifOptions struct {
+	// NumColors of the palettes, default 256
+	NumColors int
+	// SharedPalette is true to use one palette created from all frames, false is the palette of each frame
+	SharedPalette bool
+	// Dither with Floyd-Steinberg, default the nearest colors of the palette
+	Dither bool
+	// Background is drawn under the transparent and translucent pixels, the transparency is kept if nil
+	Background color.Color
+}
+
+func (o *GifOptions) setDefault() {
+	if o.NumColors < 1 || 256 < o.NumColors {
+		o.NumColors = 256
+	}
+}
+
+// AnimationConverter interface for animation edit, the operations of Converter are applied to all frames
+type AnimationConverter interface {
+	FileConverter
+	ByteConverter
+	Frames() []Frame
+	// LoopCount is the same as gif.GIF, 0 loops forever, -1 shows the frames once, otherwise LoopCount+1 times
+	LoopCount() int
+	SetLoopCount(loopCount int)
+	WriteGif(w io.Writer, options *GifOptions) error
+	WriteApng(w io.Writer) error
+}
+
+type animationConverter struct {
+	frames    []Frame
+	loopCount int
+}
+
+// NewAnimationConverter create animationConverter from the frames
+func NewAnimationConverter(frames []Frame, loopCount int) AnimationConverter {
+	a := &animationConverter{loopCount: loopCount}
+	a.frames = append(a.frames, frames...)
+	return a
+}
+
+// NewAnimation create AnimationConverter from the images, the images are resized to the common size and placed at the center
+func NewAnimation(images []image.Image, options *AnimationOptions) AnimationConverter {
+	if options == nil {
+		options = &AnimationOptions{}
+	}
+	options.setDefault(images)
+	size := image.Point{X: options.Width, Y: options.Height}
+	var frames []Frame
+	for i, img := range images {
+		dst := image.NewRGBA(image.Rectangle{Max: size})
+		draw.Draw(dst, dst.Bounds(), image.NewUniform(options.Background), image.Point{}, draw.Src)
+		fitted := fitImage(img, size, options.Fit)
+		offset := size.Sub(fitted.Bounds().Size()).Div(2)
+		draw.Draw(dst, fitted.Bounds().Sub(fitted.Bounds().Min).Add(offset), fitted, fitted.Bounds().Min, draw.Over)
+
+		delay := options.Delay
+		if i < len(options.Delays) && options.Delays[i] > 0 {
+			delay = options.Delays[i]
+		}
+		frames = append(frames, Frame{Image: dst, Delay: delay})
+	}
+	return NewAnimationConverter(frames, options.LoopCount)
+}
+
+// NewAnimationFromDir create AnimationConverter from the images in the directory,
+// the files are ordered by the name with the numbers compared as numbers, and the files except images are skipped.
+func NewAnimationFromDir(dir string, options *AnimationOptions) (AnimationConverter, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			names = append(names, entry.Name())
+		}
+	}
+	sort.SliceStable(names, func(i, j int) bool { return naturalLess(names[i], names[j]) })
+
+	var images []image.Image
+	for _, name := range names {
+		c, _, err := NewFileConverter(filepath.Join(dir, name))
+		if err != nil {
+			continue
+		}
+		images = append(images, c.Convert())
+	}
+	if len(images) == 0 {
+		return nil, errors.New("no images in the directory: " + dir)
+	}
+	return NewAnimation(images, options), nil
+}
+
+// naturalLess return true if a is before b, the runs of the digits are compared as numbers. e.g) frame2 < frame10
+func naturalLess(a, b string) bool {
+	for a != "" && b != "" {
+		digitsA, digitsB := leadingDigits(a), leadingDigits(b)
+		if digitsA == "" || digitsB == "" {
+			if a[0] != b[0] {
+				return a[0] < b[0]
+			}
+			a, b = a[1:], b[1:]
+			continue
+		}
+		numberA, numberB := strings.TrimLeft(digitsA, "0"), strings.TrimLeft(digitsB, "0")
+		if len(numberA) != len(numberB) {
+			return len(numberA) < len(numberB)
+		}
+		if numberA != numberB {
+			return numberA < numberB
+		}
+		a, b = a[len(digitsA):], b[len(digitsB):]
+	}
+	return len(a) < len(b)
+}
+
+// leadingDigits return the digits at the start of the string
+func leadingDigits(s string) string {
+	return s[:len(s)-len(strings.TrimLeft(s, "0123456789"))]
+}
+
+// decodeAnimation return animationConverter, if the data is the image of multiple frames
+func decodeAnimation(data []byte) (*animationConverter, Extension, bool) {
+	_, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", false
+	}
+	switch Extension(format) {
+	case Gif:
+		g, err := gif.DecodeAll(bytes.NewReader(data))
+		if err != nil || len(g.Image) < 2 {
+			return nil, "", false
+		}
+		return newGifAnimation(g), Gif, true
+	case Png:
+		a, err := decodeApng(data)
+		if err != nil || a == nil || len(a.frames) < 2 {
+			return nil, "", false
+		}
+		return a, Png, true
+	}
+	return nil, "", false
+}
+
+// newGifAnimation create animationConverter from the gif, the frames are drawn on the whole canvas
+func newGifAnimation(g *gif.GIF) *animationConverter {
+	bounds := image.Rect(0, 0, g.Config.Width, g.Config.Height)
+	for _, frame := range g.Image {
+		bounds = bounds.Union(frame.Bounds())
+	}
+	a := &animationConverter{loopCount: g.LoopCount}
+	canvas := image.NewRGBA(bounds)
+	for i, frame := range g.Image {
+		disposal := DisposalNone
+		if i < len(g.Disposal) {
+			switch g.Disposal[i] {
+			case gif.DisposalBackground:
+				disposal = DisposalBackground
+			case gif.DisposalPrevious:
+				disposal = DisposalPrevious
+			}
+		}
+		delay := 0
+		if i < len(g.Delay) {
+			delay = g.Delay[i]
+		}
+		var whole *image.RGBA
+		whole, canvas = drawFrame(canvas, frame, frame.Bounds(), draw.Over, disposal)
+		a.frames = append(a.frames, Frame{Image: whole, Delay: time.Duration(delay) * 10 * time.Millisecond, Disposal: disposal})
+	}
+	return a
+}
+
+// drawFrame draw the partial frame on the canvas, and return the whole image of the frame and the canvas for the next frame
+func drawFrame(canvas *image.RGBA, img image.Image, rect image.Rectangle, op draw.Op, disposal Disposal) (*image.RGBA, *image.RGBA) {
+	previous := copyRGBA(canvas)
+	draw.Draw(canvas, rect, img, img.Bounds().Min, op)
+	whole := copyRGBA(canvas)
+	switch disposal {
+	case DisposalBackground:
+		draw.Draw(canvas, rect, image.Transparent, image.Point{}, draw.Src)
+	case DisposalPrevious:
+		canvas = previous
+	}
+	return whole, canvas
+}
+
+// copyRGBA return the copy of the image
+func copyRGBA(src *image.RGBA) *image.RGBA {
+	dst := image.NewRGBA(src.Bounds())
+	copy(dst.Pix, src.Pix)
+	return dst
+}
+
+// Frames return the frames of the animation
+func (a *animationConverter) Frames() []Frame {
+	return append([]Frame(nil), a.frames...)
+}
+
+// LoopCount return the loop count of the animation
+func (a *animationConverter) LoopCount() int {
+	return a.loopCount
+}
+
+// SetLoopCount set the loop count of the animation
+func (a *animationConverter) SetLoopCount(loopCount int) {
+	a.loopCount = loopCount
+}
+
+// each apply f to the converters of all frames
+func (a *animationConverter) each(f func(c *converter)) {
+	for i := range a.frames {
+		c := &converter{a.frames[i].Image}
+		f(c)
+		a.frames[i].Image = c.Image
+	}
+}
+
+// Resize resize all frames
+func (a *animationConverter) Resize(x, y int) {
+	a.each(func(c *converter) { c.Resize(x, y) })
+}
+
+// ResizeRatio resize all frames with ratio
+func (a *animationConverter) ResizeRatio(ratio float64) {
+	a.each(func(c *converter) { c.ResizeRatio(ratio) })
+}
+
+// Trim trim all frames to the specified size
+func (a *animationConverter) Trim(left, top, width, height int) {
+	a.each(func(c *converter) { c.Trim(left, top, width, height) })
+}
+
+// Reverse flips all frames
+func (a *animationConverter) Reverse(isHorizon bool) {
+	a.each(func(c *converter) { c.Reverse(isHorizon) })
+}
+
+// ReverseX reverse all frames about x
+func (a *animationConverter) ReverseX() {
+	a.Reverse(true)
+}
+
+// ReverseY reverse all frames about y
+func (a *animationConverter) ReverseY() {
+	a.Reverse(false)
+}
+
+// Filter change the color of all frames
+func (a *animationConverter) Filter(filterModel FilterModel) {
+	a.each(func(c *converter) { c.Filter(filterModel) })
+}
+
+// Grayscale change all frames to grayscale
+func (a *animationConverter) Grayscale() {
+	a.Filter(GrayModel)
+}
+
+// AddString write the string on all frames, and return the rectangle of the string
+func (a *animationConverter) AddString(text string, options *StringOptions) image.Rectangle {
+	var rect image.Rectangle
+	a.each(func(c *converter) { rect = c.AddString(text, options) })
+	return rect
+}
+
+// Tile repeat all frames vertically and horizontally
+func (a *animationConverter) Tile(xLength, yLength int) {
+	a.each(func(c *converter) { c.Tile(xLength, yLength) })
+}
+
+// TileWithOptions tile all frames with the options
+func (a *animationConverter) TileWithOptions(options *TileOptions) {
+	a.each(func(c *converter) { c.TileWithOptions(options) })
+}
+
+// DropShadow add the shadow to all frames
+func (a *animationConverter) DropShadow(offset image.Point, blur int, shadowColor color.Color, opacity float64) {
+	a.each(func(c *converter) { c.DropShadow(offset, blur, shadowColor, opacity) })
+}
+
+// OuterGlow add the glow to all frames
+func (a *animationConverter) OuterGlow(size int, glowColor color.Color, opacity float64) {
+	a.each(func(c *converter) { c.OuterGlow(size, glowColor, opacity) })
+}
+
+// Border add the border to all frames
+func (a *animationConverter) Border(width int, borderColor color.Color) {
+	a.each(func(c *converter) { c.Border(width, borderColor) })
+}
+
+// DrawLine draw the line on all frames
+func (a *animationConverter) DrawLine(from, to image.Point, options *ShapeOptions) {
+	a.each(func(c *converter) { c.DrawLine(from, to, options) })
+}
+
+// DrawRectangle draw the rectangle on all frames
+func (a *animationConverter) DrawRectangle(rect image.Rectangle, radius int, options *ShapeOptions) {
+	a.each(func(c *converter) { c.DrawRectangle(rect, radius, options) })
+}
+
+// DrawCircle draw the circle on all frames
+func (a *animationConverter) DrawCircle(center image.Point, radius int, options *ShapeOptions) {
+	a.each(func(c *converter) { c.DrawCircle(center, radius, options) })
+}
+
+// DrawEllipse draw the ellipse on all frames
+func (a *animationConverter) DrawEllipse(center image.Point, radiusX, radiusY int, options *ShapeOptions) {
+	a.each(func(c *converter) { c.DrawEllipse(center, radiusX, radiusY, options) })
+}
+
+// DrawPolygon draw the polygon on all frames
+func (a *animationConverter) DrawPolygon(points []image.Point, options *ShapeOptions) {
+	a.each(func(c *converter) { c.DrawPolygon(points, options) })
+}
+
+// DrawPath draw the path on all frames
+func (a *animationConverter) DrawPath(p *Path, options *ShapeOptions) {
+	a.each(func(c *converter) { c.DrawPath(p, options) })
+}
+
+// Overlay blend the paint on all frames
+func (a *animationConverter) Overlay(paint image.Image, mode BlendMode, opacity float64) {
+	a.each(func(c *converter) { c.Overlay(paint, mode, opacity) })
+}
+
+// AddImage draw the image on all frames at the point
+func (a *animationConverter) AddImage(img image.Image, point image.Point) {
+	a.each(func(c *converter) { c.AddImage(img, point) })
+}
+
+// ScanCodes return the codes in the first frame
+func (a *animationConverter) ScanCodes() []ScannedCode {
+	return (&converter{a.Convert()}).ScanCodes()
+}
+
+// Convert return the first frame
+func (a *animationConverter) Convert() image.Image {
+	if len(a.frames) == 0 {
+		return image.NewRGBA(image.Rectangle{})
+	}
+	return a.frames[0].Image
+}
+
+// WriteAs write all frames for gif and png, and the first frame for the other extensions
+func (a *animationConverter) WriteAs(writer io.Writer, extension Extension) error {
+	return a.WriteAsWithOptions(writer, extension, nil)
+}
+
+// WriteAsWithOptions write all frames for gif and png with the options, and the first frame for the other extensions
+func (a *animationConverter) WriteAsWithOptions(writer io.Writer, extension Extension, options *WriteOptions) error {
+	if options == nil {
+		options = &WriteOptions{}
+	}
+	options.setDefault()
+	switch extension {
+	case Gif:
+		return a.WriteGif(writer, options.Gif)
+	case Png:
+		return a.writeApng(writer, options.CompressionLevel)
+	}
+	return (&byteConverter{converter: &converter{a.Convert()}}).WriteAsWithOptions(writer, extension, options)
+}
+
+func (a *animationConverter) SaveAs(dstPath string, extension Extension) error {
+	return a.SaveAsWithOptions(dstPath, extension, nil)
+}
+
+func (a *animationConverter) SaveAsWithOptions(dstPath string, extension Extension, options *WriteOptions) error {
+	dstFile, err := os.Create(dstPath)
+	if err != nil {
+		return err
+	}
+	defer dstFile.Close()
+	return a.WriteAsWithOptions(dstFile, extension, options)
+}
+
+// WriteGif write all frames as the animated gif,
+// the frame after the frame left as it is is cropped to the changed area.
+func (a *animationConverter) WriteGif(w io.Writer, options *GifOptions) error {
+	if options == nil {
+		options = &GifOptions{}
+	}
+	options.setDefault()
+	if len(a.frames) == 0 {
+		return errors.New("gif: animation has no frames")
+	}
+	images := a.canvasImages()
+	if options.Background != nil {
+		for i, img := range images {
+			images[i] = flattenImage(img, options.Background)
+		}
+	}
+	size := images[0].Bounds().Size()
+	if size.X >= 1<<16 || size.Y >= 1<<16 {
+		return errors.New("gif: image is too large to encode")
+	}
+	bounds := images[0].Bounds()
+
+	var shared color.Palette
+	if options.SharedPalette {
+		var all []image.Image
+		for _, img := range images {
+			all = append(all, img)
+		}
+		shared = gifPalette(createMyPalette(options.NumColors, all...))
+	}
+
+	g := &gif.GIF{LoopCount: a.loopCount, Config: image.Config{Width: size.X, Height: size.Y}}
+	if shared != nil {
+		g.Config.ColorModel = shared
+	}
+	var previous *image.RGBA
+	for i, frame := range a.frames {
+		// the frames are the whole canvas, so the transparent pixels of the next frame need the cleared canvas
+		disposal := frame.Disposal
+		if i+1 < len(images) && !images[i+1].Opaque() {
+			disposal = DisposalBackground
+		}
+		rect := bounds
+		if previous != nil {
+			rect = changedBounds(previous, images[i])
+		}
+		sub := images[i].SubImage(rect)
+		palette := shared
+		if palette == nil {
+			palette = gifPalette(createMyPalette(options.NumColors, sub))
+		}
+		dst := image.NewPaletted(rect, palette)
+		gifDraw(dst, sub, options.Dither)
+
+		g.Image = append(g.Image, dst)
+		g.Delay = append(g.Delay, int((frame.Delay+5*time.Millisecond)/(10*time.Millisecond)))
+		switch disposal {
+		case DisposalBackground:
+			g.Disposal = append(g.Disposal, gif.DisposalBackground)
+		case DisposalPrevious:
+			g.Disposal = append(g.Disposal, gif.DisposalPrevious)
+		default:
+			g.Disposal = append(g.Disposal, gif.DisposalNone)
+		}
+		previous = nil
+		if disposal == DisposalNone {
+			previous = images[i]
+		}
+	}
+	return gif.EncodeAll(w, g)
+}
+
+// canvasImages return the frames drawn on the canvas of the size of the first frame
+func (a *animationConverter) canvasImages() []*image.RGBA {
+	bounds := image.Rectangle{Max: a.frames[0].Image.Bounds().Size()}
+	images := make([]*image.RGBA, len(a.frames))
+	for i, frame := range a.frames {
+		images[i] = image.NewRGBA(bounds)
+		draw.Draw(images[i], bounds, frame.Image, frame.Image.Bounds().Min, draw.Src)
+	}
+	return images
+}
+
+// gifPalette return the palette, or the transparent palette if the image has no colors of the full alpha or transparent
+func gifPalette(colors []color.Color) color.Palette {
+	if len(colors) == 0 {
+		return color.Palette{color.Transparent}
+	}
+	return colors
+}
+
+// changedBounds return the bounds of the pixels changed from the previous image, 1px if nothing is changed
+func changedBounds(previous, current *image.RGBA) image.Rectangle {
+	b := current.Bounds()
+	rect := image.Rectangle{Min: b.Max, Max: b.Min}
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			i := current.PixOffset(x, y)
+			if bytes.Equal(previous.Pix[i:i+4], current.Pix[i:i+4]) {
+				continue
+			}
+			if x < rect.Min.X {
+				rect.Min.X = x
+			}
+			if y < rect.Min.Y {
+				rect.Min.Y = y
+			}
+			if x >= rect.Max.X {
+				rect.Max.X = x + 1
+			}
+			if y >= rect.Max.Y {
+				rect.Max.Y = y + 1
+			}
+		}
+	}
+	if rect.Empty() {
+		return image.Rect(b.Min.X, b.Min.Y, b.Min.X+1, b.Min.Y+1)
+	}
+	return rect
+}
@@ -0,0 +1,244 @@
+package imgedit
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/gif"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/magiconair/properties/assert"
+)
+
+const AnimatedGifImagePath = "assets/image/gray.gif"
+
+// getTestFrames return the frames of the red square moving on the white background
+func getTestFrames() []Frame {
+	var frames []Frame
+	for i := 0; i < 3; i++ {
+		img := image.NewRGBA(image.Rect(0, 0, 40, 30))
+		draw.Draw(img, img.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+		draw.Draw(img, image.Rect(i*10, 5, i*10+10, 15), image.NewUniform(color.RGBA{R: 255, A: 255}), image.Point{}, draw.Src)
+		frames = append(frames, Frame{Image: img, Delay: time.Duration(i+1) * 100 * time.Millisecond})
+	}
+	return frames
+}
+
+func TestNewFileConverter_animation(t *testing.T) {
+	c, extension, err := NewFileConverter(AnimatedGifImagePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a, ok := c.(AnimationConverter)
+	if !ok {
+		t.Fatalf("NewFileConverter() = %T, want AnimationConverter", c)
+	}
+	assert.Equal(t, extension, Gif)
+	assert.Equal(t, len(a.Frames()), 2)
+	assert.Equal(t, a.Frames()[0].Delay, time.Second)
+	assert.Equal(t, a.LoopCount(), 0)
+
+	// the single frame gif is not the animation
+	c, _, err = NewFileConverter(SrcGifImagePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, ok = c.(AnimationConverter)
+	assert.Equal(t, ok, false)
+}
+
+func Test_newGifAnimation(t *testing.T) {
+	palette := color.Palette{color.Transparent, color.White, color.Black}
+	first := image.NewPaletted(image.Rect(0, 0, 4, 4), palette)
+	draw.Draw(first, first.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	second := image.NewPaletted(image.Rect(1, 1, 3, 3), palette)
+	second.SetColorIndex(1, 1, 2)
+	third := image.NewPaletted(image.Rect(0, 0, 1, 1), palette)
+	third.SetColorIndex(0, 0, 2)
+	g := &gif.GIF{
+		Image:     []*image.Paletted{first, second, third},
+		Delay:     []int{10, 20, 30},
+		Disposal:  []byte{gif.DisposalNone, gif.DisposalPrevious, gif.DisposalBackground},
+		LoopCount: 2,
+	}
+	a := newGifAnimation(g)
+	frames := a.Frames()
+	assert.Equal(t, len(frames), 3)
+	assert.Equal(t, a.LoopCount(), 2)
+	assert.Equal(t, frames[1].Delay, 200*time.Millisecond)
+	assert.Equal(t, frames[1].Disposal, DisposalPrevious)
+	// the transparent pixels of the second frame keep the first frame
+	assert.Equal(t, color.RGBAModel.Convert(frames[1].Image.At(1, 1)), color.RGBA{A: 255})
+	assert.Equal(t, color.RGBAModel.Convert(frames[1].Image.At(2, 2)), color.RGBA{R: 255, G: 255, B: 255, A: 255})
+	// the second frame is disposed to the first frame
+	assert.Equal(t, color.RGBAModel.Convert(frames[2].Image.At(1, 1)), color.RGBA{R: 255, G: 255, B: 255, A: 255})
+	assert.Equal(t, color.RGBAModel.Convert(frames[2].Image.At(0, 0)), color.RGBA{A: 255})
+}
+
+func TestNewAnimation(t *testing.T) {
+	small := image.NewRGBA(image.Rect(0, 0, 10, 10))
+	draw.Draw(small, small.Bounds(), image.NewUniform(color.Black), image.Point{}, draw.Src)
+	wide := image.NewRGBA(image.Rect(0, 0, 40, 20))
+	type args struct {
+		options *AnimationOptions
+	}
+	tests := []struct {
+		name       string
+		args       args
+		wantSize   image.Point
+		wantDelays []time.Duration
+		wantLoop   int
+		wantCorner color.RGBA
+	}{
+		{
+			name:       "default",
+			args:       args{options: nil},
+			wantSize:   image.Point{X: 40, Y: 20},
+			wantDelays: []time.Duration{DefaultFrameDelay, DefaultFrameDelay},
+			wantLoop:   0,
+			wantCorner: color.RGBA{},
+		},
+		{
+			name:       "size and delays",
+			args:       args{options: &AnimationOptions{Width: 20, Delay: time.Second, Delays: []time.Duration{50 * time.Millisecond}, LoopCount: -1}},
+			wantSize:   image.Point{X: 20, Y: 20},
+			wantDelays: []time.Duration{50 * time.Millisecond, time.Second},
+			wantLoop:   -1,
+			wantCorner: color.RGBA{A: 255},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := NewAnimation([]image.Image{small, wide}, tt.args.options)
+			assert.Equal(t, a.LoopCount(), tt.wantLoop)
+			for i, frame := range a.Frames() {
+				assert.Equal(t, frame.Image.Bounds().Size(), tt.wantSize)
+				assert.Equal(t, frame.Delay, tt.wantDelays[i])
+			}
+			// the small image is fitted at the center
+			center := tt.wantSize.Div(2)
+			assert.Equal(t, color.RGBAModel.Convert(a.Frames()[0].Image.At(center.X, center.Y)), color.RGBA{A: 255})
+			assert.Equal(t, color.RGBAModel.Convert(a.Frames()[0].Image.At(0, 0)), tt.wantCorner)
+		})
+	}
+}
+
+func TestNewAnimationFromDir(t *testing.T) {
+	dir := t.TempDir()
+	for i, name := range []string{"frame10.png", "frame2.png", "frame1.png"} {
+		img := image.NewRGBA(image.Rect(0, 0, 4, 4))
+		img.Set(0, 0, color.RGBA{R: uint8(i), A: 255})
+		f, err := os.Create(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = png.Encode(f, img); err != nil {
+			t.Fatal(err)
+		}
+		f.Close()
+	}
+	if err := os.WriteFile(filepath.Join(dir, "readme.txt"), []byte("not image"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	a, err := NewAnimationFromDir(dir, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var reds []uint8
+	for _, frame := range a.Frames() {
+		reds = append(reds, color.RGBAModel.Convert(frame.Image.At(0, 0)).(color.RGBA).R)
+	}
+	assert.Equal(t, reds, []uint8{2, 1, 0})
+
+	_, err = NewAnimationFromDir(t.TempDir(), nil)
+	assert.Equal(t, err != nil, true)
+}
+
+func Test_naturalLess(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{a: "frame2.png", b: "frame10.png", want: true},
+		{a: "frame10.png", b: "frame2.png", want: false},
+		{a: "frame02.png", b: "frame3.png", want: true},
+		{a: "a.png", b: "b.png", want: true},
+		{a: "frame", b: "frame1", want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.a+" "+tt.b, func(t *testing.T) {
+			assert.Equal(t, naturalLess(tt.a, tt.b), tt.want)
+		})
+	}
+}
+
+func Test_animationConverter_Resize(t *testing.T) {
+	a := NewAnimationConverter(getTestFrames(), 0)
+	a.Resize(20, 15)
+	for _, frame := range a.Frames() {
+		assert.Equal(t, frame.Image.Bounds(), image.Rect(0, 0, 20, 15))
+	}
+	assert.Equal(t, a.Convert(), a.Frames()[0].Image)
+}
+
+func Test_animationConverter_WriteGif(t *testing.T) {
+	type args struct {
+		options *GifOptions
+	}
+	tests := []struct {
+		name string
+		args args
+	}{
+		{
+			name: "palette of each frame",
+			args: args{options: nil},
+		},
+		{
+			name: "shared palette",
+			args: args{options: &GifOptions{SharedPalette: true}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			frames := getTestFrames()
+			w := &bytes.Buffer{}
+			if err := NewAnimationConverter(frames, 3).WriteGif(w, tt.args.options); err != nil {
+				t.Fatal(err)
+			}
+			g, err := gif.DecodeAll(bytes.NewReader(w.Bytes()))
+			if err != nil {
+				t.Fatal(err)
+			}
+			assert.Equal(t, g.LoopCount, 3)
+			assert.Equal(t, g.Delay, []int{10, 20, 30})
+			// the frames after the first are cropped to the moved square
+			assert.Equal(t, g.Image[1].Bounds(), image.Rect(0, 5, 20, 15))
+			// the shared palette is written as the global color table
+			assert.Equal(t, len(g.Config.ColorModel.(color.Palette)) > 0, tt.args.options != nil)
+
+			a, _, ok := decodeAnimation(w.Bytes())
+			if !ok {
+				t.Fatal("decodeAnimation() is not ok")
+			}
+			for i, frame := range a.Frames() {
+				want := frames[i].Image.(*image.RGBA)
+				assert.Equal(t, frame.Image.(*image.RGBA).Pix, want.Pix)
+			}
+		})
+	}
+}
+
+func Test_changedBounds(t *testing.T) {
+	previous := image.NewRGBA(image.Rect(0, 0, 10, 10))
+	current := copyRGBA(previous)
+	assert.Equal(t, changedBounds(previous, current), image.Rect(0, 0, 1, 1))
+	current.Set(3, 4, color.White)
+	current.Set(6, 2, color.White)
+	assert.Equal(t, changedBounds(previous, current), image.Rect(3, 2, 7, 5))
+}
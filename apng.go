@@ -0,0 +1,406 @@
+package imgedit
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"image"
+	"image/draw"
+	"image/png"
+	"io"
+	"time"
+)
+
+// pngSignature the first 8 bytes of png
+const pngSignature = "\x89PNG\r\n\x1a\n"
+
+const (
+	apngDisposeNone       = 0
+	apngDisposeBackground = 1
+	apngDisposePrevious   = 2
+	apngBlendSource       = 0
+)
+
+// pngChunk the type and the data of the chunk of png
+type pngChunk struct {
+	typ  string
+	data []byte
+}
+
+// readPngChunks return the chunks of png until IEND
+func readPngChunks(data []byte) ([]pngChunk, error) {
+	if !bytes.HasPrefix(data, []byte(pngSignature)) {
+		return nil, errors.New("png: invalid signature")
+	}
+	var chunks []pngChunk
+	for pos := len(pngSignature); pos+8 <= len(data); {
+		length := int(binary.BigEndian.Uint32(data[pos:]))
+		typ := string(data[pos+4 : pos+8])
+		if length < 0 || pos+12+length > len(data) {
+			return nil, errors.New("png: chunk is too short")
+		}
+		chunks = append(chunks, pngChunk{typ: typ, data: data[pos+8 : pos+8+length]})
+		pos += 12 + length
+		if typ == "IEND" {
+			break
+		}
+	}
+	return chunks, nil
+}
+
+// writePngChunk write the chunk with the length and crc
+func writePngChunk(w *bytes.Buffer, typ string, data []byte) {
+	var length [4]byte
+	binary.BigEndian.PutUint32(length[:], uint32(len(data)))
+	w.Write(length[:])
+	crc := crc32.NewIEEE()
+	crc.Write([]byte(typ))
+	crc.Write(data)
+	w.WriteString(typ)
+	w.Write(data)
+	var sum [4]byte
+	binary.BigEndian.PutUint32(sum[:], crc.Sum32())
+	w.Write(sum[:])
+}
+
+// decodeApng return animationConverter of the animated png, nil if the png is not animated.
+// the default image is skipped if it is not the first frame.
+func decodeApng(data []byte) (*animationConverter, error) {
+	chunks, err := readPngChunks(data)
+	if err != nil {
+		return nil, err
+	}
+	type apngFrame struct {
+		control []byte
+		data    []byte
+	}
+	var ihdr []byte
+	var header []pngChunk
+	var frames []*apngFrame
+	var current *apngFrame
+	plays, animated, seenData := 0, false, false
+	for _, chunk := range chunks {
+		switch chunk.typ {
+		case "IHDR":
+			ihdr = chunk.data
+		case "acTL":
+			if len(chunk.data) < 8 {
+				return nil, errors.New("apng: acTL is too short")
+			}
+			animated = true
+			plays = int(binary.BigEndian.Uint32(chunk.data[4:]))
+		case "fcTL":
+			if len(chunk.data) < 26 {
+				return nil, errors.New("apng: fcTL is too short")
+			}
+			current = &apngFrame{control: chunk.data}
+			frames = append(frames, current)
+		case "IDAT":
+			seenData = true
+			if current != nil {
+				current.data = append(current.data, chunk.data...)
+			}
+		case "fdAT":
+			if current != nil && len(chunk.data) >= 4 {
+				current.data = append(current.data, chunk.data[4:]...)
+			}
+		case "IEND":
+		default:
+			// the chunks before the image data such as PLTE and tRNS are shared by the frames
+			if !seenData {
+				header = append(header, chunk)
+			}
+		}
+	}
+	if !animated || len(frames) == 0 {
+		return nil, nil
+	}
+	if len(ihdr) < 13 {
+		return nil, errors.New("apng: IHDR is too short")
+	}
+
+	bounds := image.Rect(0, 0, int(binary.BigEndian.Uint32(ihdr[0:])), int(binary.BigEndian.Uint32(ihdr[4:])))
+	a := &animationConverter{loopCount: apngLoopCount(plays)}
+	canvas := image.NewRGBA(bounds)
+	for i, frame := range frames {
+		c := frame.control
+		width, height := binary.BigEndian.Uint32(c[4:]), binary.BigEndian.Uint32(c[8:])
+		x, y := int(binary.BigEndian.Uint32(c[12:])), int(binary.BigEndian.Uint32(c[16:]))
+		delayNum, delayDen := binary.BigEndian.Uint16(c[20:]), binary.BigEndian.Uint16(c[22:])
+
+		// the frame is decoded as the png of the frame size
+		b := &bytes.Buffer{}
+		b.WriteString(pngSignature)
+		frameHeader := append([]byte(nil), ihdr...)
+		binary.BigEndian.PutUint32(frameHeader[0:], width)
+		binary.BigEndian.PutUint32(frameHeader[4:], height)
+		writePngChunk(b, "IHDR", frameHeader)
+		for _, chunk := range header {
+			writePngChunk(b, chunk.typ, chunk.data)
+		}
+		writePngChunk(b, "IDAT", frame.data)
+		writePngChunk(b, "IEND", nil)
+		img, err := png.Decode(b)
+		if err != nil {
+			return nil, err
+		}
+
+		disposal := DisposalNone
+		switch c[24] {
+		case apngDisposeBackground:
+			disposal = DisposalBackground
+		case apngDisposePrevious:
+			// the previous of the first frame is the cleared canvas
+			disposal = DisposalPrevious
+			if i == 0 {
+				disposal = DisposalBackground
+			}
+		}
+		op := draw.Over
+		if c[25] == apngBlendSource {
+			op = draw.Src
+		}
+		if delayDen == 0 {
+			delayDen = 100
+		}
+		rect := image.Rect(x, y, x+int(width), y+int(height))
+		var whole *image.RGBA
+		whole, canvas = drawFrame(canvas, img, rect, op, disposal)
+		a.frames = append(a.frames, Frame{Image: whole, Delay: time.Duration(delayNum) * time.Second / time.Duration(delayDen), Disposal: disposal})
+	}
+	return a, nil
+}
+
+// apngLoopCount return LoopCount of the number of the plays, 0 plays forever
+func apngLoopCount(plays int) int {
+	if plays == 0 {
+		return 0
+	}
+	return plays - 1
+}
+
+// WriteApng write all frames as the animated png,
+// the frame after the frame left as it is is cropped to the changed area.
+func (a *animationConverter) WriteApng(w io.Writer) error {
+	return a.writeApng(w, png.DefaultCompression)
+}
+
+// writeApng write the animated png compressed with the level same as png.Encoder
+func (a *animationConverter) writeApng(w io.Writer, level png.CompressionLevel) error {
+	if len(a.frames) == 0 {
+		return errors.New("apng: animation has no frames")
+	}
+	images := a.canvasImages()
+	bounds := images[0].Bounds()
+	if bounds.Empty() {
+		return errors.New("apng: image is empty")
+	}
+	// the alpha channel is written only if any frame has transparency
+	opaque := true
+	for _, img := range images {
+		opaque = opaque && img.Opaque()
+	}
+
+	b := &bytes.Buffer{}
+	b.WriteString(pngSignature)
+	ihdr := make([]byte, 13)
+	binary.BigEndian.PutUint32(ihdr[0:], uint32(bounds.Dx()))
+	binary.BigEndian.PutUint32(ihdr[4:], uint32(bounds.Dy()))
+	ihdr[8] = 8
+	ihdr[9] = 6
+	if opaque {
+		ihdr[9] = 2
+	}
+	writePngChunk(b, "IHDR", ihdr)
+
+	plays := 0
+	if a.loopCount < 0 {
+		plays = 1
+	} else if a.loopCount > 0 {
+		plays = a.loopCount + 1
+	}
+	actl := make([]byte, 8)
+	binary.BigEndian.PutUint32(actl[0:], uint32(len(images)))
+	binary.BigEndian.PutUint32(actl[4:], uint32(plays))
+	writePngChunk(b, "acTL", actl)
+
+	sequence := uint32(0)
+	var previous *image.RGBA
+	for i, frame := range a.frames {
+		rect := bounds
+		if previous != nil {
+			rect = changedBounds(previous, images[i])
+		}
+		fctl := make([]byte, 26)
+		binary.BigEndian.PutUint32(fctl[0:], sequence)
+		binary.BigEndian.PutUint32(fctl[4:], uint32(rect.Dx()))
+		binary.BigEndian.PutUint32(fctl[8:], uint32(rect.Dy()))
+		binary.BigEndian.PutUint32(fctl[12:], uint32(rect.Min.X))
+		binary.BigEndian.PutUint32(fctl[16:], uint32(rect.Min.Y))
+		delayNum, delayDen := apngDelay(frame.Delay)
+		binary.BigEndian.PutUint16(fctl[20:], delayNum)
+		binary.BigEndian.PutUint16(fctl[22:], delayDen)
+		switch frame.Disposal {
+		case DisposalBackground:
+			fctl[24] = apngDisposeBackground
+		case DisposalPrevious:
+			fctl[24] = apngDisposePrevious
+		}
+		// the frames are the whole canvas, so the pixels are replaced including the alpha
+		fctl[25] = apngBlendSource
+		writePngChunk(b, "fcTL", fctl)
+		sequence++
+
+		data, err := pngImageData(images[i], rect, !opaque, level)
+		if err != nil {
+			return err
+		}
+		if i == 0 {
+			writePngChunk(b, "IDAT", data)
+		} else {
+			fdat := make([]byte, 4, 4+len(data))
+			binary.BigEndian.PutUint32(fdat, sequence)
+			writePngChunk(b, "fdAT", append(fdat, data...))
+			sequence++
+		}
+
+		previous = nil
+		if frame.Disposal == DisposalNone {
+			previous = images[i]
+		}
+	}
+	writePngChunk(b, "IEND", nil)
+	_, err := w.Write(b.Bytes())
+	return err
+}
+
+// apngDelay return the numerator and the denominator of the delay seconds
+func apngDelay(delay time.Duration) (uint16, uint16) {
+	if ms := delay.Milliseconds(); ms <= 0xffff {
+		return uint16(ms), 1000
+	}
+	cs := delay.Milliseconds() / 10
+	if cs > 0xffff {
+		cs = 0xffff
+	}
+	return uint16(cs), 100
+}
+
+// pngImageData return the zlib compressed rows of the area of the image, 8 bits RGB or RGBA without premultiplied alpha.
+// the filter of each row is chosen by the smallest sum of the absolute values like image/png.
+func pngImageData(img *image.RGBA, rect image.Rectangle, alpha bool, level png.CompressionLevel) ([]byte, error) {
+	bpp := 3
+	if alpha {
+		bpp = 4
+	}
+	rowSize := rect.Dx() * bpp
+	current, previous := make([]byte, rowSize), make([]byte, rowSize)
+	filtered := make([]byte, rowSize+1)
+	b := &bytes.Buffer{}
+	zw, err := zlib.NewWriterLevel(b, zlibLevel(level))
+	if err != nil {
+		return nil, err
+	}
+	for y := rect.Min.Y; y < rect.Max.Y; y++ {
+		for x := rect.Min.X; x < rect.Max.X; x++ {
+			p := img.Pix[img.PixOffset(x, y):]
+			r, g, bl, al := uint32(p[0]), uint32(p[1]), uint32(p[2]), uint32(p[3])
+			if al != 0xff && al != 0 {
+				// same as color.NRGBAModel
+				r, g, bl = (r*0x101*0xffff/(al*0x101))>>8, (g*0x101*0xffff/(al*0x101))>>8, (bl*0x101*0xffff/(al*0x101))>>8
+			}
+			i := (x - rect.Min.X) * bpp
+			current[i], current[i+1], current[i+2] = byte(r), byte(g), byte(bl)
+			if alpha {
+				current[i+3] = byte(al)
+			}
+		}
+		pngFilter(filtered, current, previous, bpp)
+		if _, err := zw.Write(filtered); err != nil {
+			return nil, err
+		}
+		current, previous = previous, current
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+// zlibLevel return the zlib level of the png compression level
+func zlibLevel(level png.CompressionLevel) int {
+	switch level {
+	case png.NoCompression:
+		return zlib.NoCompression
+	case png.BestSpeed:
+		return zlib.BestSpeed
+	case png.BestCompression:
+		return zlib.BestCompression
+	default:
+		return zlib.DefaultCompression
+	}
+}
+
+// pngFilter write the filter type and the filtered row with the smallest sum of the absolute values
+func pngFilter(dst, current, previous []byte, bpp int) {
+	best := -1
+	candidate := make([]byte, len(current))
+	for filter := byte(0); filter < 5; filter++ {
+		sum := 0
+		for i, c := range current {
+			var left, up, upLeft byte
+			if i >= bpp {
+				left, upLeft = current[i-bpp], previous[i-bpp]
+			}
+			up = previous[i]
+			var v byte
+			switch filter {
+			case 0:
+				v = c
+			case 1:
+				v = c - left
+			case 2:
+				v = c - up
+			case 3:
+				v = c - byte((int(left)+int(up))/2)
+			case 4:
+				v = c - paeth(left, up, upLeft)
+			}
+			candidate[i] = v
+			if int8(v) < 0 {
+				sum -= int(int8(v))
+			} else {
+				sum += int(v)
+			}
+		}
+		if best < 0 || sum < best {
+			best = sum
+			dst[0] = filter
+			copy(dst[1:], candidate)
+		}
+	}
+}
+
+// paeth return the predictor of the paeth filter
+func paeth(a, b, c byte) byte {
+	p := int(a) + int(b) - int(c)
+	pa, pb, pc := p-int(a), p-int(b), p-int(c)
+	if pa < 0 {
+		pa = -pa
+	}
+	if pb < 0 {
+		pb = -pb
+	}
+	if pc < 0 {
+		pc = -pc
+	}
+	if pa <= pb && pa <= pc {
+		return a
+	}
+	if pb <= pc {
+		return b
+	}
+	return c
+}
@@ -0,0 +1,192 @@
+package imgedit
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+	"time"
+
+	"github.com/magiconair/properties/assert"
+)
+
+func Test_animationConverter_WriteApng(t *testing.T) {
+	transparent := getTestFrames()
+	for _, frame := range transparent {
+		img := frame.Image.(*image.RGBA)
+		img.Set(0, 0, color.Transparent)
+	}
+	type args struct {
+		frames    []Frame
+		loopCount int
+	}
+	tests := []struct {
+		name string
+		args args
+	}{
+		{
+			name: "opaque",
+			args: args{frames: getTestFrames(), loopCount: 0},
+		},
+		{
+			name: "transparent",
+			args: args{frames: transparent, loopCount: 2},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := &bytes.Buffer{}
+			if err := NewAnimationConverter(tt.args.frames, tt.args.loopCount).WriteApng(w); err != nil {
+				t.Fatal(err)
+			}
+			// the first frame is the default image for the decoders without apng
+			img, err := png.Decode(bytes.NewReader(w.Bytes()))
+			if err != nil {
+				t.Fatal(err)
+			}
+			assert.Equal(t, color.RGBAModel.Convert(img.At(5, 10)), color.RGBA{R: 255, A: 255})
+
+			a, extension, ok := decodeAnimation(w.Bytes())
+			if !ok {
+				t.Fatal("decodeAnimation() is not ok")
+			}
+			assert.Equal(t, extension, Png)
+			assert.Equal(t, a.LoopCount(), tt.args.loopCount)
+			assert.Equal(t, len(a.Frames()), len(tt.args.frames))
+			for i, frame := range a.Frames() {
+				assert.Equal(t, frame.Image.(*image.RGBA).Pix, tt.args.frames[i].Image.(*image.RGBA).Pix)
+				assert.Equal(t, frame.Delay, tt.args.frames[i].Delay)
+			}
+		})
+	}
+}
+
+func Test_decodeApng(t *testing.T) {
+	red, blue := color.RGBA{R: 255, A: 255}, color.RGBA{B: 255, A: 255}
+	first := image.NewRGBA(image.Rect(0, 0, 4, 4))
+	for i := 0; i < len(first.Pix); i += 4 {
+		copy(first.Pix[i:], []byte{255, 0, 0, 255})
+	}
+	second := image.NewRGBA(image.Rect(0, 0, 2, 2))
+	second.Set(0, 0, blue)
+
+	b := &bytes.Buffer{}
+	b.WriteString(pngSignature)
+	writePngChunk(b, "IHDR", []byte{0, 0, 0, 4, 0, 0, 0, 4, 8, 6, 0, 0, 0})
+	writePngChunk(b, "acTL", []byte{0, 0, 0, 3, 0, 0, 0, 1})
+	frameControl := func(sequence, size, offset int, delayNum, delayDen uint16, dispose, blend byte) []byte {
+		c := make([]byte, 26)
+		binary.BigEndian.PutUint32(c[0:], uint32(sequence))
+		binary.BigEndian.PutUint32(c[4:], uint32(size))
+		binary.BigEndian.PutUint32(c[8:], uint32(size))
+		binary.BigEndian.PutUint32(c[12:], uint32(offset))
+		binary.BigEndian.PutUint32(c[16:], uint32(offset))
+		binary.BigEndian.PutUint16(c[20:], delayNum)
+		binary.BigEndian.PutUint16(c[22:], delayDen)
+		c[24], c[25] = dispose, blend
+		return c
+	}
+	frameData := func(sequence int, img *image.RGBA) []byte {
+		data, err := pngImageData(img, img.Bounds(), true, png.DefaultCompression)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return append([]byte{0, 0, 0, byte(sequence)}, data...)
+	}
+	writePngChunk(b, "fcTL", frameControl(0, 4, 0, 1, 10, apngDisposeNone, apngBlendSource))
+	data, _ := pngImageData(first, first.Bounds(), true, png.DefaultCompression)
+	writePngChunk(b, "IDAT", data)
+	// the transparent pixels of the second frame are blended over the first frame, and disposed to the first frame
+	writePngChunk(b, "fcTL", frameControl(1, 2, 1, 0, 0, apngDisposePrevious, 1))
+	writePngChunk(b, "fdAT", frameData(2, second))
+	writePngChunk(b, "fcTL", frameControl(3, 1, 3, 500, 1000, apngDisposeNone, apngBlendSource))
+	writePngChunk(b, "fdAT", frameData(4, image.NewRGBA(image.Rect(0, 0, 1, 1))))
+	writePngChunk(b, "IEND", nil)
+
+	a, err := decodeApng(b.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	frames := a.Frames()
+	assert.Equal(t, len(frames), 3)
+	assert.Equal(t, a.LoopCount(), 0)
+	assert.Equal(t, frames[0].Delay, 100*time.Millisecond)
+	assert.Equal(t, frames[1].Delay, time.Duration(0))
+	assert.Equal(t, frames[2].Delay, 500*time.Millisecond)
+	assert.Equal(t, frames[1].Disposal, DisposalPrevious)
+	assert.Equal(t, frames[1].Image.At(1, 1), blue)
+	assert.Equal(t, frames[1].Image.At(2, 2), red)
+	assert.Equal(t, frames[2].Image.At(1, 1), red)
+	assert.Equal(t, frames[2].Image.At(3, 3), color.RGBA{})
+
+	// the png without acTL is not the animation
+	w := &bytes.Buffer{}
+	if err = png.Encode(w, first); err != nil {
+		t.Fatal(err)
+	}
+	a, err = decodeApng(w.Bytes())
+	assert.Equal(t, a == nil, true)
+	assert.Equal(t, err, nil)
+}
+
+func TestNewFileConverter_gifToApng(t *testing.T) {
+	c, _, err := NewFileConverter(AnimatedGifImagePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := &bytes.Buffer{}
+	if err = c.(AnimationConverter).WriteApng(w); err != nil {
+		t.Fatal(err)
+	}
+	got, extension, err := NewByteConverter(w)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, extension, Png)
+	a := got.(AnimationConverter)
+	want := c.(AnimationConverter).Frames()
+	assert.Equal(t, len(a.Frames()), len(want))
+	for i, frame := range a.Frames() {
+		assert.Equal(t, frame.Image.(*image.RGBA).Pix, want[i].Image.(*image.RGBA).Pix)
+		assert.Equal(t, frame.Delay, want[i].Delay)
+	}
+}
+
+func Test_apngDelay(t *testing.T) {
+	tests := []struct {
+		name    string
+		delay   time.Duration
+		wantNum uint16
+		wantDen uint16
+	}{
+		{name: "ms", delay: 1500 * time.Millisecond, wantNum: 1500, wantDen: 1000},
+		{name: "cs", delay: 100 * time.Second, wantNum: 10000, wantDen: 100},
+		{name: "max", delay: time.Hour, wantNum: 0xffff, wantDen: 100},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			num, den := apngDelay(tt.delay)
+			assert.Equal(t, num, tt.wantNum)
+			assert.Equal(t, den, tt.wantDen)
+		})
+	}
+}
+
+func Test_animationConverter_WriteAsWithOptions(t *testing.T) {
+	a := NewAnimationConverter(getTestFrames(), 0)
+	w, base := &bytes.Buffer{}, &bytes.Buffer{}
+	if err := a.WriteAsWithOptions(w, Png, &WriteOptions{CompressionLevel: png.BestCompression}); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.WriteAsWithOptions(base, Png, &WriteOptions{CompressionLevel: png.NoCompression}); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, w.Len() < base.Len(), true)
+	got, _, err := NewByteConverter(base)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, len(got.(AnimationConverter).Frames()), len(a.Frames()))
+}
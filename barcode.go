@@ -0,0 +1,258 @@
+package imgedit
+
+import (
+	"errors"
+	"image"
+	"image/color"
+	"image/draw"
+	"strings"
+)
+
+const (
+	// DefaultBarcodeHeight px of the bars used when the height is not specified in BarcodeOptions
+	DefaultBarcodeHeight = 100
+	// DefaultBarcodeQuietZone modules of the margin at the left and the right of the bars
+	DefaultBarcodeQuietZone = 10
+)
+
+// code128Patterns the widths of the bars and the spaces of the values, 106 is the stop pattern
+var code128Patterns = []string{
+	"212222", "222122", "222221", "121223", "121322", "131222", "122213", "122312", "132212", "221213",
+	"221312", "231212", "112232", "122132", "122231", "113222", "123122", "123221", "223211", "221132",
+	"221231", "213212", "223112", "312131", "311222", "321122", "321221", "312212", "322112", "322211",
+	"212123", "212321", "232121", "111323", "131123", "131321", "112313", "132113", "132311", "211313",
+	"231113", "231311", "112133", "112331", "132131", "113123", "113321", "133121", "313121", "211331",
+	"231131", "213113", "213311", "213131", "311123", "311321", "331121", "312113", "312311", "332111",
+	"314111", "221411", "431111", "111224", "111422", "121124", "121421", "141122", "141221", "112214",
+	"112412", "122114", "122411", "142112", "142211", "241211", "221114", "413111", "241112", "134111",
+	"111242", "121142", "121241", "114212", "124112", "124211", "411212", "421112", "421211", "212141",
+	"214121", "412121", "111143", "111341", "131141", "114113", "114311", "411113", "411311", "113141",
+	"114131", "311141", "411131", "211412", "211214", "211232", "2331112",
+}
+
+const (
+	code128CodeC  = 99
+	code128CodeB  = 100
+	code128StartA = 103
+	code128StartB = 104
+	code128StartC = 105
+	code128Stop   = 106
+)
+
+// ean13Patterns the modules of the digits in the L code, 1 is the bar
+var ean13Patterns = []string{
+	"0001101", "0011001", "0010011", "0111101", "0100011", "0110001", "0101111", "0111011", "0110111", "0001011",
+}
+
+// ean13Parities the codes of the left 6 digits by the first digit, G is the reversed R code
+var ean13Parities = []string{
+	"LLLLLL", "LLGLGG", "LLGGLG", "LLGGGL", "LGLLGG", "LGGLLG", "LGGGLL", "LGLGLG", "LGLGGL", "LGGLGL",
+}
+
+// BarcodeOptions options for NewCode128 and NewEAN13
+type BarcodeOptions struct {
+	// ModuleWidth px of the narrowest bar, default 4
+	ModuleWidth int
+	// Height px of the bars, default 100
+	Height int
+	// QuietZone modules of the margin at the left and the right, default 10. negative is no margin
+	QuietZone int
+	// Color of the bars, default color.Black
+	Color color.Color
+	// Background color of the spaces and the quiet zone, default color.White
+	Background color.Color
+	// Label is true to write the text under the bars
+	Label bool
+}
+
+func (o *BarcodeOptions) setDefault() {
+	if o.ModuleWidth == 0 {
+		o.ModuleWidth = DefaultModuleSize
+	}
+	if o.Height == 0 {
+		o.Height = DefaultBarcodeHeight
+	}
+	if o.QuietZone == 0 {
+		o.QuietZone = DefaultBarcodeQuietZone
+	}
+	if o.QuietZone < 0 {
+		o.QuietZone = 0
+	}
+	if o.Color == nil {
+		o.Color = color.Black
+	}
+	if o.Background == nil {
+		o.Background = color.White
+	}
+}
+
+// NewCode128 return the image of the Code 128 barcode of the ASCII text,
+// the runs of the digits are packed into the code set C.
+func NewCode128(text string, options *BarcodeOptions) (image.Image, error) {
+	values, err := code128Values(text)
+	if err != nil {
+		return nil, err
+	}
+	var modules []bool
+	for _, v := range values {
+		modules = appendWidths(modules, code128Patterns[v])
+	}
+	return drawBars(modules, text, options), nil
+}
+
+// code128Values return the values of the text with the start, the check and the stop
+func code128Values(text string) ([]int, error) {
+	if text == "" {
+		return nil, errors.New("text is empty")
+	}
+	for _, r := range text {
+		if r < ' ' || r > '~' {
+			return nil, errors.New("code128 supports only printable ASCII characters")
+		}
+	}
+	var values []int
+	codeC := false
+	for i := 0; i < len(text); {
+		digits := len(text[i:]) - len(strings.TrimLeft(text[i:], "0123456789"))
+		// the code set C is shorter for 4 digits at the start or the end, and 6 digits in the middle
+		useC := digits >= 6 || (digits >= 4 && (i == 0 || i+digits == len(text)))
+		switch {
+		case len(values) == 0 && useC:
+			values, codeC = append(values, code128StartC), true
+		case len(values) == 0:
+			values = append(values, code128StartB)
+		case useC && !codeC:
+			values, codeC = append(values, code128CodeC), true
+		case !useC && codeC:
+			values, codeC = append(values, code128CodeB), false
+		}
+		if codeC {
+			// the odd digit is left for the code set B
+			for n := digits / 2; n > 0; n-- {
+				values = append(values, int(text[i]-'0')*10+int(text[i+1]-'0'))
+				i += 2
+			}
+			if digits%2 == 1 {
+				values, codeC = append(values, code128CodeB), false
+			}
+			continue
+		}
+		values = append(values, int(text[i]-' '))
+		i++
+	}
+	check := values[0]
+	for i, v := range values[1:] {
+		check += (i + 1) * v
+	}
+	return append(values, check%103, code128Stop), nil
+}
+
+// NewEAN13 return the image of the EAN-13 barcode of the 12 digits, or the 13 digits with the check digit
+func NewEAN13(digits string, options *BarcodeOptions) (image.Image, error) {
+	if strings.Trim(digits, "0123456789") != "" || (len(digits) != 12 && len(digits) != 13) {
+		return nil, errors.New("ean13 needs 12 or 13 digits")
+	}
+	check := gtinCheckDigit(digits[:12])
+	if len(digits) == 13 && digits[12] != check {
+		return nil, errors.New("ean13 check digit is wrong")
+	}
+	digits = digits[:12] + string(check)
+
+	bits := "101"
+	parity := ean13Parities[digits[0]-'0']
+	for i, d := range digits[1:7] {
+		pattern := ean13Patterns[d-'0']
+		if parity[i] == 'G' {
+			pattern = reverseString(invertBits(pattern))
+		}
+		bits += pattern
+	}
+	bits += "01010"
+	for _, d := range digits[7:] {
+		bits += invertBits(ean13Patterns[d-'0'])
+	}
+	bits += "101"
+
+	modules := make([]bool, len(bits))
+	for i, b := range bits {
+		modules[i] = b == '1'
+	}
+	return drawBars(modules, digits, options), nil
+}
+
+// gtinCheckDigit return the check digit of the digits of EAN-13 or EAN-8,
+// the weight is 3 and 1 alternately from the last digit.
+func gtinCheckDigit(digits string) byte {
+	sum := 0
+	for i, d := range digits {
+		weight := 1
+		if (len(digits)-i)%2 == 1 {
+			weight = 3
+		}
+		sum += int(d-'0') * weight
+	}
+	return byte('0' + (10-sum%10)%10)
+}
+
+// appendWidths add the modules of the widths of the bars and the spaces in turn
+func appendWidths(modules []bool, widths string) []bool {
+	for i, w := range widths {
+		for n := 0; n < int(w-'0'); n++ {
+			modules = append(modules, i%2 == 0)
+		}
+	}
+	return modules
+}
+
+// invertBits return the bits with 0 and 1 swapped
+func invertBits(bits string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '0' {
+			return '1'
+		}
+		return '0'
+	}, bits)
+}
+
+// reverseString return the string in the reverse order
+func reverseString(s string) string {
+	b := []byte(s)
+	for i, j := 0, len(b)-1; i < j; i, j = i+1, j-1 {
+		b[i], b[j] = b[j], b[i]
+	}
+	return string(b)
+}
+
+// drawBars return the image of the modules, true is the bar
+func drawBars(modules []bool, text string, options *BarcodeOptions) image.Image {
+	if options == nil {
+		options = &BarcodeOptions{}
+	}
+	options.setDefault()
+	width := (len(modules) + options.QuietZone*2) * options.ModuleWidth
+	height := options.Height
+	labelHeight := 0
+	if options.Label {
+		labelHeight = options.ModuleWidth * 10
+		height += labelHeight
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(dst, dst.Bounds(), image.NewUniform(options.Background), image.Point{}, draw.Src)
+	for i, isBar := range modules {
+		if isBar {
+			x := (options.QuietZone + i) * options.ModuleWidth
+			draw.Draw(dst, image.Rect(x, 0, x+options.ModuleWidth, options.Height), image.NewUniform(options.Color), image.Point{}, draw.Src)
+		}
+	}
+	if !options.Label {
+		return dst
+	}
+	c := NewConverter(dst)
+	box := image.Rect(0, options.Height, width, height)
+	c.AddString(text, &StringOptions{
+		Font:        &Font{Name: FontGoRegular, Size: float64(labelHeight) * 0.8, Color: options.Color},
+		Box:         &box,
+		ShrinkToFit: true,
+	})
+	return c.Convert()
+}
@@ -0,0 +1,95 @@
+package imgedit
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/magiconair/properties/assert"
+)
+
+func Test_code128Patterns(t *testing.T) {
+	seen := map[string]bool{}
+	for i, pattern := range code128Patterns {
+		sum := 0
+		for _, w := range pattern {
+			sum += int(w - '0')
+		}
+		want := 11
+		if i == code128Stop {
+			want = 13
+		}
+		assert.Equal(t, sum, want, pattern)
+		assert.Equal(t, seen[pattern], false, pattern)
+		seen[pattern] = true
+	}
+	assert.Equal(t, len(code128Patterns), 107)
+}
+
+func Test_code128Values(t *testing.T) {
+	tests := []struct {
+		name    string
+		text    string
+		want    []int
+		wantErr bool
+	}{
+		{name: "code B", text: "AB", want: []int{104, 33, 34, 102, 106}},
+		{name: "code C", text: "1234", want: []int{105, 12, 34, 82, 106}},
+		{name: "digits at the end", text: "AB123456", want: []int{104, 33, 34, 99, 12, 34, 56, 26, 106}},
+		{name: "odd digits", text: "12345", want: []int{105, 12, 34, 100, 21, 54, 106}},
+		{name: "short digits", text: "A12", want: []int{104, 33, 17, 18, 19, 106}},
+		{name: "empty", text: "", wantErr: true},
+		{name: "not ASCII", text: "うさぎ", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := code128Values(tt.text)
+			assert.Equal(t, err != nil, tt.wantErr)
+			if !tt.wantErr {
+				assert.Equal(t, got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_gtinCheckDigit(t *testing.T) {
+	assert.Equal(t, gtinCheckDigit("400638133393"), byte('1'))
+	assert.Equal(t, gtinCheckDigit("490123456789"), byte('4'))
+	assert.Equal(t, gtinCheckDigit("9638507"), byte('4'))
+}
+
+func TestNewBarcodes(t *testing.T) {
+	tests := []struct {
+		name     string
+		encode   func(string, *BarcodeOptions) (image.Image, error)
+		text     string
+		options  *BarcodeOptions
+		wantSize image.Point
+		// wantQuietZone px of the left margin
+		wantQuietZone int
+		wantErr       bool
+	}{
+		{name: "code128", encode: NewCode128, text: "AB", wantSize: image.Point{X: (5*11 + 2 + 20) * 4, Y: 100}, wantQuietZone: 40},
+		{name: "code128 label", encode: NewCode128, text: "imgedit-0828", options: &BarcodeOptions{ModuleWidth: 2, Height: 50, Label: true}, wantSize: image.Point{X: (14*11 + 2 + 20) * 2, Y: 70}, wantQuietZone: 20},
+		{name: "ean13", encode: NewEAN13, text: "400638133393", wantSize: image.Point{X: (95 + 20) * 4, Y: 100}, wantQuietZone: 40},
+		{name: "ean13 check digit", encode: NewEAN13, text: "4006381333931", options: &BarcodeOptions{QuietZone: -1, Label: true}, wantSize: image.Point{X: 95 * 4, Y: 140}},
+		{name: "ean13 wrong check digit", encode: NewEAN13, text: "4006381333932", wantErr: true},
+		{name: "ean13 letters", encode: NewEAN13, text: "40063813339A", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			img, err := tt.encode(tt.text, tt.options)
+			assert.Equal(t, err != nil, tt.wantErr)
+			if tt.wantErr {
+				return
+			}
+			SaveTestImageAsPng(img)
+			assert.Equal(t, img.Bounds().Size(), tt.wantSize)
+			// the quiet zone is white and the first bar is black
+			if tt.wantQuietZone > 0 {
+				assert.Equal(t, color.GrayModel.Convert(img.At(tt.wantQuietZone-1, 0)), color.Gray{Y: 255})
+			}
+			assert.Equal(t, color.GrayModel.Convert(img.At(tt.wantQuietZone, 0)), color.Gray{})
+		})
+	}
+}
@@ -0,0 +1,82 @@
+package imgedit
+
+import (
+	"image"
+	"image/color"
+	"math"
+)
+
+// BlendMode how to mix the color of the paint with the color of the image
+type BlendMode int
+
+const (
+	// BlendNormal put the paint color over the image
+	BlendNormal BlendMode = iota
+	// BlendMultiply multiply the colors, the result is darker
+	BlendMultiply
+	// BlendScreen invert, multiply and invert the colors, the result is lighter
+	BlendScreen
+	// BlendOverlay multiply the dark colors and screen the light colors of the image
+	BlendOverlay
+	// BlendDarken choose the darker color
+	BlendDarken
+	// BlendLighten choose the lighter color
+	BlendLighten
+	// BlendSoftLight darken or lighten the image softly by the paint color
+	BlendSoftLight
+)
+
+// blend return the mixed value of the image value cb and the paint value cs, 0 <= cb, cs <= 1
+func (m BlendMode) blend(cb, cs float64) float64 {
+	switch m {
+	case BlendMultiply:
+		return cb * cs
+	case BlendScreen:
+		return cb + cs - cb*cs
+	case BlendOverlay:
+		if cb <= 0.5 {
+			return 2 * cb * cs
+		}
+		return BlendScreen.blend(2*cb-1, cs)
+	case BlendDarken:
+		return math.Min(cb, cs)
+	case BlendLighten:
+		return math.Max(cb, cs)
+	case BlendSoftLight:
+		if cs <= 0.5 {
+			return cb - (1-2*cs)*cb*(1-cb)
+		}
+		d := math.Sqrt(cb)
+		if cb <= 0.25 {
+			d = ((16*cb-12)*cb + 4) * cb
+		}
+		return cb + (2*cs-1)*(d-cb)
+	default:
+		return cs
+	}
+}
+
+// Overlay blend the paint like the gradient or the pattern over the image with the mode,
+// the alpha of the image is kept. opacity is 0 <= opacity <= 1
+func (c *converter) Overlay(paint image.Image, mode BlendMode, opacity float64) {
+	if paint == nil {
+		return
+	}
+	opacity = math.Max(0, math.Min(opacity, 1))
+	bounds := c.Bounds()
+	dst := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	dstSize := dst.Bounds().Size()
+	for x := 0; x < dstSize.X; x++ {
+		for y := 0; y < dstSize.Y; y++ {
+			base := color.NRGBA64Model.Convert(c.Image.At(bounds.Min.X+x, bounds.Min.Y+y)).(color.NRGBA64)
+			src := color.NRGBA64Model.Convert(paint.At(x, y)).(color.NRGBA64)
+			alpha := float64(src.A) / math.MaxUint16 * opacity
+			mix := func(cb, cs uint16) uint16 {
+				b, s := float64(cb)/math.MaxUint16, float64(cs)/math.MaxUint16
+				return uint16(math.Round((b*(1-alpha) + mode.blend(b, s)*alpha) * math.MaxUint16))
+			}
+			dst.Set(x, y, color.NRGBA64{R: mix(base.R, src.R), G: mix(base.G, src.G), B: mix(base.B, src.B), A: base.A})
+		}
+	}
+	c.Image = dst
+}
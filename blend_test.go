@@ -0,0 +1,94 @@
+package imgedit
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"testing"
+
+	"github.com/magiconair/properties/assert"
+)
+
+func TestBlendMode_blend(t *testing.T) {
+	type args struct {
+		cb, cs float64
+	}
+	tests := []struct {
+		name string
+		m    BlendMode
+		args args
+		want float64
+	}{
+		{name: "normal", m: BlendNormal, args: args{cb: 0.2, cs: 0.6}, want: 0.6},
+		{name: "multiply", m: BlendMultiply, args: args{cb: 0.5, cs: 0.5}, want: 0.25},
+		{name: "screen", m: BlendScreen, args: args{cb: 0.5, cs: 0.5}, want: 0.75},
+		{name: "overlay dark", m: BlendOverlay, args: args{cb: 0.25, cs: 0.5}, want: 0.25},
+		{name: "overlay light", m: BlendOverlay, args: args{cb: 0.75, cs: 0.5}, want: 0.75},
+		{name: "darken", m: BlendDarken, args: args{cb: 0.2, cs: 0.6}, want: 0.2},
+		{name: "lighten", m: BlendLighten, args: args{cb: 0.2, cs: 0.6}, want: 0.6},
+		{name: "soft light neutral", m: BlendSoftLight, args: args{cb: 0.3, cs: 0.5}, want: 0.3},
+		{name: "soft light white", m: BlendSoftLight, args: args{cb: 0.25, cs: 1}, want: 0.5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.m.blend(tt.args.cb, tt.args.cs), tt.want)
+		})
+	}
+}
+
+func Test_converter_Overlay(t *testing.T) {
+	red := color.RGBA{R: 255, A: 255}
+	gray := color.RGBA{R: 128, G: 128, B: 128, A: 255}
+	type args struct {
+		paint   image.Image
+		mode    BlendMode
+		opacity float64
+	}
+	tests := []struct {
+		name string
+		args args
+		want color.Color
+	}{
+		{
+			name: "normal",
+			args: args{paint: image.NewUniform(red), mode: BlendNormal, opacity: 1},
+			want: red,
+		},
+		{
+			name: "multiply",
+			args: args{paint: image.NewUniform(red), mode: BlendMultiply, opacity: 1},
+			want: color.RGBA{R: 128, A: 255},
+		},
+		{
+			name: "half opacity",
+			args: args{paint: image.NewUniform(color.White), mode: BlendNormal, opacity: 0.5},
+			want: color.RGBA{R: 192, G: 192, B: 192, A: 255},
+		},
+		{
+			name: "transparent paint",
+			args: args{paint: image.NewUniform(color.Transparent), mode: BlendScreen, opacity: 1},
+			want: gray,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			img := image.NewRGBA(image.Rect(0, 0, 20, 20))
+			draw.Draw(img, image.Rect(0, 0, 10, 20), image.NewUniform(gray), image.Point{}, draw.Src)
+			c := &converter{Image: img}
+			c.Overlay(tt.args.paint, tt.args.mode, tt.args.opacity)
+			dst := c.Convert()
+			assert.Equal(t, color.RGBAModel.Convert(dst.At(5, 5)), tt.want)
+			// the transparent area is kept
+			assert.Equal(t, color.RGBAModel.Convert(dst.At(15, 5)), color.RGBA{})
+		})
+	}
+}
+
+func Test_converter_Overlay_gradient(t *testing.T) {
+	c := NewConverter(GetPngImage())
+	size := c.Convert().Bounds().Size()
+	c.Overlay(NewRadialGradient(size.Div(2), float64(size.X)/2, EvenStops(color.Transparent, color.Black)), BlendNormal, 0.8)
+	img := c.Convert()
+	assert.Equal(t, img.Bounds().Size(), size)
+	SaveTestImageAsPng(img)
+}
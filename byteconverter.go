@@ -1,17 +1,19 @@
 package imgedit
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
 	"image"
 	"image/color"
 	"image/draw"
 	"image/gif"
-	"image/jpeg"
 	"image/png"
 	"io"
 	"math"
 	"sort"
+
+	_ "golang.org/x/image/webp"
 )
 
 // Extension is image file extension
@@ -26,36 +28,90 @@ var Jpeg = Extension("jpeg")
 // Gif is one of the supported extension
 var Gif = Extension("gif")
 
-// SupportedExtensions are supported extensions
-var SupportedExtensions = []Extension{
-	Png,
-	Jpeg,
-	Gif,
-}
+// Bmp is one of the supported extension
+var Bmp = Extension("bmp")
+
+// Tiff is one of the supported extension
+var Tiff = Extension("tiff")
+
+// Webp is one of the supported extension, written in the lossless format
+var Webp = Extension("webp")
+
+// Ico is one of the supported extension, the image larger than 256px is scaled down to fit
+var Ico = Extension("ico")
+
+// Pbm is one of the supported extension, written in black and white
+var Pbm = Extension("pbm")
+
+// Pgm is one of the supported extension, written in grayscale
+var Pgm = Extension("pgm")
 
-// SupportedExtension return true, if extension is in the SupportedExtensions
+// Ppm is one of the supported extension, written without the alpha
+var Ppm = Extension("ppm")
+
+// Pam is one of the supported extension, written with the alpha
+var Pam = Extension("pam")
+
+// Qoi is one of the supported extension
+var Qoi = Extension("qoi")
+
+// SupportedExtensions are the extensions of the registered formats, use RegisterFormat to add the extension
+var SupportedExtensions []Extension
+
+// SupportedExtension return true, if the format of the extension is registered
 func SupportedExtension(extension Extension) bool {
-	for _, e := range SupportedExtensions {
-		if e == extension {
-			return true
-		}
-	}
-	return false
+	_, ok := LookupFormat(extension)
+	return ok
 }
 
 // ByteConverter interface for image edit
 type ByteConverter interface {
 	Converter
 	WriteAs(io.Writer, Extension) error
+	WriteAsWithOptions(io.Writer, Extension, *WriteOptions) error
+	WriteWithinSize(io.Writer, Extension, int) (*SizeResult, error)
+	WriteWithinSizeWithOptions(io.Writer, Extension, int, *SizeOptions) (*SizeResult, error)
+}
+
+// WriteOptions options for WriteAsWithOptions, the options for the other extensions are ignored
+type WriteOptions struct {
+	// Quality of jpeg 1-100, default 100
+	Quality int
+	// CompressionLevel of png, the animated png and tiff, tiff is not compressed only with png.NoCompression
+	CompressionLevel png.CompressionLevel
+	// Gif options of the colors, the dither and the transparency, default 256 colors
+	Gif *GifOptions
+}
+
+func (o *WriteOptions) setDefault() {
+	if o.Quality < 1 || 100 < o.Quality {
+		o.Quality = 100
+	}
+	if o.Gif == nil {
+		o.Gif = &GifOptions{}
+	}
+	o.Gif.setDefault()
 }
 
 type byteConverter struct {
 	*converter
 }
 
-// NewByteConverter create byteConverter
+// NewByteConverter create byteConverter, or AnimationConverter if the image has multiple frames
 func NewByteConverter(r io.Reader) (ByteConverter, Extension, error) {
-	return newByteConverter(r)
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, "", err
+	}
+	if a, extension, ok := decodeAnimation(data); ok {
+		return a, extension, nil
+	}
+	return newByteConverter(bytes.NewReader(data))
+}
+
+// NewByteConverterFromImage create byteConverter from image
+func NewByteConverterFromImage(img image.Image) ByteConverter {
+	return &byteConverter{converter: &converter{img}}
 }
 
 func newByteConverter(r io.Reader) (*byteConverter, Extension, error) {
@@ -72,24 +128,36 @@ func newByteConverter(r io.Reader) (*byteConverter, Extension, error) {
 }
 
 func (b *byteConverter) WriteAs(writer io.Writer, extension Extension) error {
-	switch extension {
-	case Png:
-		return png.Encode(writer, b.Image)
-	case Jpeg:
-		return jpeg.Encode(writer, b.Image, &jpeg.Options{Quality: 100})
-	case Gif:
-		return gifEncode(writer, b.Image, &gif.Options{NumColors: 256})
-	default:
+	return b.WriteAsWithOptions(writer, extension, nil)
+}
+
+// WriteAsWithOptions write the image with the options of the extension
+func (b *byteConverter) WriteAsWithOptions(writer io.Writer, extension Extension, options *WriteOptions) error {
+	if options == nil {
+		options = &WriteOptions{}
+	}
+	options.setDefault()
+	format, ok := LookupFormat(extension)
+	if !ok || format.Encode == nil {
 		return errors.New("extension is unsupported")
 	}
+	return format.Encode(writer, b.Image, options)
 }
 
 // gifEncode wrap the original mainly due to transparency color issues.
-func gifEncode(w io.Writer, m image.Image, o *gif.Options) error {
+func gifEncode(w io.Writer, m image.Image, o *GifOptions) error {
+	opts := GifOptions{}
+	if o != nil {
+		opts = *o
+	}
+	opts.setDefault()
+	if opts.Background != nil {
+		m = flattenImage(m, opts.Background)
+	}
 	// if m.ColorModel().(color.Palette) is not satisfied, problems occur during image encoding
 	// e.g) gif.Encode transparent images.
 	if _, ok := m.ColorModel().(color.Palette); ok {
-		return gif.Encode(w, m, o)
+		return gif.Encode(w, m, &gif.Options{NumColors: opts.NumColors})
 	}
 
 	// Check for bounds and size restrictions.
@@ -98,19 +166,8 @@ func gifEncode(w io.Writer, m image.Image, o *gif.Options) error {
 		return errors.New("gif: image is too large to encode")
 	}
 
-	opts := gif.Options{}
-	if o != nil {
-		opts = *o
-	}
-	if opts.NumColors < 1 || 256 < opts.NumColors {
-		opts.NumColors = 256
-	}
-	if opts.Drawer == nil {
-		opts.Drawer = draw.FloydSteinberg
-	}
-
-	dst := image.NewPaletted(b, createMyPalette(m, o.NumColors))
-	myDraw(dst, m)
+	dst := image.NewPaletted(b, gifPalette(createMyPalette(opts.NumColors, m)))
+	gifDraw(dst, m, opts.Dither)
 	return gif.EncodeAll(w, &gif.GIF{
 		Image: []*image.Paletted{dst},
 		Delay: []int{0},
@@ -122,6 +179,34 @@ func gifEncode(w io.Writer, m image.Image, o *gif.Options) error {
 	})
 }
 
+// flattenImage return the image drawn over the background
+func flattenImage(m image.Image, background color.Color) *image.RGBA {
+	b := m.Bounds()
+	dst := image.NewRGBA(b)
+	draw.Draw(dst, b, image.NewUniform(background), image.Point{}, draw.Src)
+	draw.Draw(dst, b, m, b.Min, draw.Over)
+	return dst
+}
+
+// gifDraw draw the image with the palette, the pixels not opaque are the first color of the palette same as myDraw.
+// the opaque pixels are dithered by Floyd-Steinberg if dither is true.
+func gifDraw(dst *image.Paletted, src image.Image, dither bool) {
+	if !dither {
+		myDraw(dst, src)
+		return
+	}
+	b := src.Bounds()
+	rgba := image.NewRGBA(b)
+	draw.Draw(rgba, b, src, b.Min, draw.Src)
+	first := color.RGBAModel.Convert(dst.Palette[0]).(color.RGBA)
+	for i := 0; i < len(rgba.Pix); i += 4 {
+		if rgba.Pix[i+3] != 0xff {
+			rgba.Pix[i], rgba.Pix[i+1], rgba.Pix[i+2], rgba.Pix[i+3] = first.R, first.G, first.B, first.A
+		}
+	}
+	draw.FloydSteinberg.Draw(dst, b, rgba, b.Min)
+}
+
 type sortedColors map[color.Color]uint
 
 func (s sortedColors) Sort() []color.Color {
@@ -142,22 +227,24 @@ func (s sortedColors) Sort() []color.Color {
 	return colors
 }
 
-// createMyPalette create a palette with efficient colors to represent the image
-func createMyPalette(m image.Image, numColors int) []color.Color {
-	b := m.Bounds()
+// createMyPalette create a palette with efficient colors to represent the images
+func createMyPalette(numColors int, ms ...image.Image) []color.Color {
 	transparentColors := sortedColors{}
 	usedColors := sortedColors{}
-	for x := b.Min.X; x < b.Max.X; x++ {
-		for y := b.Min.Y; y < b.Max.Y; y++ {
-			// transparent colors are handled separately. draw.sqDiff would be
-			// a meaningless value in transparent colors. e.g(0xffff, 0xffff, 0xffff, 0)
-			c := m.At(x, y)
-			_, _, _, a := c.RGBA()
-			if a == 0 {
-				transparentColors[c]++
-			}
-			if a == math.MaxUint16 {
-				usedColors[c]++
+	for _, m := range ms {
+		b := m.Bounds()
+		for x := b.Min.X; x < b.Max.X; x++ {
+			for y := b.Min.Y; y < b.Max.Y; y++ {
+				// transparent colors are handled separately. draw.sqDiff would be
+				// a meaningless value in transparent colors. e.g(0xffff, 0xffff, 0xffff, 0)
+				c := m.At(x, y)
+				_, _, _, a := c.RGBA()
+				if a == 0 {
+					transparentColors[c]++
+				}
+				if a == math.MaxUint16 {
+					usedColors[c]++
+				}
 			}
 		}
 	}
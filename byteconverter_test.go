@@ -3,11 +3,16 @@ package imgedit
 import (
 	"bytes"
 	"image"
+	"image/color"
+	"image/draw"
 	"image/gif"
+	"image/png"
 	"io"
 	"os"
 	"reflect"
 	"testing"
+
+	"github.com/magiconair/properties/assert"
 )
 
 func TestNewByteConverter(t *testing.T) {
@@ -47,6 +52,30 @@ func TestNewByteConverter(t *testing.T) {
 	}
 }
 
+func TestNewByteConverterFromImage(t *testing.T) {
+	type args struct {
+		img image.Image
+	}
+	tests := []struct {
+		name string
+		args args
+		want ByteConverter
+	}{
+		{
+			name: "normal",
+			args: args{img: GetPngImage()},
+			want: &byteConverter{&converter{Image: GetPngImage()}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NewByteConverterFromImage(tt.args.img); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("NewByteConverterFromImage() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
 func TestSupportedExtension(t *testing.T) {
 	type args struct {
 		extension Extension
@@ -61,6 +90,11 @@ func TestSupportedExtension(t *testing.T) {
 			args: args{extension: Png},
 			want: true,
 		},
+		{
+			name: "webp",
+			args: args{extension: Webp},
+			want: true,
+		},
 		{
 			name: "unsupported extension",
 			args: args{extension: Extension("unsupported")},
@@ -76,10 +110,71 @@ func TestSupportedExtension(t *testing.T) {
 	}
 }
 
+func Test_byteConverter_WriteAs(t *testing.T) {
+	type args struct {
+		extension Extension
+	}
+	tests := []struct {
+		name    string
+		args    args
+		wantErr bool
+	}{
+		{
+			name:    "bmp",
+			args:    args{extension: Bmp},
+			wantErr: false,
+		},
+		{
+			name:    "tiff",
+			args:    args{extension: Tiff},
+			wantErr: false,
+		},
+		{
+			name:    "webp",
+			args:    args{extension: Webp},
+			wantErr: false,
+		},
+		{
+			name:    "unsupported extension",
+			args:    args{extension: Extension("unsupported")},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			src := image.NewNRGBA(image.Rect(0, 0, 30, 20))
+			for i := range src.Pix {
+				src.Pix[i] = uint8(i)
+			}
+			w := &bytes.Buffer{}
+			err := NewByteConverterFromImage(src).WriteAs(w, tt.args.extension)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("WriteAs() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+			got, extension, err := NewByteConverter(w)
+			if err != nil {
+				t.Errorf("NewByteConverter() error = %v", err)
+				return
+			}
+			if extension != tt.args.extension {
+				t.Errorf("NewByteConverter() extension = %v, want %v", extension, tt.args.extension)
+			}
+			want := color.NRGBAModel.Convert(src.At(7, 5))
+			if c := color.NRGBAModel.Convert(got.Convert().At(7, 5)); c != want {
+				t.Errorf("WriteAs() color = %v, want %v", c, want)
+			}
+		})
+	}
+}
+
 func Test_gifEncode(t *testing.T) {
 	type args struct {
 		m image.Image
-		o *gif.Options
+		o *GifOptions
 	}
 	tests := []struct {
 		name    string
@@ -94,7 +189,7 @@ func Test_gifEncode(t *testing.T) {
 		//},
 		{
 			name:    "over opts.NumColors",
-			args:    args{m: image.NewRGBA(image.Rect(0, 0, 100, 100)), o: &gif.Options{NumColors: 257}},
+			args:    args{m: image.NewRGBA(image.Rect(0, 0, 100, 100)), o: &GifOptions{NumColors: 257}},
 			wantErr: false,
 		},
 	}
@@ -117,3 +212,145 @@ func GetPngImageReadCloser() io.ReadCloser {
 	}
 	return p
 }
+
+func Test_byteConverter_WriteAsWithOptions(t *testing.T) {
+	// the gradient needs many colors and the compression
+	gradient := image.NewRGBA(image.Rect(0, 0, 64, 64))
+	for x := 0; x < 64; x++ {
+		for y := 0; y < 64; y++ {
+			gradient.Set(x, y, color.RGBA{R: uint8(x * 4), G: uint8(y * 4), B: 128, A: 255})
+		}
+	}
+	type args struct {
+		m         image.Image
+		extension Extension
+		options   *WriteOptions
+		base      *WriteOptions
+	}
+	tests := []struct {
+		name string
+		args args
+	}{
+		{
+			name: "jpeg quality",
+			args: args{m: gradient, extension: Jpeg, options: &WriteOptions{Quality: 30}, base: nil},
+		},
+		{
+			name: "png compression",
+			args: args{m: gradient, extension: Png, options: &WriteOptions{CompressionLevel: png.BestCompression}, base: &WriteOptions{CompressionLevel: png.NoCompression}},
+		},
+		{
+			name: "tiff compression",
+			args: args{m: GetPngImage(), extension: Tiff, options: nil, base: &WriteOptions{CompressionLevel: png.NoCompression}},
+		},
+		{
+			name: "gif colors",
+			args: args{m: gradient, extension: Gif, options: &WriteOptions{Gif: &GifOptions{NumColors: 4}}, base: nil},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewByteConverterFromImage(tt.args.m)
+			w, base := &bytes.Buffer{}, &bytes.Buffer{}
+			if err := c.WriteAsWithOptions(w, tt.args.extension, tt.args.options); err != nil {
+				t.Fatal(err)
+			}
+			if err := c.WriteAsWithOptions(base, tt.args.extension, tt.args.base); err != nil {
+				t.Fatal(err)
+			}
+			// the options make the file smaller
+			if w.Len() >= base.Len() {
+				t.Errorf("WriteAsWithOptions() size = %d, want less than %d", w.Len(), base.Len())
+			}
+			if _, _, err := NewByteConverter(w); err != nil {
+				t.Errorf("NewByteConverter() error = %v", err)
+			}
+		})
+	}
+}
+
+func Test_gifEncode_semiTransparent(t *testing.T) {
+	// the palette has no colors of the full alpha or transparent
+	src := image.NewRGBA(image.Rect(0, 0, 10, 10))
+	draw.Draw(src, src.Bounds(), image.NewUniform(color.NRGBA{R: 255, A: 128}), image.Point{}, draw.Src)
+	for _, dither := range []bool{false, true} {
+		w := &bytes.Buffer{}
+		c := NewByteConverterFromImage(src)
+		if err := c.WriteAsWithOptions(w, Gif, &WriteOptions{Gif: &GifOptions{Dither: dither}}); err != nil {
+			t.Fatal(err)
+		}
+		got, err := gif.Decode(w)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, color.RGBAModel.Convert(got.At(5, 5)), color.RGBA{})
+	}
+}
+
+func Test_gifEncode_options(t *testing.T) {
+	// the left half is transparent, the right half is gray
+	src := image.NewRGBA(image.Rect(0, 0, 20, 10))
+	for x := 10; x < 20; x++ {
+		for y := 0; y < 10; y++ {
+			src.Set(x, y, color.RGBA{R: 100, G: 100, B: 100, A: 255})
+		}
+	}
+	type args struct {
+		o *GifOptions
+	}
+	tests := []struct {
+		name      string
+		args      args
+		wantLeft  color.Color
+		wantRight color.Color
+	}{
+		{
+			name:      "transparency",
+			args:      args{o: nil},
+			wantLeft:  color.RGBA{},
+			wantRight: color.RGBA{R: 100, G: 100, B: 100, A: 255},
+		},
+		{
+			name:      "background",
+			args:      args{o: &GifOptions{Background: color.White}},
+			wantLeft:  color.RGBA{R: 255, G: 255, B: 255, A: 255},
+			wantRight: color.RGBA{R: 100, G: 100, B: 100, A: 255},
+		},
+		{
+			name:      "dither",
+			args:      args{o: &GifOptions{Dither: true}},
+			wantLeft:  color.RGBA{},
+			wantRight: color.RGBA{R: 100, G: 100, B: 100, A: 255},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := &bytes.Buffer{}
+			if err := gifEncode(w, src, tt.args.o); err != nil {
+				t.Fatal(err)
+			}
+			got, err := gif.Decode(w)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assert.Equal(t, color.RGBAModel.Convert(got.At(2, 2)), tt.wantLeft)
+			assert.Equal(t, color.RGBAModel.Convert(got.At(15, 2)), tt.wantRight)
+		})
+	}
+}
+
+func Test_gifDraw(t *testing.T) {
+	// the gray between black and white is drawn with both colors by the dither
+	src := image.NewRGBA(image.Rect(0, 0, 8, 8))
+	draw.Draw(src, src.Bounds(), image.NewUniform(color.RGBA{R: 128, G: 128, B: 128, A: 255}), image.Point{}, draw.Src)
+	palette := color.Palette{color.Black, color.White}
+	for _, dither := range []bool{false, true} {
+		dst := image.NewPaletted(src.Bounds(), palette)
+		gifDraw(dst, src, dither)
+		var whites int
+		for _, index := range dst.Pix {
+			whites += int(index)
+		}
+		assert.Equal(t, whites > 0 && whites < len(dst.Pix), dither)
+	}
+}
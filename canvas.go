@@ -0,0 +1,40 @@
+package imgedit
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+)
+
+var (
+	// PlaceholderColor the default background color of NewPlaceholder
+	PlaceholderColor = color.RGBA{R: 204, G: 204, B: 204, A: 255}
+	// PlaceholderTextColor the text color of NewPlaceholder
+	PlaceholderTextColor = color.RGBA{R: 102, G: 102, B: 102, A: 255}
+)
+
+// NewCanvas create converter of the blank image filled with the color, nil is transparent
+func NewCanvas(width, height int, fill color.Color) Converter {
+	return NewConverter(Paint(width, height, paintOf(nil, fill)))
+}
+
+// NewPlaceholder create converter of the image with the text in the center like "800x600",
+// the text is the size of the image if it is empty, fill nil is PlaceholderColor.
+func NewPlaceholder(width, height int, text string, fill color.Color) Converter {
+	if fill == nil {
+		fill = PlaceholderColor
+	}
+	if text == "" {
+		text = fmt.Sprintf("%dx%d", width, height)
+	}
+	c := NewCanvas(width, height, fill)
+	// the text is shrunk to fit in the box of 80% of the image
+	box := image.Rect(0, 0, width, height).Inset(int(math.Min(float64(width), float64(height)) / 10))
+	c.AddString(text, &StringOptions{
+		Font:        &Font{Name: FontGoRegular, Size: float64(height) / 4, Color: PlaceholderTextColor},
+		Box:         &box,
+		ShrinkToFit: true,
+	})
+	return c
+}
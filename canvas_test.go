@@ -0,0 +1,84 @@
+package imgedit
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/magiconair/properties/assert"
+)
+
+func TestNewCanvas(t *testing.T) {
+	type args struct {
+		width, height int
+		fill          color.Color
+	}
+	tests := []struct {
+		name string
+		args args
+		want color.Color
+	}{
+		{
+			name: "filled",
+			args: args{width: 30, height: 20, fill: color.RGBA{R: 255, A: 255}},
+			want: color.RGBA{R: 255, A: 255},
+		},
+		{
+			name: "transparent",
+			args: args{width: 30, height: 20},
+			want: color.RGBA{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			img := NewCanvas(tt.args.width, tt.args.height, tt.args.fill).Convert()
+			assert.Equal(t, img.Bounds(), image.Rect(0, 0, tt.args.width, tt.args.height))
+			assert.Equal(t, img.At(0, 0), tt.want)
+			assert.Equal(t, img.At(29, 19), tt.want)
+		})
+	}
+}
+
+func TestNewPlaceholder(t *testing.T) {
+	type args struct {
+		width, height int
+		text          string
+		fill          color.Color
+	}
+	tests := []struct {
+		name     string
+		args     args
+		wantFill color.Color
+	}{
+		{
+			name:     "size text",
+			args:     args{width: 800, height: 600},
+			wantFill: PlaceholderColor,
+		},
+		{
+			name:     "long text in the wide image",
+			args:     args{width: 1000, height: 100, text: "rabbit and turtle are playing the game", fill: color.White},
+			wantFill: color.RGBA{R: 255, G: 255, B: 255, A: 255},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			img := NewPlaceholder(tt.args.width, tt.args.height, tt.args.text, tt.args.fill).Convert()
+			assert.Equal(t, img.Bounds(), image.Rect(0, 0, tt.args.width, tt.args.height))
+			assert.Equal(t, img.At(0, 0), tt.wantFill)
+			// the text is in the center and not cut off at the edges
+			margin := tt.args.height / 10
+			text := image.Rectangle{}
+			for y := 0; y < tt.args.height; y++ {
+				for x := 0; x < tt.args.width; x++ {
+					if img.At(x, y) != tt.wantFill {
+						text = text.Union(image.Rect(x, y, x+1, y+1))
+					}
+				}
+			}
+			assert.Equal(t, text.Empty(), false)
+			assert.Equal(t, text.In(image.Rect(0, 0, tt.args.width, tt.args.height).Inset(margin-1)), true)
+			SaveTestImageAsPng(img)
+		})
+	}
+}
@@ -17,9 +17,11 @@ const (
 )
 
 func main() {
+	// the subcommands of the formats registered by imgedit.RegisterFormat are included
+	subCommands := app.AvailableSubCommands()
 	checkedOptions := map[string]bool{}
-	for _, subCommand := range app.SupportedSubCommands {
-		options := append(subCommand.OptionalOptions, subCommand.RequiredOptions...)
+	for _, subCommand := range subCommands {
+		options := append(append(subCommand.OptionalOptions, subCommand.RequiredOptions...), app.OutputOptions...)
 		for _, option := range options {
 			if _, ok := checkedOptions[option.Name()]; !ok {
 				option.RegisterFlag()
@@ -38,25 +40,32 @@ func main() {
 		exitOnError(nil)
 	}
 
-	if len(args) != 2 {
+	if len(args) < 2 {
 		exitOnError(errors.New("argument is missing"))
 	}
-	subCommandName, imagePath := args[0], args[1]
-	subCommand := app.SupportedSubCommands.FindSubCommand(subCommandName)
+	subCommandName, imagePaths := args[0], args[1:]
+	subCommand := subCommands.FindSubCommand(subCommandName)
 	if subCommand == nil {
 		exitOnError(errors.New(fmt.Sprintf("%s is not supported for subcommand", subCommandName)))
 	}
 
+	if len(imagePaths) > 1 && !subCommand.MultipleImages {
+		exitOnError(errors.New(fmt.Sprintf("%s does not accept multiple images", subCommandName)))
+	}
+
 	if !subCommand.ValidOption() {
 		exitOnError(errors.New(fmt.Sprintf("%s is not valid for option", subCommandName)))
 	}
 
-	if !exists(imagePath) {
-		exitOnError(errors.New(fmt.Sprintf("file does not exist : %s", imagePath)))
+	for _, imagePath := range imagePaths {
+		// the image of the subcommand creating the new image does not exist yet
+		if !subCommand.NewImage && !exists(imagePath) {
+			exitOnError(errors.New(fmt.Sprintf("file does not exist : %s", imagePath)))
+		}
 	}
 
 	// run application
-	err := app.NewApp(subCommand, imagePath).Run()
+	err := app.NewApp(subCommand, imagePaths...).Run()
 	if err != nil {
 		exitOnError(err)
 	}
@@ -81,9 +90,16 @@ func usage() {
 	fmt.Printf("Try running %s like:\n", commandName)
 	fmt.Printf("%s <sub command> <image path> -<option> | for example:\n\n", commandName)
 	fmt.Printf("%s reverse test.png -vertical\n", commandName)
-	fmt.Printf("%s resize test.png -width 500 -height 500\n\n", commandName)
+	fmt.Printf("%s resize test.png -width 500 -height 500\n", commandName)
+	fmt.Printf("%s montage test1.png test2.png test3.png -x 3 -caption\n", commandName)
+	fmt.Printf("%s new placeholder.png -width 800 -height 600 -placeholder\n", commandName)
+	fmt.Printf("%s qrcode label.png -text https://example.com -left 10 -top 10 -width 200\n", commandName)
+	fmt.Printf("%s gif frames -delay 200 -width 320 -height 240\n", commandName)
+	fmt.Printf("%s favicon logo.png\n", commandName)
+	fmt.Printf("%s jpeg test.png -quality 80\n", commandName)
+	fmt.Printf("%s jpeg test.png -max-size 200KB\n\n", commandName)
 	fmt.Printf("[sub command]\n")
-	for _, subCommand := range app.SupportedSubCommands {
+	for _, subCommand := range app.AvailableSubCommands() {
 		fmt.Printf("\n  %s : %s\n", subCommand.Name, subCommand.Usage)
 		if len(subCommand.RequiredOptions) > 0 {
 			fmt.Printf("    (required options)\n")
@@ -98,6 +114,10 @@ func usage() {
 			}
 		}
 	}
+	fmt.Printf("\n[output options]\n")
+	for _, option := range app.OutputOptions {
+		fmt.Printf("      -%s : %s\n", option.Name(), option.Usage())
+	}
 	fmt.Printf("\n[supported extensions]\n")
 	var supportedExtensions []string
 	for _, e := range imgedit.SupportedExtensions {
@@ -114,8 +134,8 @@ func permuteArgs(args []string) {
 		v := args[i]
 		if v[0] == '-' {
 			optionName := v[1:]
-			switch optionName {
-			case app.OptionVertical.Name(), "h", "help":
+			switch {
+			case app.AvailableSubCommands().IsBoolOption(optionName), optionName == "h", optionName == "help":
 				flagArgs = append(flagArgs, args[i])
 			default:
 				/* out of index */
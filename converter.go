@@ -7,12 +7,9 @@ import (
 	"image/draw"
 	"io/ioutil"
 	"math"
-	"regexp"
 
 	"github.com/golang/freetype"
 	"github.com/golang/freetype/truetype"
-	"golang.org/x/image/font"
-	"golang.org/x/image/math/fixed"
 )
 
 const (
@@ -39,8 +36,21 @@ type Converter interface {
 	Filter(filterModel FilterModel)
 	// Deprecated: Replace Filter(imgedit.GrayModel).
 	Grayscale()
-	AddString(text string, options *StringOptions)
+	AddString(text string, options *StringOptions) image.Rectangle
 	Tile(xLength, yLength int)
+	TileWithOptions(options *TileOptions)
+	DropShadow(offset image.Point, blur int, shadowColor color.Color, opacity float64)
+	OuterGlow(size int, glowColor color.Color, opacity float64)
+	Border(width int, borderColor color.Color)
+	DrawLine(from, to image.Point, options *ShapeOptions)
+	DrawRectangle(rect image.Rectangle, radius int, options *ShapeOptions)
+	DrawCircle(center image.Point, radius int, options *ShapeOptions)
+	DrawEllipse(center image.Point, radiusX, radiusY int, options *ShapeOptions)
+	DrawPolygon(points []image.Point, options *ShapeOptions)
+	DrawPath(p *Path, options *ShapeOptions)
+	Overlay(paint image.Image, mode BlendMode, opacity float64)
+	AddImage(img image.Image, point image.Point)
+	ScanCodes() []ScannedCode
 	Convert() image.Image
 }
 
@@ -102,11 +112,11 @@ func (c *converter) Reverse(isHorizon bool) {
 	var f func(int, int) (int, int)
 	if isHorizon {
 		f = func(x int, y int) (int, int) {
-			return srcSize.X - x, y
+			return srcSize.X - 1 - x, y
 		}
 	} else {
 		f = func(x int, y int) (int, int) {
-			return x, srcSize.Y - y
+			return x, srcSize.Y - 1 - y
 		}
 	}
 	for x := 0; x < dstSize.X; x++ {
@@ -195,170 +205,16 @@ func (c *converter) Filter(filterModel FilterModel) {
 	c.Image = dst
 }
 
-// StringOptions options for AddString
-type StringOptions struct {
-	// Point left top = (0px, 0px), default center
-	Point *image.Point
-	// Font
-	Font *Font
-	// Outline
-	Outline *Outline
-}
-
-// Font used in the options
-type Font struct {
-	// TrueTypeFont use ReadTtf to get font
-	TrueTypeFont *truetype.Font
-	// Size default 100
-	Size float64
-	// Color default color.Black
-	Color color.Color
-}
-
-// Outline used in the options
-type Outline struct {
-	// Color default color.White
-	Color color.Color
-	// Width from font. 0 <= Width <= 200 recommended
-	Width int
-}
-
-func (o *StringOptions) setDefault() {
-	// font
-	if o.Font == nil {
-		o.Font = &Font{}
-	}
-	if o.Font.TrueTypeFont == nil {
-		o.Font.TrueTypeFont, _ = ReadTtfFromByte(TtfFile)
-	}
-	if o.Font.Size == 0 {
-		o.Font.Size = DefaultFontSize
-	}
-	if o.Font.Color == nil {
-		o.Font.Color = color.Black
-	}
-
-	// outLine
-	if o.Outline != nil {
-		if o.Outline.Color == nil {
-			o.Outline.Color = color.White
-		}
-		if o.Outline.Width == 0 {
-			o.Outline.Width = DefaultOutlineWidth
-		}
-	}
-}
-
-func (o *StringOptions) face() font.Face {
-	// use only font size
-	return truetype.NewFace(o.Font.TrueTypeFont, &truetype.Options{Size: o.Font.Size})
-}
-
-func (o *StringOptions) color() *image.Uniform {
-	return image.NewUniform(o.Font.Color)
-}
-
-func (o *StringOptions) colorOutLine() *image.Uniform {
-	return image.NewUniform(o.Outline.Color)
+// Tile lay down the image with cols * rows
+func (c *converter) Tile(cols, rows int) {
+	c.TileWithOptions(&TileOptions{Cols: cols, Rows: rows})
 }
 
-// AddString add string on current Image
-func (c *converter) AddString(text string, options *StringOptions) {
-	if text == "" {
-		return
-	}
-	if options == nil {
-		options = &StringOptions{}
-	}
-	options.setDefault()
-
-	// copy base image
+// AddImage draw the image like the QR code over the image, point is the left top of img
+func (c *converter) AddImage(img image.Image, point image.Point) {
 	dst := image.NewRGBA(image.Rect(0, 0, c.Bounds().Dx(), c.Bounds().Dy()))
-	draw.Draw(dst, image.Rect(0, 0, c.Bounds().Dx(), c.Bounds().Dy()), c.Image, image.Point{}, draw.Over)
-
-	var outLinDrawer *font.Drawer
-	if options.Outline != nil {
-		outLinDrawer = &font.Drawer{
-			Dst:  dst,
-			Src:  options.colorOutLine(),
-			Face: options.face(),
-		}
-	}
-
-	drawer := &font.Drawer{
-		Dst:  dst,
-		Src:  options.color(),
-		Face: options.face(),
-	}
-	drawString(dst, drawer, outLinDrawer, text, options)
-	c.Image = dst
-}
-
-// drawString draw string at adjusted position
-func drawString(dst draw.Image, drawer *font.Drawer, outlineDrawer *font.Drawer, text string, options *StringOptions) {
-	// support for multiple lines
-	lines := regexp.MustCompile("\r\n|\n\r|\n|\r").Split(text, -1)
-
-	for i := 1; i <= len(lines); i++ {
-		text = lines[i-1]
-
-		// notice : Dot values are determined by feeling.
-		// set drawer first position
-		if options.Point == nil {
-			drawer.Dot.X, drawer.Dot.Y = (fixed.I(dst.Bounds().Dx())-drawer.MeasureString(text))/2, (fixed.I(dst.Bounds().Dy())+(drawer.Face.Metrics().Ascent+drawer.Face.Metrics().Descent)/2)/2
-		} else {
-			drawer.Dot.X, drawer.Dot.Y = fixed.I(options.Point.X)-drawer.MeasureString(text)/2, fixed.I(options.Point.Y)+((drawer.Face.Metrics().Ascent+drawer.Face.Metrics().Descent)/2)/2
-		}
-		// Adjust for line breaks.
-		diff := -float64(len(lines)-1)/float64(2) + float64(i-1)
-		drawer.Dot.Y += fixed.I(int(diff*2)) * drawer.Face.Metrics().Height / 128
-
-		if outlineDrawer == nil {
-			drawer.DrawString(text)
-			continue
-		}
-
-		// notice : width values are determined by feeling
-		width := drawer.Face.Metrics().Height / 128 / 100 * fixed.Int26_6(options.Outline.Width)
-
-		// draw letter by letter for adjust outline position
-		for _, s := range []byte(text) {
-			// As a technique, the image is drawn on the four corners,
-			// but that is an incomplete implementation of OUTLINE.
-
-			// left top
-			outlineDrawer.Dot = fixed.Point26_6{X: drawer.Dot.X - width, Y: drawer.Dot.Y - width}
-			outlineDrawer.DrawBytes([]byte{s})
-
-			// left bottom
-			outlineDrawer.Dot = fixed.Point26_6{X: drawer.Dot.X - width, Y: drawer.Dot.Y + width}
-			outlineDrawer.DrawBytes([]byte{s})
-
-			// right top
-			outlineDrawer.Dot = fixed.Point26_6{X: drawer.Dot.X + width, Y: drawer.Dot.Y - width}
-			outlineDrawer.DrawBytes([]byte{s})
-
-			// right bottom
-			outlineDrawer.Dot = fixed.Point26_6{X: drawer.Dot.X + width, Y: drawer.Dot.Y + width}
-			outlineDrawer.DrawBytes([]byte{s})
-
-			drawer.DrawBytes([]byte{s})
-		}
-	}
-}
-
-func (c *converter) Tile(cols, rows int) {
-	dst := image.NewRGBA(image.Rect(0, 0, c.Bounds().Dx()*cols, c.Bounds().Dy()*rows))
-	srcSize := c.Bounds().Size()
-	for xLen := 0; xLen < cols; xLen++ {
-		for yLen := 0; yLen < rows; yLen++ {
-			for x := 0; x < srcSize.X; x++ {
-				for y := 0; y < srcSize.Y; y++ {
-					dst.Set(x+srcSize.X*xLen, y+srcSize.Y*yLen, c.Image.At(x, y))
-				}
-			}
-		}
-	}
+	draw.Draw(dst, dst.Bounds(), c.Image, c.Bounds().Min, draw.Src)
+	draw.Draw(dst, img.Bounds().Sub(img.Bounds().Min).Add(point), img, img.Bounds().Min, draw.Over)
 	c.Image = dst
 }
 
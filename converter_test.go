@@ -249,20 +249,25 @@ func Test_converter_Reverse(t *testing.T) {
 	type args struct {
 		isHorizon bool
 	}
+	size := GetPngImage().Bounds().Size()
 	tests := []struct {
 		name   string
 		fields fields
 		args   args
+		// wantCorner is the point where the left top pixel is moved
+		wantCorner image.Point
 	}{
 		{
-			name:   "horizon",
-			fields: fields{Image: GetPngImage()},
-			args:   args{isHorizon: true},
+			name:       "horizon",
+			fields:     fields{Image: GetPngImage()},
+			args:       args{isHorizon: true},
+			wantCorner: image.Point{X: size.X - 1},
 		},
 		{
-			name:   "vertical",
-			fields: fields{Image: GetPngImage()},
-			args:   args{isHorizon: false},
+			name:       "vertical",
+			fields:     fields{Image: GetPngImage()},
+			args:       args{isHorizon: false},
+			wantCorner: image.Point{Y: size.Y - 1},
 		},
 	}
 	for _, tt := range tests {
@@ -274,6 +279,7 @@ func Test_converter_Reverse(t *testing.T) {
 			img := c.Convert()
 			assert.Equal(t, img.Bounds().Dx(), tt.fields.Image.Bounds().Dx())
 			assert.Equal(t, img.Bounds().Dy(), tt.fields.Image.Bounds().Dy())
+			assert.Equal(t, img.At(tt.wantCorner.X, tt.wantCorner.Y), color.RGBAModel.Convert(tt.fields.Image.At(0, 0)))
 			SaveTestImageAsPng(img)
 		})
 	}
@@ -299,8 +305,11 @@ func Test_converter_ReverseX(t *testing.T) {
 			}
 			c.ReverseX()
 			img := c.Convert()
+			size := tt.fields.Image.Bounds().Size()
 			assert.Equal(t, img.Bounds().Dx(), tt.fields.Image.Bounds().Dx())
 			assert.Equal(t, img.Bounds().Dy(), tt.fields.Image.Bounds().Dy())
+			// the left top pixel is moved to the opposite corner
+			assert.Equal(t, img.At(size.X-1, 0), color.RGBAModel.Convert(tt.fields.Image.At(0, 0)))
 			SaveTestImageAsPng(img)
 		})
 	}
@@ -326,8 +335,11 @@ func Test_converter_ReverseY(t *testing.T) {
 			}
 			c.ReverseY()
 			img := c.Convert()
+			size := tt.fields.Image.Bounds().Size()
 			assert.Equal(t, img.Bounds().Dx(), tt.fields.Image.Bounds().Dx())
 			assert.Equal(t, img.Bounds().Dy(), tt.fields.Image.Bounds().Dy())
+			// the left top pixel is moved to the opposite corner
+			assert.Equal(t, img.At(0, size.Y-1), color.RGBAModel.Convert(tt.fields.Image.At(0, 0)))
 			SaveTestImageAsPng(img)
 		})
 	}
@@ -481,11 +493,96 @@ func Test_converter_AddString(t *testing.T) {
 			fields: fields{Image: GetPngImage()},
 			args:   args{text: "Rabbit", options: &StringOptions{Font: &Font{Size: 400, TrueTypeFont: popTtf, Color: color.Black}, Outline: &Outline{}}},
 		},
+		{
+			name:   "font size 400 with miter outline",
+			fields: fields{Image: GetPngImage()},
+			args:   args{text: "WAVE", options: &StringOptions{Font: &Font{Size: 400, Color: color.White}, Outline: &Outline{Color: color.Black, Width: 200, Join: JoinMiter}}},
+		},
+		{
+			name:   "font size 400 with bevel outline",
+			fields: fields{Image: GetPngImage()},
+			args:   args{text: "WAVE", options: &StringOptions{Font: &Font{Size: 400, Color: color.White}, Outline: &Outline{Color: color.Black, Width: 200, Join: JoinBevel}}},
+		},
+		{
+			name:   "japanese with outline",
+			fields: fields{Image: GetPngImage()},
+			args:   args{text: "うさぎとかめ", options: &StringOptions{Font: &Font{Size: 200, TrueTypeFont: popTtf, Color: color.White}, Outline: &Outline{Color: color.Black, Width: 200}}},
+		},
+		{
+			name:   "accented latin and emoji with outline",
+			fields: fields{Image: GetPngImage()},
+			args:   args{text: "Crème brûlée 😀", options: &StringOptions{Font: &Font{Size: 100, Color: color.White}, Outline: &Outline{Color: color.Black}}},
+		},
 		{
 			name:   "multi lines font size 200 with outline",
 			fields: fields{Image: GetPngImage()},
 			args:   args{text: "Rabbit\nmulti lines\noutline", options: &StringOptions{Font: &Font{Size: 200, TrueTypeFont: popTtf, Color: color.White}, Outline: &Outline{Color: color.RGBA{R: 255, G: 192, B: 203, A: 255}, Width: 100}}},
 		},
+		{
+			name:   "left align from left top",
+			fields: fields{Image: GetPngImage()},
+			args:   args{text: "Rabbit\nand\nturtle", options: &StringOptions{Font: &Font{Size: 200}, Point: &image.Point{X: 100, Y: 100}, Align: AlignLeft, Anchor: AnchorTopLeft}},
+		},
+		{
+			name:   "right align with spacing",
+			fields: fields{Image: GetPngImage()},
+			args:   args{text: "Rabbit\nand\nturtle", options: &StringOptions{Font: &Font{Size: 200}, Point: &image.Point{X: 1400, Y: 1400}, Align: AlignRight, Anchor: AnchorBottomRight, LineSpacing: 1.5, LetterSpacing: 20}},
+		},
+		{
+			name:   "wrap in max width",
+			fields: fields{Image: GetPngImage()},
+			args:   args{text: "the rabbit and the turtle had a race", options: &StringOptions{Font: &Font{Size: 150}, MaxWidth: 1000}},
+		},
+		{
+			name:   "shrink to fit in box",
+			fields: fields{Image: GetPngImage()},
+			args:   args{text: "WHEN YOU FINALLY FIX THE BUG", options: &StringOptions{Font: &Font{Size: 400, Color: color.White}, Outline: &Outline{Color: color.Black}, Box: &image.Rectangle{Min: image.Point{X: 100, Y: 100}, Max: image.Point{X: 1400, Y: 500}}, Anchor: AnchorTop, ShrinkToFit: true}},
+		},
+		{
+			name:   "rotated with outline",
+			fields: fields{Image: GetPngImage()},
+			args:   args{text: "Rabbit", options: &StringOptions{Font: &Font{Size: 300, Color: color.White}, Outline: &Outline{Color: color.Black}, Rotation: -30}},
+		},
+		{
+			name:   "vertical japanese",
+			fields: fields{Image: GetPngImage()},
+			args:   args{text: "うさぎと\n「かめ」。", options: &StringOptions{Font: &Font{Size: 200, TrueTypeFont: popTtf}, Vertical: true, Align: AlignLeft}},
+		},
+		{
+			name:   "arc around the center",
+			fields: fields{Image: GetPngImage()},
+			args:   args{text: "RABBIT AND TURTLE", options: &StringOptions{Font: &Font{Size: 120, Color: color.RGBA{R: 200, A: 255}}, Arc: &TextArc{Radius: 550}}},
+		},
+		{
+			name:   "reversed arc with outline",
+			fields: fields{Image: GetPngImage()},
+			args:   args{text: "seal stamp", options: &StringOptions{Font: &Font{Size: 120, Color: color.RGBA{R: 200, A: 255}}, Outline: &Outline{}, Arc: &TextArc{Radius: 550, Reverse: true}}},
+		},
+		{
+			name:   "shadow",
+			fields: fields{Image: GetPngImage()},
+			args:   args{text: "Rabbit", options: &StringOptions{Font: &Font{Size: 300, Color: color.White}, Shadow: &TextShadow{Offset: image.Point{X: 10, Y: 10}, Blur: 10}}},
+		},
+		{
+			name:   "background with rounded corners",
+			fields: fields{Image: GetPngImage()},
+			args:   args{text: "Rabbit\nand turtle", options: &StringOptions{Font: &Font{Size: 150}, Background: &TextBackground{Padding: 40, Radius: 40}, Opacity: 0.8}},
+		},
+		{
+			name:   "gradient with outline",
+			fields: fields{Image: GetPngImage()},
+			args:   args{text: "Rabbit", options: &StringOptions{Font: &Font{Size: 400, TrueTypeFont: popTtf}, Outline: &Outline{}, Gradient: &TextGradient{Colors: []color.Color{color.RGBA{R: 255, A: 255}, color.RGBA{B: 255, A: 255}}, Angle: 90}}},
+		},
+		{
+			name:   "pattern",
+			fields: fields{Image: GetPngImage()},
+			args:   args{text: "Rabbit", options: &StringOptions{Font: &Font{Size: 400, TrueTypeFont: popTtf}, Pattern: GetGifImage()}},
+		},
+		{
+			name:   "markup",
+			fields: fields{Image: GetPngImage()},
+			args:   args{text: "Rabbit <b><color=#ff0000>and</color></b>\n<size=200><font=lightNovelPOP>turtle</font></size>", options: &StringOptions{Font: &Font{Size: 120}, Outline: &Outline{}, Markup: true}},
+		},
 		{
 			name:   "empty",
 			fields: fields{Image: GetPngImage()},
@@ -497,8 +594,10 @@ func Test_converter_AddString(t *testing.T) {
 			c := &converter{
 				Image: tt.fields.Image,
 			}
-			c.AddString(tt.args.text, tt.args.options)
+			rect := c.AddString(tt.args.text, tt.args.options)
 			img := c.Convert()
+			assert.Equal(t, rect.In(img.Bounds()), true)
+			assert.Equal(t, rect.Empty(), tt.args.text == "")
 			assert.Equal(t, img.Bounds().Dx(), tt.fields.Image.Bounds().Dx())
 			assert.Equal(t, img.Bounds().Dy(), tt.fields.Image.Bounds().Dy())
 			SaveTestImageAsPng(img)
@@ -539,6 +638,43 @@ func Test_converter_Tile(t *testing.T) {
 	}
 }
 
+func Test_converter_AddImage(t *testing.T) {
+	qrCode, _ := NewQRCode("imgedit", &QROptions{Size: 100})
+	type args struct {
+		img   image.Image
+		point image.Point
+	}
+	tests := []struct {
+		name   string
+		fields image.Image
+		args   args
+	}{
+		{
+			name:   "qr code",
+			fields: GetPngImage(),
+			args:   args{img: qrCode, point: image.Point{X: 50, Y: 30}},
+		},
+		{
+			name:   "shifted image",
+			fields: GetPngImage(),
+			args:   args{img: qrCode.(*image.RGBA).SubImage(image.Rect(10, 10, 60, 60)), point: image.Point{X: 50, Y: 30}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &converter{
+				Image: tt.fields,
+			}
+			c.AddImage(tt.args.img, tt.args.point)
+			img := c.Convert()
+			SaveTestImageAsPng(img)
+			assert.Equal(t, img.Bounds().Size(), tt.fields.Bounds().Size())
+			assert.Equal(t, img.At(tt.args.point.X, tt.args.point.Y), tt.args.img.At(tt.args.img.Bounds().Min.X, tt.args.img.Bounds().Min.Y))
+			assert.Equal(t, img.At(tt.args.point.X-1, tt.args.point.Y-1), color.RGBAModel.Convert(tt.fields.At(tt.args.point.X-1, tt.args.point.Y-1)))
+		})
+	}
+}
+
 func TestReadTtf(t *testing.T) {
 	type args struct {
 		ttfFilePath string
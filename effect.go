@@ -0,0 +1,182 @@
+package imgedit
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+)
+
+// DropShadow add the shadow of the image, the canvas grows to fit the shadow
+// offset is the shadow position from the image, blur is the blur radius px,
+// opacity is 0 <= opacity <= 1
+func (c *converter) DropShadow(offset image.Point, blur int, shadowColor color.Color, opacity float64) {
+	if shadowColor == nil {
+		shadowColor = color.Black
+	}
+	srcRect := image.Rect(0, 0, c.Bounds().Dx(), c.Bounds().Dy())
+	shadowRect := srcRect.Add(offset).Inset(-blur)
+	dstRect := srcRect.Union(shadowRect)
+
+	// move the origin of the canvas to the left top
+	origin := dstRect.Min.Mul(-1)
+	dst := image.NewRGBA(image.Rect(0, 0, dstRect.Dx(), dstRect.Dy()))
+	shadow := newAlphaMask(c.Image, dst.Bounds(), origin.Add(offset))
+	shadow.blur(float64(blur) / 2)
+	draw.DrawMask(dst, dst.Bounds(), image.NewUniform(shadowColor), image.Point{}, shadow.alpha(opacity), image.Point{}, draw.Over)
+	draw.Draw(dst, srcRect.Add(origin), c.Image, c.Bounds().Min, draw.Over)
+	c.Image = dst
+}
+
+// OuterGlow add the glow around the image, the canvas grows by size
+// size is the glow spread px, opacity is 0 <= opacity <= 1
+func (c *converter) OuterGlow(size int, glowColor color.Color, opacity float64) {
+	if glowColor == nil {
+		glowColor = color.White
+	}
+	srcRect := image.Rect(0, 0, c.Bounds().Dx(), c.Bounds().Dy())
+	origin := image.Point{X: size, Y: size}
+
+	dst := image.NewRGBA(srcRect.Inset(-size).Add(origin))
+	glow := newAlphaMask(c.Image, dst.Bounds(), origin)
+	glow.spread(size / 2)
+	glow.blur(float64(size) / 4)
+	draw.DrawMask(dst, dst.Bounds(), image.NewUniform(glowColor), image.Point{}, glow.alpha(opacity), image.Point{}, draw.Over)
+	draw.Draw(dst, srcRect.Add(origin), c.Image, c.Bounds().Min, draw.Over)
+	c.Image = dst
+}
+
+// Border add the border around the image, the canvas grows by width
+func (c *converter) Border(width int, borderColor color.Color) {
+	if borderColor == nil {
+		borderColor = color.Black
+	}
+	srcRect := image.Rect(0, 0, c.Bounds().Dx(), c.Bounds().Dy())
+	origin := image.Point{X: width, Y: width}
+
+	dst := image.NewRGBA(srcRect.Inset(-width).Add(origin))
+	draw.Draw(dst, dst.Bounds(), image.NewUniform(borderColor), image.Point{}, draw.Src)
+	draw.Draw(dst, srcRect.Add(origin), c.Image, c.Bounds().Min, draw.Src)
+	c.Image = dst
+}
+
+// alphaMask is the alpha channel of an image, 0 <= value <= 1
+type alphaMask struct {
+	rect   image.Rectangle
+	values []float64
+}
+
+// newAlphaMask copy the alpha channel of src to rect at point
+func newAlphaMask(src image.Image, rect image.Rectangle, point image.Point) *alphaMask {
+	m := &alphaMask{rect: rect, values: make([]float64, rect.Dx()*rect.Dy())}
+	srcBounds := src.Bounds()
+	for y := 0; y < srcBounds.Dy(); y++ {
+		for x := 0; x < srcBounds.Dx(); x++ {
+			dstX, dstY := x+point.X-rect.Min.X, y+point.Y-rect.Min.Y
+			if dstX < 0 || dstY < 0 || dstX >= rect.Dx() || dstY >= rect.Dy() {
+				continue
+			}
+			_, _, _, a := src.At(x+srcBounds.Min.X, y+srcBounds.Min.Y).RGBA()
+			m.values[dstY*rect.Dx()+dstX] = float64(a) / math.MaxUint16
+		}
+	}
+	return m
+}
+
+// blur approximate the gaussian blur with three box blurs.
+func (m *alphaMask) blur(sigma float64) {
+	if sigma <= 0 {
+		return
+	}
+	// box radius to approximate gaussian of sigma with 3 passes.
+	radius := int(math.Round((math.Sqrt(4*sigma*sigma+1) - 1) / 2))
+	if radius < 1 {
+		radius = 1
+	}
+	for i := 0; i < 3; i++ {
+		m.boxBlur(radius, true)
+		m.boxBlur(radius, false)
+	}
+}
+
+// boxBlur average the values in the radius with the running sum
+func (m *alphaMask) boxBlur(radius int, isHorizon bool) {
+	width, height := m.rect.Dx(), m.rect.Dy()
+	length, count, step := width, height, 1
+	if !isHorizon {
+		length, count, step = height, width, width
+	}
+	line := make([]float64, length)
+	size := float64(radius*2 + 1)
+	for i := 0; i < count; i++ {
+		start := i * width
+		if !isHorizon {
+			start = i
+		}
+		for j := 0; j < length; j++ {
+			line[j] = m.values[start+j*step]
+		}
+		var sum float64
+		for j := -radius; j <= radius; j++ {
+			if 0 <= j && j < length {
+				sum += line[j]
+			}
+		}
+		for j := 0; j < length; j++ {
+			m.values[start+j*step] = sum / size
+			if out := j - radius; out >= 0 {
+				sum -= line[out]
+			}
+			if in := j + radius + 1; in < length {
+				sum += line[in]
+			}
+		}
+	}
+}
+
+// spread expand the opaque area by radius px with the separable max filter
+func (m *alphaMask) spread(radius int) {
+	if radius <= 0 {
+		return
+	}
+	m.maxFilter(radius, true)
+	m.maxFilter(radius, false)
+}
+
+// maxFilter replace the values with the max value in the radius
+func (m *alphaMask) maxFilter(radius int, isHorizon bool) {
+	width, height := m.rect.Dx(), m.rect.Dy()
+	length, count, step := width, height, 1
+	if !isHorizon {
+		length, count, step = height, width, width
+	}
+	line := make([]float64, length)
+	for i := 0; i < count; i++ {
+		start := i * width
+		if !isHorizon {
+			start = i
+		}
+		for j := 0; j < length; j++ {
+			line[j] = m.values[start+j*step]
+		}
+		for j := 0; j < length; j++ {
+			var v float64
+			for k := j - radius; k <= j+radius && v < 1; k++ {
+				if 0 <= k && k < length {
+					v = math.Max(v, line[k])
+				}
+			}
+			m.values[start+j*step] = v
+		}
+	}
+}
+
+// alpha return the mask as image.Alpha with opacity
+func (m *alphaMask) alpha(opacity float64) *image.Alpha {
+	opacity = math.Max(0, math.Min(1, opacity))
+	dst := image.NewAlpha(m.rect)
+	for i, v := range m.values {
+		dst.Pix[i] = uint8(math.Round(math.Min(1, v) * opacity * math.MaxUint8))
+	}
+	return dst
+}
@@ -0,0 +1,143 @@
+package imgedit
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/magiconair/properties/assert"
+)
+
+func Test_converter_DropShadow(t *testing.T) {
+	type fields struct {
+		Image image.Image
+	}
+	type args struct {
+		offset      image.Point
+		blur        int
+		shadowColor color.Color
+		opacity     float64
+	}
+	tests := []struct {
+		name       string
+		fields     fields
+		args       args
+		wantWidth  int
+		wantHeight int
+	}{
+		{
+			name:       "normal",
+			fields:     fields{Image: image.NewRGBA(image.Rect(0, 0, 100, 100))},
+			args:       args{offset: image.Point{X: 10, Y: 20}, blur: 5, shadowColor: color.Black, opacity: 0.5},
+			wantWidth:  115,
+			wantHeight: 125,
+		},
+		{
+			name:       "negative offset",
+			fields:     fields{Image: image.NewRGBA(image.Rect(0, 0, 100, 100))},
+			args:       args{offset: image.Point{X: -10, Y: -20}, blur: 0, opacity: 1},
+			wantWidth:  110,
+			wantHeight: 120,
+		},
+		{
+			name:       "png",
+			fields:     fields{Image: GetAlphaPngImage()},
+			args:       args{offset: image.Point{X: 30, Y: 30}, blur: 20, shadowColor: color.Black, opacity: 0.8},
+			wantWidth:  GetAlphaPngImage().Bounds().Dx() + 50,
+			wantHeight: GetAlphaPngImage().Bounds().Dy() + 50,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &converter{
+				Image: tt.fields.Image,
+			}
+			c.DropShadow(tt.args.offset, tt.args.blur, tt.args.shadowColor, tt.args.opacity)
+			img := c.Convert()
+			assert.Equal(t, img.Bounds().Dx(), tt.wantWidth)
+			assert.Equal(t, img.Bounds().Dy(), tt.wantHeight)
+			SaveTestImageAsPng(img)
+		})
+	}
+}
+
+func Test_converter_OuterGlow(t *testing.T) {
+	type fields struct {
+		Image image.Image
+	}
+	type args struct {
+		size      int
+		glowColor color.Color
+		opacity   float64
+	}
+	tests := []struct {
+		name   string
+		fields fields
+		args   args
+	}{
+		{
+			name:   "normal",
+			fields: fields{Image: GetAlphaPngImage()},
+			args:   args{size: 30, glowColor: color.RGBA{R: 255, G: 192, B: 203, A: 255}, opacity: 1},
+		},
+		{
+			name:   "default color",
+			fields: fields{Image: image.NewRGBA(image.Rect(0, 0, 100, 100))},
+			args:   args{size: 10, opacity: 0.5},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &converter{
+				Image: tt.fields.Image,
+			}
+			c.OuterGlow(tt.args.size, tt.args.glowColor, tt.args.opacity)
+			img := c.Convert()
+			assert.Equal(t, img.Bounds().Dx(), tt.fields.Image.Bounds().Dx()+tt.args.size*2)
+			assert.Equal(t, img.Bounds().Dy(), tt.fields.Image.Bounds().Dy()+tt.args.size*2)
+			SaveTestImageAsPng(img)
+		})
+	}
+}
+
+func Test_converter_Border(t *testing.T) {
+	type fields struct {
+		Image image.Image
+	}
+	type args struct {
+		width       int
+		borderColor color.Color
+	}
+	tests := []struct {
+		name      string
+		fields    fields
+		args      args
+		wantColor color.Color
+	}{
+		{
+			name:      "normal",
+			fields:    fields{Image: GetPngImage()},
+			args:      args{width: 50, borderColor: color.White},
+			wantColor: color.White,
+		},
+		{
+			name:      "default color",
+			fields:    fields{Image: image.NewRGBA(image.Rect(0, 0, 100, 100))},
+			args:      args{width: 10},
+			wantColor: color.Black,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &converter{
+				Image: tt.fields.Image,
+			}
+			c.Border(tt.args.width, tt.args.borderColor)
+			img := c.Convert()
+			assert.Equal(t, img.Bounds().Dx(), tt.fields.Image.Bounds().Dx()+tt.args.width*2)
+			assert.Equal(t, img.Bounds().Dy(), tt.fields.Image.Bounds().Dy()+tt.args.width*2)
+			assert.Equal(t, color.RGBAModel.Convert(img.At(0, 0)), color.RGBAModel.Convert(tt.wantColor))
+			SaveTestImageAsPng(img)
+		})
+	}
+}
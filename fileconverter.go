@@ -1,6 +1,8 @@
 package imgedit
 
 import (
+	"bytes"
+	"image"
 	"os"
 )
 
@@ -8,30 +10,44 @@ import (
 type FileConverter interface {
 	Converter
 	SaveAs(string, Extension) error
+	SaveAsWithOptions(string, Extension, *WriteOptions) error
 }
 
 type fileConverter struct {
 	*byteConverter
 }
 
-// NewFileConverter create fileConverter
+// NewFileConverter create fileConverter, or AnimationConverter if the image has multiple frames
 func NewFileConverter(srcPath string) (FileConverter, Extension, error) {
-	srcFile, err := os.Open(srcPath)
+	data, err := os.ReadFile(srcPath)
 	if err != nil {
 		return nil, "", err
 	}
-	bc, extension, err := newByteConverter(srcFile)
+	if a, extension, ok := decodeAnimation(data); ok {
+		return a, extension, nil
+	}
+	bc, extension, err := newByteConverter(bytes.NewReader(data))
 	if err != nil {
 		return nil, "", err
 	}
 	return &fileConverter{byteConverter: bc}, extension, nil
 }
 
+// NewFileConverterFromImage create fileConverter from image
+func NewFileConverterFromImage(img image.Image) FileConverter {
+	return &fileConverter{byteConverter: &byteConverter{converter: &converter{img}}}
+}
+
 func (p *fileConverter) SaveAs(dstPath string, extension Extension) error {
+	return p.SaveAsWithOptions(dstPath, extension, nil)
+}
+
+// SaveAsWithOptions save the image with the options of the extension
+func (p *fileConverter) SaveAsWithOptions(dstPath string, extension Extension, options *WriteOptions) error {
 	dstFile, err := os.Create(dstPath)
 	if err != nil {
 		return err
 	}
 	defer dstFile.Close()
-	return p.byteConverter.WriteAs(dstFile, extension)
+	return p.byteConverter.WriteAsWithOptions(dstFile, extension, options)
 }
@@ -1,6 +1,7 @@
 package imgedit
 
 import (
+	"image"
 	"reflect"
 	"testing"
 )
@@ -165,3 +166,27 @@ func TestFileEdit(t *testing.T) {
 	c.Grayscale()
 	_ = c.SaveAs(DstPngImagePath, Png)
 }
+
+func TestNewFileConverterFromImage(t *testing.T) {
+	type args struct {
+		img image.Image
+	}
+	tests := []struct {
+		name string
+		args args
+		want FileConverter
+	}{
+		{
+			name: "normal",
+			args: args{img: GetPngImage()},
+			want: &fileConverter{byteConverter: &byteConverter{&converter{Image: GetPngImage()}}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NewFileConverterFromImage(tt.args.img); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("NewFileConverterFromImage() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
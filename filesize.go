@@ -0,0 +1,169 @@
+package imgedit
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"io"
+	"math"
+)
+
+// DefaultMinQuality is the lowest quality searched by WriteWithinSize
+const DefaultMinQuality = 10
+
+// SizeOptions options for WriteWithinSizeWithOptions
+type SizeOptions struct {
+	// WriteOptions are used for the encoding except Quality searched for the lossy formats
+	WriteOptions *WriteOptions
+	// MinQuality is the lowest quality searched, default DefaultMinQuality
+	MinQuality int
+	// Downscale the image until it is within the size, if the image of MinQuality is over the size
+	Downscale bool
+}
+
+func (o *SizeOptions) setDefault() {
+	if o.WriteOptions == nil {
+		o.WriteOptions = &WriteOptions{}
+	}
+	if o.MinQuality < 1 || 100 < o.MinQuality {
+		o.MinQuality = DefaultMinQuality
+	}
+}
+
+// SizeResult is the result of WriteWithinSize
+type SizeResult struct {
+	// Quality chosen for the lossy formats like jpeg, 0 for the other formats
+	Quality int
+	// Width and Height of the written image, smaller than the image if it is downscaled
+	Width, Height int
+	// Size is the written bytes
+	Size int
+}
+
+// sizeEncoder write the image scaled by the ratio, and return the size of the scaled image
+type sizeEncoder func(w io.Writer, ratio float64, options *WriteOptions) (image.Point, error)
+
+// writeWithinSize write the image of the highest quality within maxBytes, and downscale it if it is allowed.
+// the quality is searched by the binary search only if the format is lossy.
+func writeWithinSize(w io.Writer, extension Extension, maxBytes int, options *SizeOptions, size image.Point, encode sizeEncoder) (*SizeResult, error) {
+	if options == nil {
+		options = &SizeOptions{}
+	}
+	options.setDefault()
+	format, ok := LookupFormat(extension)
+	if !ok || format.Encode == nil {
+		return nil, errors.New("extension is unsupported")
+	}
+
+	ratio := 1.0
+	for {
+		b, result, err := searchQuality(maxBytes, ratio, format.Lossy, options, encode)
+		if err != nil {
+			return nil, err
+		}
+		if result.Size <= maxBytes {
+			_, err = w.Write(b.Bytes())
+			return result, err
+		}
+		if !options.Downscale {
+			return nil, fmt.Errorf("%s is %d bytes over %d bytes at the lowest quality", extension, result.Size, maxBytes)
+		}
+		// the size is roughly proportional to the area, and the ratio is reduced at least 10 percent
+		ratio *= math.Min(0.9, math.Sqrt(float64(maxBytes)/float64(result.Size)))
+		if float64(size.X)*ratio < 1 || float64(size.Y)*ratio < 1 {
+			return nil, fmt.Errorf("%s is not within %d bytes in 1px", extension, maxBytes)
+		}
+	}
+}
+
+// searchQuality return the image of the highest quality within maxBytes, or the lowest quality if nothing is within maxBytes
+func searchQuality(maxBytes int, ratio float64, lossy bool, options *SizeOptions, encode sizeEncoder) (*bytes.Buffer, *SizeResult, error) {
+	writeOptions := *options.WriteOptions
+	tryQuality := func(quality int) (*bytes.Buffer, *SizeResult, error) {
+		writeOptions.Quality = quality
+		b := &bytes.Buffer{}
+		size, err := encode(b, ratio, &writeOptions)
+		if err != nil {
+			return nil, nil, err
+		}
+		if !lossy {
+			quality = 0
+		}
+		return b, &SizeResult{Quality: quality, Width: size.X, Height: size.Y, Size: b.Len()}, nil
+	}
+	if !lossy {
+		return tryQuality(0)
+	}
+
+	var found *bytes.Buffer
+	var foundResult *SizeResult
+	low, high := options.MinQuality, 100
+	for low <= high {
+		quality := (low + high) / 2
+		b, result, err := tryQuality(quality)
+		if err != nil {
+			return nil, nil, err
+		}
+		if result.Size <= maxBytes {
+			found, foundResult = b, result
+			low = quality + 1
+		} else {
+			high = quality - 1
+		}
+	}
+	if found == nil {
+		return tryQuality(options.MinQuality)
+	}
+	return found, foundResult, nil
+}
+
+// scaledSize return the size scaled by the ratio, at least 1px
+func scaledSize(size image.Point, ratio float64) image.Point {
+	return image.Point{
+		X: int(math.Max(1, math.Round(float64(size.X)*ratio))),
+		Y: int(math.Max(1, math.Round(float64(size.Y)*ratio))),
+	}
+}
+
+// WriteWithinSize write the image within maxBytes with the highest quality, the image is not downscaled
+func (b *byteConverter) WriteWithinSize(writer io.Writer, extension Extension, maxBytes int) (*SizeResult, error) {
+	return b.WriteWithinSizeWithOptions(writer, extension, maxBytes, nil)
+}
+
+// WriteWithinSizeWithOptions write the image within maxBytes with the highest quality, and downscale it with the options
+func (b *byteConverter) WriteWithinSizeWithOptions(writer io.Writer, extension Extension, maxBytes int, options *SizeOptions) (*SizeResult, error) {
+	size := b.Bounds().Size()
+	return writeWithinSize(writer, extension, maxBytes, options, size, func(w io.Writer, ratio float64, writeOptions *WriteOptions) (image.Point, error) {
+		img := b.Image
+		if ratio < 1 {
+			img = scaleImage(img, scaledSize(size, ratio))
+		}
+		return img.Bounds().Size(), (&byteConverter{converter: &converter{img}}).WriteAsWithOptions(w, extension, writeOptions)
+	})
+}
+
+// WriteWithinSize write all frames for gif and png within maxBytes, and the first frame for the other extensions
+func (a *animationConverter) WriteWithinSize(writer io.Writer, extension Extension, maxBytes int) (*SizeResult, error) {
+	return a.WriteWithinSizeWithOptions(writer, extension, maxBytes, nil)
+}
+
+// WriteWithinSizeWithOptions write all frames for gif and png within maxBytes with the options,
+// and the first frame for the other extensions
+func (a *animationConverter) WriteWithinSizeWithOptions(writer io.Writer, extension Extension, maxBytes int, options *SizeOptions) (*SizeResult, error) {
+	if extension != Gif && extension != Png {
+		return (&byteConverter{converter: &converter{a.Convert()}}).WriteWithinSizeWithOptions(writer, extension, maxBytes, options)
+	}
+	size := a.Convert().Bounds().Size()
+	return writeWithinSize(writer, extension, maxBytes, options, size, func(w io.Writer, ratio float64, writeOptions *WriteOptions) (image.Point, error) {
+		scaled := a
+		if ratio < 1 {
+			scaled = &animationConverter{loopCount: a.loopCount}
+			for _, frame := range a.frames {
+				frame.Image = scaleImage(frame.Image, scaledSize(frame.Image.Bounds().Size(), ratio))
+				scaled.frames = append(scaled.frames, frame)
+			}
+		}
+		return scaled.Convert().Bounds().Size(), scaled.WriteAsWithOptions(w, extension, writeOptions)
+	})
+}
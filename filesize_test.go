@@ -0,0 +1,117 @@
+package imgedit
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"testing"
+
+	"github.com/magiconair/properties/assert"
+)
+
+func Test_byteConverter_WriteWithinSizeWithOptions(t *testing.T) {
+	src := GetPngImage()
+	type args struct {
+		extension Extension
+		maxBytes  int
+		options   *SizeOptions
+	}
+	tests := []struct {
+		name          string
+		args          args
+		wantErr       bool
+		wantDownscale bool
+	}{
+		{
+			name:          "jpeg quality",
+			args:          args{extension: Jpeg, maxBytes: 60000, options: nil},
+			wantErr:       false,
+			wantDownscale: false,
+		},
+		{
+			name:          "jpeg over the size",
+			args:          args{extension: Jpeg, maxBytes: 3000, options: nil},
+			wantErr:       true,
+			wantDownscale: false,
+		},
+		{
+			name:          "jpeg downscale",
+			args:          args{extension: Jpeg, maxBytes: 3000, options: &SizeOptions{Downscale: true}},
+			wantErr:       false,
+			wantDownscale: true,
+		},
+		{
+			name:          "png downscale",
+			args:          args{extension: Png, maxBytes: 20000, options: &SizeOptions{Downscale: true, WriteOptions: &WriteOptions{CompressionLevel: png.BestSpeed}}},
+			wantErr:       false,
+			wantDownscale: true,
+		},
+		{
+			name:          "unsupported extension",
+			args:          args{extension: Extension("unsupported"), maxBytes: 3000, options: nil},
+			wantErr:       true,
+			wantDownscale: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewByteConverterFromImage(src)
+			w := &bytes.Buffer{}
+			got, err := c.WriteWithinSizeWithOptions(w, tt.args.extension, tt.args.maxBytes, tt.args.options)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("WriteWithinSizeWithOptions() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				assert.Equal(t, w.Len(), 0)
+				return
+			}
+			assert.Equal(t, got.Size, w.Len())
+			assert.Equal(t, got.Size <= tt.args.maxBytes, true)
+			assert.Equal(t, got.Width < src.Bounds().Dx(), tt.wantDownscale)
+			decoded, _, err := image.Decode(w)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assert.Equal(t, decoded.Bounds().Size(), image.Point{X: got.Width, Y: got.Height})
+
+			if tt.args.extension != Jpeg {
+				assert.Equal(t, got.Quality, 0)
+				return
+			}
+			// the next quality is over the size
+			if got.Quality < 100 && !tt.wantDownscale {
+				next := &bytes.Buffer{}
+				if err = c.WriteAsWithOptions(next, Jpeg, &WriteOptions{Quality: got.Quality + 1}); err != nil {
+					t.Fatal(err)
+				}
+				assert.Equal(t, next.Len() > tt.args.maxBytes, true)
+			}
+		})
+	}
+}
+
+func Test_animationConverter_WriteWithinSize(t *testing.T) {
+	c, _, err := NewFileConverter(AnimatedGifImagePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := c.(AnimationConverter)
+	w := &bytes.Buffer{}
+	if err = a.WriteAs(w, Gif); err != nil {
+		t.Fatal(err)
+	}
+	maxBytes := w.Len() / 2
+	w.Reset()
+	got, err := a.WriteWithinSizeWithOptions(w, Gif, maxBytes, &SizeOptions{Downscale: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, got.Size <= maxBytes, true)
+	assert.Equal(t, got.Width < a.Convert().Bounds().Dx(), true)
+	decoded, _, err := NewByteConverter(w)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, len(decoded.(AnimationConverter).Frames()), len(a.Frames()))
+}
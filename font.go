@@ -0,0 +1,366 @@
+package imgedit
+
+import (
+	_ "embed"
+	"errors"
+	"io/fs"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/golang/freetype/truetype"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/goregular"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/font/sfnt"
+	"golang.org/x/image/math/fixed"
+)
+
+const (
+	// FontLogoTypeGothic the name of the bundled default font
+	FontLogoTypeGothic = "07LogoTypeGothic7"
+	// FontLightNovelPOP the name of the bundled pop font
+	FontLightNovelPOP = "lightNovelPOP"
+	// FontGoRegular the name of the bundled Go font used as the fallback for latin letters
+	FontGoRegular = "goregular"
+)
+
+//go:embed assets/font/lightNovelPOP.ttf
+var PopTtfFile []byte
+
+// FontExtensions the file extensions loaded by FontRegistry.LoadDir
+var FontExtensions = []string{".ttf", ".otf"}
+
+// Typeface the TrueType or OpenType font
+type Typeface struct {
+	name string
+	ttf  *truetype.Font
+	otf  *sfnt.Font
+}
+
+// NewTypeface wrap the font read by ReadTtf
+func NewTypeface(ttf *truetype.Font) *Typeface {
+	return &Typeface{ttf: ttf}
+}
+
+// ReadTypeface return TrueType or OpenType font from file path
+func ReadTypeface(fontFilePath string) (*Typeface, error) {
+	fontFile, err := ioutil.ReadFile(fontFilePath)
+	if err != nil {
+		return nil, err
+	}
+	return ReadTypefaceFromByte(fontFile)
+}
+
+// ReadTypefaceFromByte return TrueType or OpenType font from file byte
+func ReadTypefaceFromByte(fontFile []byte) (*Typeface, error) {
+	otf, err := opentype.Parse(fontFile)
+	if err != nil {
+		return nil, err
+	}
+	return &Typeface{otf: otf}, nil
+}
+
+// Name return the name registered in FontRegistry
+func (t *Typeface) Name() string {
+	return t.name
+}
+
+// face return the face of the size px
+func (t *Typeface) face(size float64) font.Face {
+	if t.otf != nil {
+		// NewFace fails only with the invalid options
+		face, _ := opentype.NewFace(t.otf, &opentype.FaceOptions{Size: size, DPI: 72})
+		return face
+	}
+	return truetype.NewFace(t.ttf, &truetype.Options{Size: size})
+}
+
+// hasGlyph return true, if the font has the glyph of r.
+// the glyph without the contours is regarded as missing unless r is the space,
+// some fonts map the letters to the empty glyph.
+func (t *Typeface) hasGlyph(r rune) bool {
+	visible := unicode.IsGraphic(r) && !unicode.IsSpace(r)
+	if t.otf != nil {
+		buf := &sfnt.Buffer{}
+		index, err := t.otf.GlyphIndex(buf, r)
+		if err != nil || index == 0 {
+			return false
+		}
+		if !visible {
+			return true
+		}
+		segments, err := t.otf.LoadGlyph(buf, index, fixed.I(16), nil)
+		return err == nil && len(segments) > 0
+	}
+	index := t.ttf.Index(r)
+	if index == 0 {
+		return false
+	}
+	if !visible {
+		return true
+	}
+	buf := &truetype.GlyphBuf{}
+	return buf.Load(t.ttf, fixed.I(16), index, font.HintingNone) == nil && len(buf.Ends) > 0
+}
+
+// glyphPath return the contours of the glyph placed at dot
+func (t *Typeface) glyphPath(size float64, r rune, dot fixed.Point26_6) (path, error) {
+	if t.otf == nil {
+		return glyphPath(t.ttf, size, r, dot)
+	}
+	buf := &sfnt.Buffer{}
+	index, err := t.otf.GlyphIndex(buf, r)
+	if err != nil {
+		return nil, err
+	}
+	segments, err := t.otf.LoadGlyph(buf, index, fixed.Int26_6(size*64), nil)
+	if err != nil {
+		return nil, err
+	}
+	origin := vec{float64(dot.X) / 64, float64(dot.Y) / 64}
+	toVec := func(p fixed.Point26_6) vec {
+		// the segments y axis is downward
+		return vec{origin.x + float64(p.X)/64, origin.y + float64(p.Y)/64}
+	}
+
+	var glyph path
+	var current vec
+	for _, segment := range segments {
+		switch segment.Op {
+		case sfnt.SegmentOpMoveTo:
+			current = toVec(segment.Args[0])
+			glyph = append(glyph, contour{points: []vec{current}, closed: true})
+			continue
+		case sfnt.SegmentOpLineTo:
+			current = toVec(segment.Args[0])
+			glyph[len(glyph)-1].points = append(glyph[len(glyph)-1].points, current)
+		case sfnt.SegmentOpQuadTo:
+			c := &glyph[len(glyph)-1]
+			c.points = flattenQuad(c.points, current, toVec(segment.Args[0]), toVec(segment.Args[1]))
+			current = toVec(segment.Args[1])
+		case sfnt.SegmentOpCubeTo:
+			c := &glyph[len(glyph)-1]
+			c.points = flattenCube(c.points, current, toVec(segment.Args[0]), toVec(segment.Args[1]), toVec(segment.Args[2]))
+			current = toVec(segment.Args[2])
+		}
+	}
+	return glyph, nil
+}
+
+// glyphPath return the contours of the TrueType glyph placed at dot
+func glyphPath(f *truetype.Font, size float64, r rune, dot fixed.Point26_6) (path, error) {
+	buf := &truetype.GlyphBuf{}
+	err := buf.Load(f, fixed.Int26_6(size*64), f.Index(r), font.HintingNone)
+	if err != nil {
+		return nil, err
+	}
+	origin := vec{float64(dot.X) / 64, float64(dot.Y) / 64}
+	toVec := func(p truetype.Point) vec {
+		// the glyph y axis is upward
+		return vec{origin.x + float64(p.X)/64, origin.y - float64(p.Y)/64}
+	}
+
+	var glyph path
+	start := 0
+	for _, end := range buf.Ends {
+		points := buf.Points[start:end]
+		start = end
+		if len(points) == 0 {
+			continue
+		}
+		// rotate the points to start from the on curve point,
+		// or the middle of the off curve points if there is no on curve point.
+		first := -1
+		for i, p := range points {
+			if p.Flags&0x01 != 0 {
+				first = i
+				break
+			}
+		}
+		var startVec vec
+		if first < 0 {
+			startVec = toVec(points[0]).add(toVec(points[len(points)-1])).mul(0.5)
+			first = 0
+		} else {
+			startVec = toVec(points[first])
+			first++
+		}
+
+		c := contour{points: []vec{startVec}, closed: true}
+		current := startVec
+		var control *vec
+		for i := 0; i < len(points); i++ {
+			p := points[(first+i)%len(points)]
+			v := toVec(p)
+			if p.Flags&0x01 != 0 {
+				if control == nil {
+					c.points = append(c.points, v)
+				} else {
+					c.points = flattenQuad(c.points, current, *control, v)
+					control = nil
+				}
+				current = v
+				continue
+			}
+			if control != nil {
+				// the middle of the two off curve points is the implicit on curve point
+				mid := control.add(v).mul(0.5)
+				c.points = flattenQuad(c.points, current, *control, mid)
+				current = mid
+			}
+			control = &vec{v.x, v.y}
+		}
+		if control != nil {
+			c.points = flattenQuad(c.points, current, *control, startVec)
+		}
+		glyph = append(glyph, c)
+	}
+	return glyph, nil
+}
+
+// FontRegistry the fonts registered by name with the fallback chains
+type FontRegistry struct {
+	// mu guards the maps, the fonts are looked up from multiple goroutines
+	mu        sync.RWMutex
+	typefaces map[string]*Typeface
+	// sources are the font files parsed when they are looked up first
+	sources   map[string][]byte
+	fallbacks map[string][]string
+}
+
+// DefaultFontRegistry has the bundled fonts, Font.Name is looked up in it
+var DefaultFontRegistry = newDefaultFontRegistry()
+
+func newDefaultFontRegistry() *FontRegistry {
+	r := NewFontRegistry()
+	r.sources[FontLogoTypeGothic] = TtfFile
+	r.sources[FontLightNovelPOP] = PopTtfFile
+	r.sources[FontGoRegular] = goregular.TTF
+	// the bundled japanese fonts do not have the accented latin letters
+	r.SetFallbacks(FontLogoTypeGothic, FontGoRegular)
+	r.SetFallbacks(FontLightNovelPOP, FontGoRegular)
+	return r
+}
+
+// NewFontRegistry create empty registry
+func NewFontRegistry() *FontRegistry {
+	return &FontRegistry{typefaces: map[string]*Typeface{}, sources: map[string][]byte{}, fallbacks: map[string][]string{}}
+}
+
+// Register add the typeface with the name
+func (r *FontRegistry) Register(name string, typeface *Typeface) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.register(name, typeface)
+}
+
+func (r *FontRegistry) register(name string, typeface *Typeface) {
+	registered := *typeface
+	registered.name = name
+	r.typefaces[name] = &registered
+	delete(r.sources, name)
+}
+
+// LoadFile register the font file with the file name without extension
+func (r *FontRegistry) LoadFile(fontFilePath string) (string, error) {
+	typeface, err := ReadTypeface(fontFilePath)
+	if err != nil {
+		return "", err
+	}
+	name := strings.TrimSuffix(filepath.Base(fontFilePath), filepath.Ext(fontFilePath))
+	r.Register(name, typeface)
+	return name, nil
+}
+
+// LoadDir register all font files with FontExtensions in the directory and its subdirectories
+func (r *FontRegistry) LoadDir(dir string) ([]string, error) {
+	var names []string
+	err := filepath.WalkDir(dir, func(fontFilePath string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || !isFontFile(fontFilePath) {
+			return err
+		}
+		name, err := r.LoadFile(fontFilePath)
+		if err != nil {
+			return err
+		}
+		names = append(names, name)
+		return nil
+	})
+	return names, err
+}
+
+// Lookup return the typeface registered with the name
+func (r *FontRegistry) Lookup(name string) (*Typeface, error) {
+	r.mu.RLock()
+	typeface, ok := r.typefaces[name]
+	r.mu.RUnlock()
+	if ok {
+		return typeface, nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	// the font may be parsed by the other goroutine while waiting for the lock
+	if typeface, ok := r.typefaces[name]; ok {
+		return typeface, nil
+	}
+	source, ok := r.sources[name]
+	if !ok {
+		return nil, errors.New("font is not registered: " + name)
+	}
+	ttf, err := ReadTtfFromByte(source)
+	if err != nil {
+		return nil, err
+	}
+	r.register(name, NewTypeface(ttf))
+	return r.typefaces[name], nil
+}
+
+// Names return the names of the registered fonts
+func (r *FontRegistry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var names []string
+	for name := range r.typefaces {
+		names = append(names, name)
+	}
+	for name := range r.sources {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// SetFallbacks set the fonts used for the letters missing from the font of the name
+func (r *FontRegistry) SetFallbacks(name string, fallbacks ...string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.fallbacks[name] = fallbacks
+}
+
+// chain return the typeface and the registered fallbacks, the fonts failed to look up are skipped
+func (r *FontRegistry) chain(typeface *Typeface) []*Typeface {
+	chain := []*Typeface{typeface}
+	r.mu.RLock()
+	fallbacks := r.fallbacks[typeface.name]
+	r.mu.RUnlock()
+	for _, name := range fallbacks {
+		if fallback, err := r.Lookup(name); err == nil {
+			chain = append(chain, fallback)
+		}
+	}
+	return chain
+}
+
+func isFontFile(fontFilePath string) bool {
+	for _, extension := range FontExtensions {
+		if strings.EqualFold(filepath.Ext(fontFilePath), extension) {
+			return true
+		}
+	}
+	return false
+}
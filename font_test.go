@@ -0,0 +1,169 @@
+package imgedit
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/magiconair/properties/assert"
+	"golang.org/x/image/font/gofont/goregular"
+	"golang.org/x/image/math/fixed"
+)
+
+const FontDirPath = "assets/font"
+
+func TestReadTypeface(t *testing.T) {
+	tests := []struct {
+		name    string
+		path    string
+		wantErr bool
+	}{
+		{
+			name: "ttf",
+			path: PopFontPath,
+		},
+		{
+			name:    "missing file",
+			path:    MissingImagePath,
+			wantErr: true,
+		},
+		{
+			name:    "not font",
+			path:    SrcPngImagePath,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ReadTypeface(tt.path)
+			assert.Equal(t, err != nil, tt.wantErr)
+			assert.Equal(t, got != nil, !tt.wantErr)
+		})
+	}
+}
+
+func TestTypeface_hasGlyph(t *testing.T) {
+	ttf, _ := ReadTtfFromByte(TtfFile)
+	otf, _ := ReadTypefaceFromByte(goregular.TTF)
+	tests := []struct {
+		name     string
+		typeface *Typeface
+		r        rune
+		want     bool
+	}{
+		{
+			name:     "ttf latin",
+			typeface: NewTypeface(ttf),
+			r:        'A',
+			want:     true,
+		},
+		{
+			name:     "ttf japanese",
+			typeface: NewTypeface(ttf),
+			r:        'あ',
+			want:     true,
+		},
+		{
+			name:     "ttf space",
+			typeface: NewTypeface(ttf),
+			r:        ' ',
+			want:     true,
+		},
+		{
+			name:     "ttf empty glyph",
+			typeface: NewTypeface(ttf),
+			r:        'é',
+			want:     false,
+		},
+		{
+			name:     "otf accented latin",
+			typeface: otf,
+			r:        'é',
+			want:     true,
+		},
+		{
+			name:     "otf missing japanese",
+			typeface: otf,
+			r:        'あ',
+			want:     false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.typeface.hasGlyph(tt.r), tt.want)
+		})
+	}
+}
+
+func TestTypeface_glyphPath(t *testing.T) {
+	ttf, _ := ReadTtfFromByte(TtfFile)
+	otf, _ := ReadTypefaceFromByte(goregular.TTF)
+	for _, typeface := range []*Typeface{NewTypeface(ttf), otf} {
+		glyph, err := typeface.glyphPath(100, 'O', fixed.P(10, 100))
+		if err != nil {
+			t.Fatal(err)
+		}
+		// O has the outer and inner contours, the baseline is y = 100
+		assert.Equal(t, len(glyph), 2)
+		for _, c := range glyph {
+			assert.Equal(t, c.closed, true)
+			for _, p := range c.points {
+				if p.x < 10 || p.x > 110 || p.y < 0 || p.y > 110 {
+					t.Errorf("glyphPath() point %v is out of the glyph box", p)
+				}
+			}
+		}
+	}
+}
+
+func TestFontRegistry(t *testing.T) {
+	r := NewFontRegistry()
+	_, err := r.Lookup(FontLightNovelPOP)
+	assert.Equal(t, err != nil, true)
+
+	names, err := r.LoadDir(FontDirPath)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, names, []string{FontLogoTypeGothic, FontLightNovelPOP})
+	assert.Equal(t, r.Names(), []string{FontLogoTypeGothic, FontLightNovelPOP})
+
+	pop, err := r.Lookup(FontLightNovelPOP)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, pop.Name(), FontLightNovelPOP)
+
+	// the missing fallback is skipped
+	r.SetFallbacks(FontLightNovelPOP, "missing", FontLogoTypeGothic)
+	chain := r.chain(pop)
+	assert.Equal(t, len(chain), 2)
+	assert.Equal(t, chain[1].Name(), FontLogoTypeGothic)
+
+	_, err = r.LoadFile(SrcPngImagePath)
+	assert.Equal(t, err != nil, true)
+}
+
+func TestDefaultFontRegistry(t *testing.T) {
+	for _, name := range []string{FontLogoTypeGothic, FontLightNovelPOP, FontGoRegular} {
+		typeface, err := DefaultFontRegistry.Lookup(name)
+		assert.Equal(t, err, nil)
+		assert.Equal(t, typeface.Name(), name)
+	}
+}
+
+func TestFontRegistry_concurrent(t *testing.T) {
+	r := NewFontRegistry()
+	r.sources[FontGoRegular] = goregular.TTF
+	typefaces := make([]*Typeface, 8)
+	var wg sync.WaitGroup
+	for i := range typefaces {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			// the font is parsed once and shared by the goroutines
+			typefaces[i], _ = r.Lookup(FontGoRegular)
+			r.Register(FontLogoTypeGothic, typefaces[i])
+			_ = r.Names()
+		}(i)
+	}
+	wg.Wait()
+	for _, typeface := range typefaces {
+		assert.Equal(t, typeface, typefaces[0])
+	}
+}
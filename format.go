@@ -0,0 +1,180 @@
+package imgedit
+
+import (
+	"image"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"strings"
+
+	"golang.org/x/image/bmp"
+	"golang.org/x/image/tiff"
+)
+
+// Format is the image format registered with the extension
+type Format struct {
+	Extension Extension
+	// Description is shown in the usage of the command, default the extension
+	Description string
+	MimeType    string
+	// Suffixes of the file names without the dot, default the extension
+	Suffixes []string
+	// Magic are the prefixes of the encoded image, "?" matches any one byte same as image.RegisterFormat
+	Magic []string
+	// Lossy is true if Quality of WriteOptions changes the encoded image, WriteWithinSize searches the quality
+	Lossy bool
+	// Encode write the image with the options set to the default, the format is read only if Encode is nil
+	Encode func(w io.Writer, m image.Image, options *WriteOptions) error
+	// Decode and DecodeConfig are registered to the image package with Magic,
+	// they can be nil if the decoder is registered by the other package like image/png
+	Decode       func(r io.Reader) (image.Image, error)
+	DecodeConfig func(r io.Reader) (image.Config, error)
+}
+
+// formats are the registered formats in the order of the registration
+var formats []Format
+
+func init() {
+	for _, format := range []Format{
+		{
+			Extension: Png, Description: "png", MimeType: "image/png", Magic: []string{pngSignature},
+			Encode: func(w io.Writer, m image.Image, options *WriteOptions) error {
+				return (&png.Encoder{CompressionLevel: options.CompressionLevel}).Encode(w, m)
+			},
+		},
+		{
+			Extension: Jpeg, Description: "jpeg", MimeType: "image/jpeg", Suffixes: []string{"jpeg", "jpg", "jpe"}, Magic: []string{"\xff\xd8"}, Lossy: true,
+			Encode: func(w io.Writer, m image.Image, options *WriteOptions) error {
+				return jpeg.Encode(w, m, &jpeg.Options{Quality: options.Quality})
+			},
+		},
+		{
+			Extension: Gif, Description: "gif", MimeType: "image/gif", Magic: []string{"GIF87a", "GIF89a"},
+			Encode: func(w io.Writer, m image.Image, options *WriteOptions) error {
+				return gifEncode(w, m, options.Gif)
+			},
+		},
+		{
+			Extension: Bmp, Description: "bmp", MimeType: "image/bmp", Magic: []string{"BM????\x00\x00\x00\x00"},
+			Encode: func(w io.Writer, m image.Image, _ *WriteOptions) error {
+				return bmp.Encode(w, m)
+			},
+		},
+		{
+			Extension: Tiff, Description: "tiff", MimeType: "image/tiff", Suffixes: []string{"tiff", "tif"}, Magic: []string{"II\x2a\x00", "MM\x00\x2a"},
+			Encode: func(w io.Writer, m image.Image, options *WriteOptions) error {
+				if options.CompressionLevel == png.NoCompression {
+					return tiff.Encode(w, m, &tiff.Options{Compression: tiff.Uncompressed})
+				}
+				return tiff.Encode(w, m, &tiff.Options{Compression: tiff.Deflate, Predictor: true})
+			},
+		},
+		{
+			Extension: Webp, Description: "lossless webp", MimeType: "image/webp", Magic: []string{"RIFF????WEBPVP8"},
+			Encode: func(w io.Writer, m image.Image, _ *WriteOptions) error {
+				return webpEncode(w, m)
+			},
+		},
+		{
+			Extension: Ico, Description: "ico, the image larger than 256px is scaled down", MimeType: "image/x-icon", Magic: []string{icoHeader},
+			Encode: func(w io.Writer, m image.Image, _ *WriteOptions) error {
+				return EncodeIco(w, icoImage(m))
+			},
+			Decode: decodeIcoImage, DecodeConfig: decodeIcoConfig,
+		},
+		{
+			Extension: Pbm, Description: "black and white pbm", MimeType: "image/x-portable-bitmap", Magic: []string{"P1", "P4"},
+			Encode: pnmEncoder(Pbm), Decode: decodePnm, DecodeConfig: decodePnmConfig,
+		},
+		{
+			Extension: Pgm, Description: "grayscale pgm", MimeType: "image/x-portable-graymap", Magic: []string{"P2", "P5"},
+			Encode: pnmEncoder(Pgm), Decode: decodePnm, DecodeConfig: decodePnmConfig,
+		},
+		{
+			Extension: Ppm, Description: "ppm", MimeType: "image/x-portable-pixmap", Magic: []string{"P3", "P6"},
+			Encode: pnmEncoder(Ppm), Decode: decodePnm, DecodeConfig: decodePnmConfig,
+		},
+		{
+			Extension: Pam, Description: "pam with alpha", MimeType: "image/x-portable-arbitrarymap", Magic: []string{"P7"},
+			Encode: pnmEncoder(Pam), Decode: decodePnm, DecodeConfig: decodePnmConfig,
+		},
+		{
+			Extension: Qoi, Description: "qoi", MimeType: "image/qoi", Magic: []string{qoiMagic},
+			Encode: func(w io.Writer, m image.Image, _ *WriteOptions) error {
+				return qoiEncode(w, m)
+			},
+			Decode: decodeQoi, DecodeConfig: decodeQoiConfig,
+		},
+	} {
+		RegisterFormat(format)
+	}
+}
+
+// RegisterFormat register the format to convert with the extension, the format of the same extension is replaced.
+// it is usually called in an init function same as image.RegisterFormat.
+func RegisterFormat(format Format) {
+	if format.Description == "" {
+		format.Description = string(format.Extension)
+	}
+	if len(format.Suffixes) == 0 {
+		format.Suffixes = []string{string(format.Extension)}
+	}
+	if format.Decode != nil && format.DecodeConfig != nil {
+		// the name of the format is the extension to find the format of the decoded image
+		for _, magic := range format.Magic {
+			image.RegisterFormat(string(format.Extension), magic, format.Decode, format.DecodeConfig)
+		}
+	}
+	for i, registered := range formats {
+		if registered.Extension == format.Extension {
+			formats[i] = format
+			return
+		}
+	}
+	formats = append(formats, format)
+	SupportedExtensions = append(SupportedExtensions, format.Extension)
+}
+
+// Formats return the registered formats in the order of the registration
+func Formats() []Format {
+	return append([]Format{}, formats...)
+}
+
+// LookupFormat return the format registered with the extension
+func LookupFormat(extension Extension) (Format, bool) {
+	for _, format := range formats {
+		if format.Extension == extension {
+			return format, true
+		}
+	}
+	return Format{}, false
+}
+
+// FormatBySuffix return the format of the file suffix like "jpg", the suffix is case-insensitive and the dot is optional
+func FormatBySuffix(suffix string) (Format, bool) {
+	suffix = strings.ToLower(strings.TrimPrefix(suffix, "."))
+	for _, format := range formats {
+		for _, s := range format.Suffixes {
+			if s == suffix {
+				return format, true
+			}
+		}
+	}
+	return Format{}, false
+}
+
+// FormatByMimeType return the format of the mime type
+func FormatByMimeType(mimeType string) (Format, bool) {
+	for _, format := range formats {
+		if format.MimeType != "" && format.MimeType == mimeType {
+			return format, true
+		}
+	}
+	return Format{}, false
+}
+
+func pnmEncoder(extension Extension) func(w io.Writer, m image.Image, options *WriteOptions) error {
+	return func(w io.Writer, m image.Image, _ *WriteOptions) error {
+		return pnmEncode(w, m, extension)
+	}
+}
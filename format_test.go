@@ -0,0 +1,126 @@
+package imgedit
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"io"
+	"testing"
+
+	"github.com/magiconair/properties/assert"
+)
+
+// testFormat is the gray image of 1 byte width and height after the magic
+var testFormat = Format{
+	Extension: Extension("test"),
+	MimeType:  "image/x-test",
+	Suffixes:  []string{"test", "tst"},
+	Magic:     []string{"TEST"},
+	Encode: func(w io.Writer, m image.Image, _ *WriteOptions) error {
+		b := m.Bounds()
+		data := []byte{'T', 'E', 'S', 'T', byte(b.Dx()), byte(b.Dy())}
+		for y := b.Min.Y; y < b.Max.Y; y++ {
+			for x := b.Min.X; x < b.Max.X; x++ {
+				data = append(data, color.GrayModel.Convert(m.At(x, y)).(color.Gray).Y)
+			}
+		}
+		_, err := w.Write(data)
+		return err
+	},
+	Decode: func(r io.Reader) (image.Image, error) {
+		data, err := io.ReadAll(r)
+		if err != nil || len(data) < 6 || len(data) < 6+int(data[4])*int(data[5]) {
+			return nil, errors.New("test: invalid format")
+		}
+		return &image.Gray{Pix: data[6:], Stride: int(data[4]), Rect: image.Rect(0, 0, int(data[4]), int(data[5]))}, nil
+	},
+	DecodeConfig: func(r io.Reader) (image.Config, error) {
+		data := make([]byte, 6)
+		if _, err := io.ReadFull(r, data); err != nil {
+			return image.Config{}, err
+		}
+		return image.Config{ColorModel: color.GrayModel, Width: int(data[4]), Height: int(data[5])}, nil
+	},
+}
+
+func TestRegisterFormat(t *testing.T) {
+	RegisterFormat(testFormat)
+	assert.Equal(t, SupportedExtension(testFormat.Extension), true)
+	assert.Equal(t, SupportedExtensions[len(SupportedExtensions)-1], testFormat.Extension)
+
+	src := image.NewGray(image.Rect(0, 0, 3, 2))
+	src.SetGray(1, 1, color.Gray{Y: 200})
+	w := &bytes.Buffer{}
+	if err := NewByteConverterFromImage(src).WriteAs(w, testFormat.Extension); err != nil {
+		t.Fatal(err)
+	}
+	got, extension, err := NewByteConverter(w)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, extension, testFormat.Extension)
+	assert.Equal(t, got.Convert().At(1, 1), color.Gray{Y: 200})
+
+	// the format of the same extension is replaced
+	count := len(Formats())
+	replaced := testFormat
+	replaced.Description = "replaced"
+	RegisterFormat(replaced)
+	assert.Equal(t, len(Formats()), count)
+	format, ok := LookupFormat(testFormat.Extension)
+	assert.Equal(t, ok, true)
+	assert.Equal(t, format.Description, "replaced")
+}
+
+func TestFormatBySuffix(t *testing.T) {
+	type args struct {
+		suffix string
+	}
+	tests := []struct {
+		name   string
+		args   args
+		want   Extension
+		wantOk bool
+	}{
+		{
+			name:   "extension",
+			args:   args{suffix: "png"},
+			want:   Png,
+			wantOk: true,
+		},
+		{
+			name:   "upper case with dot",
+			args:   args{suffix: ".JPG"},
+			want:   Jpeg,
+			wantOk: true,
+		},
+		{
+			name:   "tif",
+			args:   args{suffix: "tif"},
+			want:   Tiff,
+			wantOk: true,
+		},
+		{
+			name:   "unsupported suffix",
+			args:   args{suffix: "txt"},
+			want:   "",
+			wantOk: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := FormatBySuffix(tt.args.suffix)
+			assert.Equal(t, ok, tt.wantOk)
+			assert.Equal(t, got.Extension, tt.want)
+		})
+	}
+}
+
+func TestFormatByMimeType(t *testing.T) {
+	got, ok := FormatByMimeType("image/webp")
+	assert.Equal(t, ok, true)
+	assert.Equal(t, got.Extension, Webp)
+	_, ok = FormatByMimeType("")
+	assert.Equal(t, ok, false)
+}
@@ -7,3 +7,5 @@ require (
 	github.com/magiconair/properties v1.8.6
 	golang.org/x/image v0.0.0-20220617043117-41969df76e82
 )
+
+require golang.org/x/text v0.3.7 // indirect
@@ -0,0 +1,197 @@
+package imgedit
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+	"sort"
+)
+
+// ColorStop the color at the offset of the gradient
+type ColorStop struct {
+	// Offset 0 <= Offset <= 1 from the start to the end of the gradient
+	Offset float64
+	Color  color.Color
+}
+
+// EvenStops return the stops of the colors placed at even intervals from 0 to 1
+func EvenStops(colors ...color.Color) []ColorStop {
+	stops := make([]ColorStop, len(colors))
+	for i, c := range colors {
+		stops[i] = ColorStop{Color: c}
+		if len(colors) > 1 {
+			stops[i].Offset = float64(i) / float64(len(colors)-1)
+		}
+	}
+	return stops
+}
+
+// sortStops return the copy of the stops sorted by the offset
+func sortStops(stops []ColorStop) []ColorStop {
+	sorted := append([]ColorStop{}, stops...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Offset < sorted[j].Offset
+	})
+	return sorted
+}
+
+// NewLinearGradient return the image of the colors changing along the line from start to end,
+// the colors before start and after end are the first and last stops.
+func NewLinearGradient(start, end image.Point, stops []ColorStop) image.Image {
+	return &linearGradient{start: pixelCenter(start), end: pixelCenter(end), stops: sortStops(stops)}
+}
+
+// NewRadialGradient return the image of the colors changing from the center to the circle of radius px
+func NewRadialGradient(center image.Point, radius float64, stops []ColorStop) image.Image {
+	return &radialGradient{center: pixelCenter(center), radius: radius, stops: sortStops(stops)}
+}
+
+// NewConicGradient return the image of the colors changing clockwise around the center,
+// angle is degrees clockwise from the top to start the gradient.
+func NewConicGradient(center image.Point, angle float64, stops []ColorStop) image.Image {
+	return &conicGradient{center: pixelCenter(center), angle: angle * math.Pi / 180, stops: sortStops(stops)}
+}
+
+// NewPattern return the image repeated infinitely from origin
+func NewPattern(img image.Image, origin image.Point) image.Image {
+	return &repeatedImage{Image: img, origin: origin}
+}
+
+// Paint return the new image of the size filled with the paint like the gradient or the pattern
+func Paint(width, height int, paint image.Image) image.Image {
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	if paint != nil {
+		draw.Draw(dst, dst.Bounds(), paint, image.Point{}, draw.Src)
+	}
+	return dst
+}
+
+// infiniteBounds is the bounds of the paints covering any image like image.Uniform
+var infiniteBounds = image.Rectangle{Min: image.Point{X: -1e9, Y: -1e9}, Max: image.Point{X: 1e9, Y: 1e9}}
+
+// linearGradient is the image of the colors changing along the line from start to end
+type linearGradient struct {
+	start, end vec
+	stops      []ColorStop
+}
+
+func (g *linearGradient) ColorModel() color.Model {
+	return color.RGBA64Model
+}
+
+func (g *linearGradient) Bounds() image.Rectangle {
+	return infiniteBounds
+}
+
+func (g *linearGradient) At(x, y int) color.Color {
+	d := g.end.sub(g.start)
+	length := d.dot(d)
+	if length == 0 {
+		return stopColor(g.stops, 0)
+	}
+	// the position of the pixel center projected on the line
+	t := pixelCenter(image.Point{X: x, Y: y}).sub(g.start).dot(d) / length
+	return stopColor(g.stops, t)
+}
+
+// radialGradient is the image of the colors changing from the center to the circle
+type radialGradient struct {
+	center vec
+	radius float64
+	stops  []ColorStop
+}
+
+func (g *radialGradient) ColorModel() color.Model {
+	return color.RGBA64Model
+}
+
+func (g *radialGradient) Bounds() image.Rectangle {
+	return infiniteBounds
+}
+
+func (g *radialGradient) At(x, y int) color.Color {
+	if g.radius <= 0 {
+		return stopColor(g.stops, 1)
+	}
+	return stopColor(g.stops, pixelCenter(image.Point{X: x, Y: y}).sub(g.center).length()/g.radius)
+}
+
+// conicGradient is the image of the colors changing around the center
+type conicGradient struct {
+	center vec
+	// angle radians clockwise from the top
+	angle float64
+	stops []ColorStop
+}
+
+func (g *conicGradient) ColorModel() color.Model {
+	return color.RGBA64Model
+}
+
+func (g *conicGradient) Bounds() image.Rectangle {
+	return infiniteBounds
+}
+
+func (g *conicGradient) At(x, y int) color.Color {
+	d := pixelCenter(image.Point{X: x, Y: y}).sub(g.center)
+	// the clockwise angle from the top in the y axis downward
+	theta := math.Atan2(d.x, -d.y) - g.angle
+	t := math.Mod(theta/(2*math.Pi), 1)
+	if t < 0 {
+		t++
+	}
+	return stopColor(g.stops, t)
+}
+
+// gradientColor return the color at t of the colors placed at even intervals from 0 to 1
+func gradientColor(colors []color.Color, t float64) color.Color {
+	return stopColor(EvenStops(colors...), t)
+}
+
+// stopColor return the color at t between the stops sorted by the offset
+func stopColor(stops []ColorStop, t float64) color.Color {
+	if len(stops) == 0 {
+		return color.Transparent
+	}
+	if t <= stops[0].Offset {
+		return stops[0].Color
+	}
+	for i := 1; i < len(stops); i++ {
+		if t < stops[i].Offset {
+			prev := stops[i-1]
+			return mixColor(prev.Color, stops[i].Color, (t-prev.Offset)/(stops[i].Offset-prev.Offset))
+		}
+	}
+	return stops[len(stops)-1].Color
+}
+
+// mixColor return the color between a and b at the ratio, 0 is a and 1 is b
+func mixColor(a, b color.Color, ratio float64) color.Color {
+	r1, g1, b1, a1 := a.RGBA()
+	r2, g2, b2, a2 := b.RGBA()
+	mix := func(v1, v2 uint32) uint16 {
+		return uint16(math.Round(float64(v1)*(1-ratio) + float64(v2)*ratio))
+	}
+	return color.RGBA64{R: mix(r1, r2), G: mix(g1, g2), B: mix(b1, b2), A: mix(a1, a2)}
+}
+
+// repeatedImage is the image repeated infinitely from origin
+type repeatedImage struct {
+	image.Image
+	origin image.Point
+}
+
+func (r *repeatedImage) Bounds() image.Rectangle {
+	return infiniteBounds
+}
+
+func (r *repeatedImage) At(x, y int) color.Color {
+	bounds := r.Image.Bounds()
+	if bounds.Empty() {
+		return color.Transparent
+	}
+	x = ((x-r.origin.X)%bounds.Dx()+bounds.Dx())%bounds.Dx() + bounds.Min.X
+	y = ((y-r.origin.Y)%bounds.Dy()+bounds.Dy())%bounds.Dy() + bounds.Min.Y
+	return r.Image.At(x, y)
+}
@@ -0,0 +1,163 @@
+package imgedit
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/magiconair/properties/assert"
+)
+
+func Test_gradientColor(t *testing.T) {
+	red, blue := color.RGBA{R: 255, A: 255}, color.RGBA{B: 255, A: 255}
+	type args struct {
+		colors []color.Color
+		t      float64
+	}
+	tests := []struct {
+		name string
+		args args
+		want color.Color
+	}{
+		{
+			name: "start",
+			args: args{colors: []color.Color{red, blue}, t: 0},
+			want: red,
+		},
+		{
+			name: "before start",
+			args: args{colors: []color.Color{red, blue}, t: -1},
+			want: red,
+		},
+		{
+			name: "end",
+			args: args{colors: []color.Color{red, blue}, t: 1},
+			want: blue,
+		},
+		{
+			name: "middle",
+			args: args{colors: []color.Color{red, blue}, t: 0.5},
+			want: color.RGBA64{R: 0x8000, B: 0x8000, A: 0xffff},
+		},
+		{
+			name: "second stop",
+			args: args{colors: []color.Color{red, color.White, blue}, t: 0.5},
+			want: color.RGBA64{R: 0xffff, G: 0xffff, B: 0xffff, A: 0xffff},
+		},
+		{
+			name: "single color",
+			args: args{colors: []color.Color{red}, t: 0.5},
+			want: red,
+		},
+		{
+			name: "no color",
+			args: args{t: 0.5},
+			want: color.Transparent,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, color.RGBA64Model.Convert(gradientColor(tt.args.colors, tt.args.t)), color.RGBA64Model.Convert(tt.want))
+		})
+	}
+}
+
+func Test_linearGradient_At(t *testing.T) {
+	black, white := color.RGBA64Model.Convert(color.Black), color.RGBA64Model.Convert(color.White)
+	g := &linearGradient{start: vec{0, 0}, end: vec{0, 100}, stops: EvenStops(color.Black, color.White)}
+	assert.Equal(t, color.RGBA64Model.Convert(g.At(50, -10)), black)
+	assert.Equal(t, color.RGBA64Model.Convert(g.At(0, 200)), white)
+	// the gradient is vertical
+	assert.Equal(t, g.At(0, 49), g.At(100, 49))
+	_, _, b1, _ := g.At(0, 20).RGBA()
+	_, _, b2, _ := g.At(0, 80).RGBA()
+	assert.Equal(t, b1 < b2, true)
+}
+
+func Test_stopColor(t *testing.T) {
+	red, blue := color.RGBA{R: 255, A: 255}, color.RGBA{B: 255, A: 255}
+	stops := sortStops([]ColorStop{{Offset: 1, Color: blue}, {Offset: 0.25, Color: red}, {Offset: 0.75, Color: color.White}, {Offset: 0.75, Color: color.Black}})
+	tests := []struct {
+		name string
+		t    float64
+		want color.Color
+	}{
+		{
+			name: "before the first stop",
+			t:    0.1,
+			want: red,
+		},
+		{
+			name: "between the stops",
+			t:    0.5,
+			want: color.RGBA64{R: 0xffff, G: 0x8000, B: 0x8000, A: 0xffff},
+		},
+		{
+			name: "hard stop",
+			t:    0.75,
+			want: color.Black,
+		},
+		{
+			name: "after the last stop",
+			t:    2,
+			want: blue,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, color.RGBA64Model.Convert(stopColor(stops, tt.t)), color.RGBA64Model.Convert(tt.want))
+		})
+	}
+}
+
+func TestEvenStops(t *testing.T) {
+	assert.Equal(t, EvenStops(color.Black, color.Gray{}, color.White), []ColorStop{{0, color.Black}, {0.5, color.Gray{}}, {1, color.White}})
+	assert.Equal(t, EvenStops(color.Black), []ColorStop{{0, color.Black}})
+}
+
+func TestNewGradients(t *testing.T) {
+	red, blue := color.RGBA{R: 255, A: 255}, color.RGBA{B: 255, A: 255}
+	stops := EvenStops(red, blue)
+	tests := []struct {
+		name string
+		img  image.Image
+		// want is the color of the pixel
+		want map[image.Point]color.Color
+	}{
+		{
+			name: "linear",
+			img:  NewLinearGradient(image.Point{X: 10}, image.Point{X: 90}, stops),
+			want: map[image.Point]color.Color{{X: 0, Y: 50}: red, {X: 50, Y: 0}: color.RGBA64{R: 0x8000, B: 0x8000, A: 0xffff}, {X: 95, Y: 99}: blue},
+		},
+		{
+			name: "radial",
+			img:  NewRadialGradient(image.Point{X: 50, Y: 50}, 40, stops),
+			want: map[image.Point]color.Color{{X: 50, Y: 50}: red, {X: 50, Y: 70}: color.RGBA64{R: 0x8000, B: 0x8000, A: 0xffff}, {X: 0, Y: 0}: blue},
+		},
+		{
+			name: "conic from the right",
+			img:  NewConicGradient(image.Point{X: 50, Y: 50}, 90, []ColorStop{{0, red}, {0.5, red}, {0.5, blue}, {1, blue}}),
+			want: map[image.Point]color.Color{{X: 20, Y: 80}: red, {X: 20, Y: 20}: blue, {X: 80, Y: 20}: blue, {X: 80, Y: 80}: red},
+		},
+		{
+			name: "pattern",
+			img:  NewPattern(image.NewUniform(red), image.Point{X: 5, Y: 5}),
+			want: map[image.Point]color.Color{{X: -100, Y: 1000}: red},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for p, want := range tt.want {
+				assert.Equal(t, color.RGBA64Model.Convert(tt.img.At(p.X, p.Y)), color.RGBA64Model.Convert(want), p.String())
+			}
+		})
+	}
+}
+
+func TestPaint(t *testing.T) {
+	img := Paint(30, 20, NewLinearGradient(image.Point{}, image.Point{X: 29}, EvenStops(color.Black, color.White)))
+	assert.Equal(t, img.Bounds(), image.Rect(0, 0, 30, 20))
+	assert.Equal(t, color.GrayModel.Convert(img.At(0, 10)), color.Gray{})
+	assert.Equal(t, color.GrayModel.Convert(img.At(29, 10)), color.Gray{Y: 255})
+	assert.Equal(t, Paint(10, 10, nil).At(5, 5), color.RGBA{})
+}
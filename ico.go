@@ -0,0 +1,391 @@
+package imgedit
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+)
+
+const (
+	icoHeader      = "\x00\x00\x01\x00"
+	icoHeaderSize  = 6
+	icoEntrySize   = 16
+	icoBmpInfoSize = 40
+	// IcoMaxSize is the max width and height of the image in the ico
+	IcoMaxSize = 256
+)
+
+// IcoSizes are the sizes of the images in favicon.ico
+var IcoSizes = []int{16, 32, 48}
+
+// FaviconFile is the png icon saved with favicon.ico
+type FaviconFile struct {
+	Name string
+	Size int
+}
+
+// FaviconFiles are the png icons for the browsers, iOS and Android
+var FaviconFiles = []FaviconFile{
+	{Name: "favicon-16x16.png", Size: 16},
+	{Name: "favicon-32x32.png", Size: 32},
+	{Name: "apple-touch-icon.png", Size: 180},
+	{Name: "android-chrome-192x192.png", Size: 192},
+	{Name: "android-chrome-512x512.png", Size: 512},
+}
+
+// EncodeIco write the images in the ico, the images of 256px are stored as png and the others as bmp
+func EncodeIco(w io.Writer, images ...image.Image) error {
+	if len(images) == 0 {
+		return errors.New("ico: no image to encode")
+	}
+	var entries [][]byte
+	for _, img := range images {
+		size := img.Bounds().Size()
+		if size.X < 1 || size.Y < 1 || size.X > IcoMaxSize || size.Y > IcoMaxSize {
+			return errors.New("ico: image size is out of range")
+		}
+		data, err := icoEntryData(img)
+		if err != nil {
+			return err
+		}
+		entries = append(entries, data)
+	}
+
+	b := &bytes.Buffer{}
+	b.WriteString(icoHeader)
+	_ = binary.Write(b, binary.LittleEndian, uint16(len(images)))
+	offset := icoHeaderSize + icoEntrySize*len(images)
+	for i, img := range images {
+		size := img.Bounds().Size()
+		// 0 means 256px
+		b.Write([]byte{byte(size.X), byte(size.Y), 0, 0})
+		_ = binary.Write(b, binary.LittleEndian, []uint16{1, 32})
+		_ = binary.Write(b, binary.LittleEndian, []uint32{uint32(len(entries[i])), uint32(offset)})
+		offset += len(entries[i])
+	}
+	for _, data := range entries {
+		b.Write(data)
+	}
+	_, err := w.Write(b.Bytes())
+	return err
+}
+
+// icoEntryData return png of 256px, or bmp of 32 bits with the mask, the old windows can not read the small png
+func icoEntryData(img image.Image) ([]byte, error) {
+	b := &bytes.Buffer{}
+	size := img.Bounds().Size()
+	if size.X == IcoMaxSize || size.Y == IcoMaxSize {
+		err := png.Encode(b, img)
+		return b.Bytes(), err
+	}
+
+	maskStride := (size.X + 31) / 32 * 4
+	header := []uint32{icoBmpInfoSize, uint32(size.X), uint32(size.Y * 2)}
+	_ = binary.Write(b, binary.LittleEndian, header)
+	_ = binary.Write(b, binary.LittleEndian, []uint16{1, 32})
+	_ = binary.Write(b, binary.LittleEndian, []uint32{0, uint32((size.X*4 + maskStride) * size.Y), 0, 0, 0, 0})
+
+	mask := make([]byte, maskStride*size.Y)
+	origin := img.Bounds().Min
+	// the rows are bottom up
+	for y := size.Y - 1; y >= 0; y-- {
+		maskRow := mask[(size.Y-1-y)*maskStride:]
+		for x := 0; x < size.X; x++ {
+			c := color.NRGBAModel.Convert(img.At(origin.X+x, origin.Y+y)).(color.NRGBA)
+			b.Write([]byte{c.B, c.G, c.R, c.A})
+			if c.A == 0 {
+				maskRow[x/8] |= 0x80 >> (x % 8)
+			}
+		}
+	}
+	b.Write(mask)
+	return b.Bytes(), nil
+}
+
+// DecodeIco return all images in the ico in the order of the entries
+func DecodeIco(r io.Reader) ([]image.Image, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := readIcoEntries(data)
+	if err != nil {
+		return nil, err
+	}
+	var images []image.Image
+	for _, entry := range entries {
+		img, err := decodeIcoEntry(entry)
+		if err != nil {
+			return nil, err
+		}
+		images = append(images, img)
+	}
+	return images, nil
+}
+
+// icoEntry is the image in the ico
+type icoEntry struct {
+	width, height int
+	bitCount      int
+	data          []byte
+}
+
+func readIcoEntries(data []byte) ([]icoEntry, error) {
+	if len(data) < icoHeaderSize || string(data[:4]) != icoHeader {
+		return nil, errors.New("ico: invalid format")
+	}
+	count := int(binary.LittleEndian.Uint16(data[4:]))
+	if count == 0 || len(data) < icoHeaderSize+icoEntrySize*count {
+		return nil, errors.New("ico: invalid format")
+	}
+	var entries []icoEntry
+	for i := 0; i < count; i++ {
+		e := data[icoHeaderSize+icoEntrySize*i:]
+		length, offset := binary.LittleEndian.Uint32(e[8:]), binary.LittleEndian.Uint32(e[12:])
+		if uint64(offset)+uint64(length) > uint64(len(data)) {
+			return nil, errors.New("ico: entry is out of range")
+		}
+		entry := icoEntry{
+			width:    int(e[0]),
+			height:   int(e[1]),
+			bitCount: int(binary.LittleEndian.Uint16(e[6:])),
+			data:     data[offset : offset+length],
+		}
+		if entry.width == 0 {
+			entry.width = IcoMaxSize
+		}
+		if entry.height == 0 {
+			entry.height = IcoMaxSize
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// largestIcoEntry return the widest entry, and the one of the most colors in the same width
+func largestIcoEntry(entries []icoEntry) icoEntry {
+	largest := entries[0]
+	for _, entry := range entries[1:] {
+		if entry.width*entry.height > largest.width*largest.height ||
+			(entry.width*entry.height == largest.width*largest.height && entry.bitCount > largest.bitCount) {
+			largest = entry
+		}
+	}
+	return largest
+}
+
+// decodeIcoImage is the decoder for image.Decode, return the largest image in the ico
+func decodeIcoImage(r io.Reader) (image.Image, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := readIcoEntries(data)
+	if err != nil {
+		return nil, err
+	}
+	return decodeIcoEntry(largestIcoEntry(entries))
+}
+
+func decodeIcoConfig(r io.Reader) (image.Config, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return image.Config{}, err
+	}
+	entries, err := readIcoEntries(data)
+	if err != nil {
+		return image.Config{}, err
+	}
+	entry := largestIcoEntry(entries)
+	return image.Config{ColorModel: color.NRGBAModel, Width: entry.width, Height: entry.height}, nil
+}
+
+func decodeIcoEntry(entry icoEntry) (image.Image, error) {
+	if bytes.HasPrefix(entry.data, []byte(pngSignature)) {
+		return png.Decode(bytes.NewReader(entry.data))
+	}
+	return decodeIcoBmp(entry.data)
+}
+
+// decodeIcoBmp decode bmp without the file header, the height is doubled for the mask
+func decodeIcoBmp(data []byte) (image.Image, error) {
+	if len(data) < icoBmpInfoSize {
+		return nil, errors.New("ico: invalid bmp")
+	}
+	headerSize := int(binary.LittleEndian.Uint32(data[0:]))
+	width := int(int32(binary.LittleEndian.Uint32(data[4:])))
+	height := int(int32(binary.LittleEndian.Uint32(data[8:]))) / 2
+	bitCount := int(binary.LittleEndian.Uint16(data[14:]))
+	compression := binary.LittleEndian.Uint32(data[16:])
+	colorsUsed := int(binary.LittleEndian.Uint32(data[32:]))
+	if width <= 0 || height <= 0 || headerSize < icoBmpInfoSize || (compression != 0 && !(compression == 3 && bitCount == 32)) {
+		return nil, errors.New("ico: unsupported bmp")
+	}
+
+	var palette []color.NRGBA
+	offset := headerSize
+	switch bitCount {
+	case 1, 4, 8:
+		if colorsUsed == 0 {
+			colorsUsed = 1 << bitCount
+		}
+		if len(data) < offset+colorsUsed*4 {
+			return nil, errors.New("ico: invalid bmp")
+		}
+		for i := 0; i < colorsUsed; i++ {
+			p := data[offset+i*4:]
+			palette = append(palette, color.NRGBA{R: p[2], G: p[1], B: p[0], A: 255})
+		}
+		offset += colorsUsed * 4
+	case 24, 32:
+	default:
+		return nil, errors.New("ico: unsupported bmp")
+	}
+	stride := (width*bitCount + 31) / 32 * 4
+	maskStride := (width + 31) / 32 * 4
+	if len(data) < offset+stride*height {
+		return nil, errors.New("ico: invalid bmp")
+	}
+	// the mask is omitted by some encoders of 32 bits
+	mask := data[offset+stride*height:]
+	hasMask := len(mask) >= maskStride*height
+
+	dst := image.NewNRGBA(image.Rect(0, 0, width, height))
+	hasAlpha := false
+	for y := 0; y < height; y++ {
+		row := data[offset+(height-1-y)*stride:]
+		for x := 0; x < width; x++ {
+			var c color.NRGBA
+			switch bitCount {
+			case 32:
+				c = color.NRGBA{R: row[x*4+2], G: row[x*4+1], B: row[x*4], A: row[x*4+3]}
+				hasAlpha = hasAlpha || c.A != 0
+			case 24:
+				c = color.NRGBA{R: row[x*3+2], G: row[x*3+1], B: row[x*3], A: 255}
+			default:
+				pixelsPerByte := 8 / bitCount
+				index := int(row[x/pixelsPerByte]>>((pixelsPerByte-1-x%pixelsPerByte)*bitCount)) & (1<<bitCount - 1)
+				if index < len(palette) {
+					c = palette[index]
+				}
+			}
+			dst.SetNRGBA(x, y, c)
+		}
+	}
+	// the alpha of 32 bits takes precedence over the mask, the old icons have no alpha
+	if hasMask && (bitCount != 32 || !hasAlpha) {
+		for y := 0; y < height; y++ {
+			row := mask[(height-1-y)*maskStride:]
+			for x := 0; x < width; x++ {
+				i := dst.PixOffset(x, y)
+				dst.Pix[i+3] = 255
+				if row[x/8]&(0x80>>(x%8)) != 0 {
+					dst.Pix[i+3] = 0
+				}
+			}
+		}
+	}
+	return dst, nil
+}
+
+// icoImage return the image scaled down to fit in 256px, the image in the range is returned as it is
+func icoImage(img image.Image) image.Image {
+	size := img.Bounds().Size()
+	if size.X <= IcoMaxSize && size.Y <= IcoMaxSize {
+		return img
+	}
+	ratio := math.Min(float64(IcoMaxSize)/float64(size.X), float64(IcoMaxSize)/float64(size.Y))
+	return scaleImage(img, image.Point{
+		X: int(math.Max(1, math.Round(float64(size.X)*ratio))),
+		Y: int(math.Max(1, math.Round(float64(size.Y)*ratio))),
+	})
+}
+
+// NewIcon return the square image of the size, the image is scaled to fit and placed at the center on the transparent
+func NewIcon(img image.Image, size int) image.Image {
+	srcSize := img.Bounds().Size()
+	dst := image.NewRGBA(image.Rect(0, 0, size, size))
+	if srcSize.X == 0 || srcSize.Y == 0 {
+		return dst
+	}
+	ratio := math.Min(float64(size)/float64(srcSize.X), float64(size)/float64(srcSize.Y))
+	scaled := scaleImage(img, image.Point{
+		X: int(math.Max(1, math.Round(float64(srcSize.X)*ratio))),
+		Y: int(math.Max(1, math.Round(float64(srcSize.Y)*ratio))),
+	})
+	offset := dst.Bounds().Size().Sub(scaled.Bounds().Size()).Div(2)
+	draw.Draw(dst, scaled.Bounds().Add(offset), scaled, image.Point{}, draw.Src)
+	return dst
+}
+
+// scaleImage resize the image with the average of the pixels, the thin lines remain in the small icons unlike Resize
+func scaleImage(img image.Image, size image.Point) *image.RGBA {
+	src := img.Bounds()
+	dst := image.NewRGBA(image.Rectangle{Max: size})
+	for y := 0; y < size.Y; y++ {
+		y0 := src.Min.Y + y*src.Dy()/size.Y
+		y1 := src.Min.Y + (y+1)*src.Dy()/size.Y
+		if y1 <= y0 {
+			y1 = y0 + 1
+		}
+		for x := 0; x < size.X; x++ {
+			x0 := src.Min.X + x*src.Dx()/size.X
+			x1 := src.Min.X + (x+1)*src.Dx()/size.X
+			if x1 <= x0 {
+				x1 = x0 + 1
+			}
+			var r, g, b, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					cr, cg, cb, ca := img.At(sx, sy).RGBA()
+					r, g, b, a, n = r+uint64(cr), g+uint64(cg), b+uint64(cb), a+uint64(ca), n+1
+				}
+			}
+			// the average of 16 bits is rounded to 8 bits
+			dst.SetRGBA(x, y, color.RGBA{
+				R: uint8((r*2 + n*0x101) / (n * 0x202)),
+				G: uint8((g*2 + n*0x101) / (n * 0x202)),
+				B: uint8((b*2 + n*0x101) / (n * 0x202)),
+				A: uint8((a*2 + n*0x101) / (n * 0x202)),
+			})
+		}
+	}
+	return dst
+}
+
+// SaveFavicon save favicon.ico of IcoSizes and the png icons of FaviconFiles in the directory, and return the saved paths
+func SaveFavicon(img image.Image, dir string) ([]string, error) {
+	var icons []image.Image
+	for _, size := range IcoSizes {
+		icons = append(icons, NewIcon(img, size))
+	}
+	b := &bytes.Buffer{}
+	if err := EncodeIco(b, icons...); err != nil {
+		return nil, err
+	}
+	icoPath := filepath.Join(dir, "favicon.ico")
+	if err := os.WriteFile(icoPath, b.Bytes(), 0644); err != nil {
+		return nil, err
+	}
+	paths := []string{icoPath}
+	for _, file := range FaviconFiles {
+		b.Reset()
+		if err := png.Encode(b, NewIcon(img, file.Size)); err != nil {
+			return paths, err
+		}
+		pngPath := filepath.Join(dir, file.Name)
+		if err := os.WriteFile(pngPath, b.Bytes(), 0644); err != nil {
+			return paths, err
+		}
+		paths = append(paths, pngPath)
+	}
+	return paths, nil
+}
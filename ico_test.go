@@ -0,0 +1,180 @@
+package imgedit
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/magiconair/properties/assert"
+)
+
+func getTestIcon(size int) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, size, size))
+	for i := range img.Pix {
+		img.Pix[i] = uint8(i * 7)
+	}
+	// the transparent pixel
+	img.SetNRGBA(0, 0, color.NRGBA{})
+	return img
+}
+
+func TestEncodeIco(t *testing.T) {
+	type args struct {
+		images []image.Image
+	}
+	tests := []struct {
+		name    string
+		args    args
+		wantErr bool
+	}{
+		{
+			name:    "bmp",
+			args:    args{images: []image.Image{getTestIcon(16), getTestIcon(33)}},
+			wantErr: false,
+		},
+		{
+			name:    "png",
+			args:    args{images: []image.Image{getTestIcon(48), getTestIcon(256)}},
+			wantErr: false,
+		},
+		{
+			name:    "too large",
+			args:    args{images: []image.Image{getTestIcon(257)}},
+			wantErr: true,
+		},
+		{
+			name:    "no image",
+			args:    args{images: nil},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := &bytes.Buffer{}
+			err := EncodeIco(w, tt.args.images...)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("EncodeIco() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+			data := w.Bytes()
+			got, err := DecodeIco(bytes.NewReader(data))
+			if err != nil {
+				t.Fatal(err)
+			}
+			assert.Equal(t, len(got), len(tt.args.images))
+			for i, img := range got {
+				want := tt.args.images[i].(*image.NRGBA)
+				assert.Equal(t, img.Bounds(), want.Bounds())
+				assert.Equal(t, color.NRGBAModel.Convert(img.At(0, 0)).(color.NRGBA).A, uint8(0))
+				assert.Equal(t, color.NRGBAModel.Convert(img.At(5, 3)), want.At(5, 3))
+			}
+
+			// image.Decode return the largest image
+			img, format, err := image.Decode(bytes.NewReader(data))
+			if err != nil {
+				t.Fatal(err)
+			}
+			assert.Equal(t, format, string(Ico))
+			assert.Equal(t, img.Bounds(), tt.args.images[len(tt.args.images)-1].Bounds())
+			config, _, err := image.DecodeConfig(bytes.NewReader(data))
+			if err != nil {
+				t.Fatal(err)
+			}
+			assert.Equal(t, config.Width, img.Bounds().Dx())
+		})
+	}
+}
+
+func Test_decodeIcoBmp(t *testing.T) {
+	// 2x2 1 bit with the palette of black and red, the right bottom is transparent by the mask
+	b := &bytes.Buffer{}
+	_ = binary.Write(b, binary.LittleEndian, []uint32{icoBmpInfoSize, 2, 4})
+	_ = binary.Write(b, binary.LittleEndian, []uint16{1, 1})
+	_ = binary.Write(b, binary.LittleEndian, []uint32{0, 0, 0, 0, 2, 0})
+	b.Write([]byte{0, 0, 0, 0, 0, 0, 255, 0})
+	// the rows are bottom up
+	b.Write([]byte{0x40, 0, 0, 0, 0x80, 0, 0, 0})
+	b.Write([]byte{0x40, 0, 0, 0, 0x00, 0, 0, 0})
+
+	got, err := decodeIcoBmp(b.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	red, black := color.NRGBA{R: 255, A: 255}, color.NRGBA{A: 255}
+	assert.Equal(t, got.At(0, 0), red)
+	assert.Equal(t, got.At(1, 0), black)
+	assert.Equal(t, got.At(0, 1), black)
+	assert.Equal(t, got.At(1, 1), color.NRGBA{R: 255})
+
+	_, err = decodeIcoBmp(b.Bytes()[:50])
+	assert.Equal(t, err != nil, true)
+}
+
+func Test_byteConverter_WriteAs_ico(t *testing.T) {
+	w := &bytes.Buffer{}
+	if err := NewByteConverterFromImage(image.NewRGBA(image.Rect(0, 0, 600, 300))).WriteAs(w, Ico); err != nil {
+		t.Fatal(err)
+	}
+	got, extension, err := NewByteConverter(w)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, extension, Ico)
+	assert.Equal(t, got.Convert().Bounds(), image.Rect(0, 0, 256, 128))
+}
+
+func TestNewIcon(t *testing.T) {
+	src := image.NewRGBA(image.Rect(0, 0, 40, 20))
+	for x := 0; x < 40; x++ {
+		for y := 0; y < 20; y++ {
+			// the thin line remains by the average
+			if x%2 == 0 {
+				src.Set(x, y, color.White)
+			}
+		}
+	}
+	got := NewIcon(src, 10)
+	assert.Equal(t, got.Bounds(), image.Rect(0, 0, 10, 10))
+	assert.Equal(t, got.At(5, 0), color.RGBA{})
+	assert.Equal(t, got.At(5, 5), color.RGBA{R: 128, G: 128, B: 128, A: 128})
+
+	// the colors are kept in the same size
+	src.Set(1, 1, color.RGBA{R: 255, G: 200, B: 1, A: 255})
+	got = NewIcon(src, 40)
+	assert.Equal(t, got.At(1, 11), color.RGBA{R: 255, G: 200, B: 1, A: 255})
+}
+
+func TestSaveFavicon(t *testing.T) {
+	dir := t.TempDir()
+	paths, err := SaveFavicon(GetPngImage(), dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, len(paths), len(FaviconFiles)+1)
+	data, err := os.ReadFile(filepath.Join(dir, "favicon.ico"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	icons, err := DecodeIco(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, icon := range icons {
+		assert.Equal(t, icon.Bounds().Dx(), IcoSizes[i])
+	}
+	for i, file := range FaviconFiles {
+		c, _, err := NewFileConverter(paths[i+1])
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, filepath.Base(paths[i+1]), file.Name)
+		assert.Equal(t, c.Convert().Bounds().Dx(), file.Size)
+	}
+}
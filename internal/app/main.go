@@ -5,13 +5,15 @@ import (
 	"fmt"
 	"image"
 	"image/color"
+	"image/png"
+	"math"
 	"os"
 	"path"
 	"path/filepath"
 	"strconv"
 	"strings"
+	"time"
 
-	"github.com/golang/freetype/truetype"
 	"github.com/icemint0828/imgedit"
 )
 
@@ -22,20 +24,22 @@ const (
 type App struct {
 	subCommand    *SubCommand
 	filePath      string
+	filePaths     []string
 	fileExtension string
 	extension     imgedit.Extension
 }
 
-// NewApp create app
-func NewApp(subCommand *SubCommand, filePath string) *App {
+// NewApp create app, the output file name is based on the first file path
+func NewApp(subCommand *SubCommand, filePaths ...string) *App {
 	return &App{
 		subCommand:    subCommand,
-		filePath:      filePath,
-		fileExtension: filepath.Ext(filePath),
+		filePath:      filePaths[0],
+		filePaths:     filePaths,
+		fileExtension: filepath.Ext(filePaths[0]),
 	}
 }
 
-type subcommand func(imgedit.FileConverter)
+type subcommand func(imgedit.FileConverter) error
 
 var subcommands = map[string]subcommand{
 	"resize":    resize,
@@ -45,27 +49,43 @@ var subcommands = map[string]subcommand{
 	"grayscale": grayscale,
 	"addstring": addstring,
 	"filter":    filter,
+	"shadow":    shadow,
+	"glow":      glow,
+	"border":    border,
+	"qrcode":    qrcode,
 }
 
 // Run edit the image
 func (a *App) Run() error {
 	// load image
-	c, extension, err := imgedit.NewFileConverter(a.filePath)
+	var c imgedit.FileConverter
+	var extension imgedit.Extension
+	var err error
+	switch {
+	case a.subCommand.NewImage:
+		extension, err = getExtension(a.filePath)
+		c = imgedit.NewFileConverterFromImage(newImage())
+	case a.subCommand.MultipleImages:
+		c, extension, err = a.loadMultiple()
+	default:
+		c, extension, err = imgedit.NewFileConverter(a.filePath)
+	}
 	if err != nil {
 		return err
 	}
+	if a.subCommand.Name == SubCommandFavicon.Name {
+		return saveFavicon(c)
+	}
 	// convert image
 	if subcommand, ok := subcommands[a.subCommand.Name]; ok {
-		subcommand(c)
-	} else {
-		switch a.subCommand.Name {
-		case SubCommandPng.Name:
-			extension = imgedit.Png
-		case SubCommandJpeg.Name:
-			extension = imgedit.Jpeg
-		case SubCommandGif.Name:
-			extension = imgedit.Gif
+		if err = subcommand(c); err != nil {
+			return err
 		}
+	} else if a.subCommand.Name == SubCommandSprite.Name {
+		c, extension = spriteSheet(c), imgedit.Png
+	} else if format, ok := imgedit.LookupFormat(imgedit.Extension(a.subCommand.Name)); ok {
+		// the subcommand of the format converts the file
+		extension = format.Extension
 	}
 
 	// save image
@@ -73,7 +93,7 @@ func (a *App) Run() error {
 	if err != nil {
 		return err
 	}
-	err = c.SaveAs(outputPath, extension)
+	err = saveAs(c, outputPath, extension)
 	if err != nil {
 		return err
 	}
@@ -81,56 +101,346 @@ func (a *App) Run() error {
 	return nil
 }
 
-func resize(c imgedit.FileConverter) {
+// saveAs save the image with the output options, the animated gif is written with the palette option
+func saveAs(c imgedit.FileConverter, outputPath string, extension imgedit.Extension) error {
+	options := &imgedit.WriteOptions{
+		Quality:          OptionQuality.Int(),
+		CompressionLevel: getCompressionLevel(OptionCompression.String()),
+		Gif:              &imgedit.GifOptions{SharedPalette: OptionShared.Bool()},
+	}
+	if OptionMaxSize.String() == "" {
+		return c.SaveAsWithOptions(outputPath, extension, options)
+	}
+	return saveWithinSize(c, outputPath, extension, options)
+}
+
+// saveWithinSize save the image within max-size with the highest quality, the image is downscaled if it is needed
+func saveWithinSize(c imgedit.FileConverter, outputPath string, extension imgedit.Extension, options *imgedit.WriteOptions) error {
+	maxBytes, err := getByteSize(OptionMaxSize.String())
+	if err != nil {
+		return err
+	}
+	bc, ok := c.(imgedit.ByteConverter)
+	if !ok {
+		return errors.New("max-size is unsupported")
+	}
+	dstFile, err := os.Create(outputPath)
+	if err != nil {
+		return err
+	}
+	result, err := bc.WriteWithinSizeWithOptions(dstFile, extension, maxBytes, &imgedit.SizeOptions{WriteOptions: options, Downscale: true})
+	if closeErr := dstFile.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(outputPath)
+		return err
+	}
+	if result.Quality > 0 {
+		fmt.Printf("quality: %d\n", result.Quality)
+	}
+	fmt.Printf("size: %dx%d %d bytes\n", result.Width, result.Height, result.Size)
+	return nil
+}
+
+// saveFavicon save favicon.ico and the png icons in the working directory
+func saveFavicon(c imgedit.FileConverter) error {
+	outputDir, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+	outputPaths, err := imgedit.SaveFavicon(c.Convert(), outputDir)
+	// Directory of the host when started by docker
+	hostDir := os.Getenv(EnvWd)
+	for _, outputPath := range outputPaths {
+		displayPath := outputPath
+		if hostDir != "" {
+			displayPath = path.Join(hostDir, filepath.Base(outputPath))
+		}
+		fmt.Printf("save convert file: %s\n", displayPath)
+	}
+	return err
+}
+
+// loadMultiple load all images and combine them by subcommand
+func (a *App) loadMultiple() (imgedit.FileConverter, imgedit.Extension, error) {
+	if a.subCommand.Name == SubCommandGif.Name {
+		return a.loadAnimation()
+	}
+	var images []image.Image
+	var extension imgedit.Extension
+	for i, filePath := range a.filePaths {
+		c, e, err := imgedit.NewFileConverter(filePath)
+		if err != nil {
+			return nil, "", err
+		}
+		if i == 0 {
+			extension = e
+		}
+		images = append(images, c.Convert())
+	}
+	return imgedit.NewFileConverterFromImage(montage(images, a.filePaths)), extension, nil
+}
+
+// loadAnimation build the animation from the images or the directory, or split the sprite sheet
+func (a *App) loadAnimation() (imgedit.FileConverter, imgedit.Extension, error) {
+	options := &imgedit.AnimationOptions{
+		Delay:      time.Duration(OptionDelay.Int()) * time.Millisecond,
+		LoopCount:  OptionLoop.Int(),
+		Width:      OptionWidth.Int(),
+		Height:     OptionHeight.Int(),
+		Fit:        getFit(OptionFit.String()),
+		Background: getColor(OptionColor.String()),
+	}
+	if len(a.filePaths) == 1 {
+		if info, err := os.Stat(a.filePath); err == nil && info.IsDir() {
+			c, err := imgedit.NewAnimationFromDir(a.filePath, options)
+			return c, imgedit.Gif, err
+		}
+		c, extension, err := imgedit.NewFileConverter(a.filePath)
+		if err != nil || (!OptionX.IsSet() && !OptionY.IsSet()) {
+			return c, extension, err
+		}
+		sheet := imgedit.NewAnimationFromSpriteSheet(c.Convert(), &imgedit.SpriteSheetOptions{
+			Cols:   OptionX.Int(),
+			Rows:   OptionY.Int(),
+			Layout: getLayout(OptionLayout.String()),
+			Gap:    OptionGutter.Int(),
+			Delay:  options.Delay,
+		})
+		sheet.SetLoopCount(options.LoopCount)
+		return sheet, extension, nil
+	}
+
+	var images []image.Image
+	for _, filePath := range a.filePaths {
+		c, _, err := imgedit.NewFileConverter(filePath)
+		if err != nil {
+			return nil, "", err
+		}
+		images = append(images, c.Convert())
+	}
+	return imgedit.NewAnimation(images, options), imgedit.Gif, nil
+}
+
+// spriteSheet return the sprite sheet of the frames of the animation
+func spriteSheet(c imgedit.FileConverter) imgedit.FileConverter {
+	frames := []imgedit.Frame{{Image: c.Convert()}}
+	if a, ok := c.(imgedit.AnimationConverter); ok {
+		frames = a.Frames()
+	}
+	return imgedit.NewFileConverterFromImage(imgedit.NewSpriteSheet(frames, &imgedit.SpriteSheetOptions{
+		Cols:     OptionX.Int(),
+		Rows:     OptionY.Int(),
+		Layout:   getLayout(OptionLayout.String()),
+		Gap:      OptionGutter.Int(),
+		GapColor: getColor(OptionColor.String()),
+	}).Convert())
+}
+
+func montage(images []image.Image, filePaths []string) image.Image {
+	options := &imgedit.MontageOptions{
+		Cols:       OptionX.Int(),
+		Rows:       OptionY.Int(),
+		CellWidth:  OptionWidth.Int(),
+		CellHeight: OptionHeight.Int(),
+		Fit:        getFit(OptionFit.String()),
+		Gutter:     OptionGutter.Int(),
+		Background: getColor(OptionColor.String()),
+	}
+	if OptionCaption.Bool() {
+		for _, filePath := range filePaths {
+			options.Captions = append(options.Captions, filepath.Base(filePath))
+		}
+		options.Caption = &imgedit.StringOptions{
+			Font: &imgedit.Font{Typeface: getTypeface(OptionTtf.String()), Size: OptionSize.Float64()},
+		}
+		if options.Caption.Font.Size == 0 {
+			options.Caption.Font.Size = imgedit.DefaultCaptionFontSize
+		}
+	}
+	return imgedit.NewMontage(images, options).Convert()
+}
+
+// newImage create the blank image or the placeholder
+func newImage() image.Image {
+	width, height := OptionWidth.Int(), OptionHeight.Int()
+	if OptionPlaceholder.Bool() {
+		return imgedit.NewPlaceholder(width, height, OptionText.String(), getColor(OptionColor.String())).Convert()
+	}
+	c := imgedit.NewCanvas(width, height, getColor(OptionColor.String()))
+	if OptionText.IsSet() {
+		c.AddString(OptionText.String(), &imgedit.StringOptions{
+			Font:        &imgedit.Font{Typeface: getTypeface(OptionTtf.String()), Size: OptionSize.Float64()},
+			MaxWidth:    width,
+			ShrinkToFit: true,
+		})
+	}
+	return c.Convert()
+}
+
+func resize(c imgedit.FileConverter) error {
 	if OptionRatio.Float64() != 0 {
 		c.ResizeRatio(OptionRatio.Float64())
 	} else {
 		c.Resize(OptionWidth.Int(), OptionHeight.Int())
 	}
+	return nil
 }
 
-func trim(c imgedit.FileConverter) {
+func trim(c imgedit.FileConverter) error {
 	c.Trim(OptionLeft.Int(), OptionTop.Int(), OptionWidth.Int(), OptionHeight.Int())
+	return nil
 }
 
-func reverse(c imgedit.FileConverter) {
+func reverse(c imgedit.FileConverter) error {
 	c.Reverse(!OptionVertical.Bool())
+	return nil
 }
 
-func tile(c imgedit.FileConverter) {
-	c.Tile(OptionX.Int(), OptionY.Int())
+func tile(c imgedit.FileConverter) error {
+	c.TileWithOptions(&imgedit.TileOptions{
+		Cols:     OptionX.Int(),
+		Rows:     OptionY.Int(),
+		Width:    OptionWidth.Int(),
+		Height:   OptionHeight.Int(),
+		Mirror:   OptionMirror.Bool(),
+		Layout:   getLayout(OptionLayout.String()),
+		Gap:      OptionGutter.Int(),
+		GapColor: getColor(OptionColor.String()),
+	})
+	return nil
 }
 
-func grayscale(c imgedit.FileConverter) {
+func grayscale(c imgedit.FileConverter) error {
 	c.Filter(imgedit.GrayModel)
+	return nil
 }
 
-func filter(c imgedit.FileConverter) {
+func filter(c imgedit.FileConverter) error {
 	c.Filter(getModel(OptionMode.String()))
+	return nil
 }
 
-func addstring(c imgedit.FileConverter) {
+func addstring(c imgedit.FileConverter) error {
 	var point *image.Point
 	// If both left and top are not set, it will be centered
 	if OptionLeft.IsSet() && OptionTop.IsSet() {
 		point = &image.Point{X: OptionLeft.Int(), Y: OptionTop.Int()}
 	}
 	option := &imgedit.StringOptions{
-		Point: point,
-		Font:  &imgedit.Font{TrueTypeFont: getTtf(OptionTtf.String()), Size: OptionSize.Float64(), Color: getColor(OptionColor.String())},
+		Point:       point,
+		Font:        &imgedit.Font{Typeface: getTypeface(OptionTtf.String()), Size: OptionSize.Float64(), Color: getColor(OptionColor.String())},
+		Align:       getAlign(OptionAlign.String()),
+		Anchor:      getAnchor(OptionAnchor.String()),
+		MaxWidth:    OptionMaxWidth.Int(),
+		ShrinkToFit: OptionShrink.Bool(),
+		Rotation:    OptionRotation.Float64(),
+		Vertical:    OptionVertical.Bool(),
+		Opacity:     OptionOpacity.Float64(),
+		Markup:      OptionMarkup.Bool(),
+	}
+	if OptionRadius.IsSet() {
+		option.Arc = &imgedit.TextArc{Radius: OptionRadius.Float64()}
+	}
+	if OptionShadowColor.IsSet() || OptionOffset.IsSet() || OptionBlur.IsSet() {
+		offset := OptionOffset.Int()
+		option.Shadow = &imgedit.TextShadow{Offset: image.Point{X: offset, Y: offset}, Blur: OptionBlur.Int(), Color: getColor(OptionShadowColor.String())}
+	}
+	if OptionBackground.IsSet() {
+		option.Background = &imgedit.TextBackground{Color: getColor(OptionBackground.String()), Padding: OptionPadding.Int(), Radius: OptionCornerRadius.Int()}
+	}
+	if OptionGradient.IsSet() {
+		var colors []color.Color
+		for _, colorString := range strings.Split(OptionGradient.String(), ",") {
+			if c := getColor(colorString); c != nil {
+				colors = append(colors, c)
+			}
+		}
+		option.Gradient = &imgedit.TextGradient{Colors: colors}
 	}
 	c.AddString(OptionText.String(), option)
+	return nil
+}
+
+func shadow(c imgedit.FileConverter) error {
+	offset := OptionOffset.Int()
+	c.DropShadow(image.Point{X: offset, Y: offset}, OptionBlur.Int(), getColor(OptionColor.String()), OptionOpacity.Float64())
+	return nil
+}
+
+func glow(c imgedit.FileConverter) error {
+	c.OuterGlow(OptionWidth.Int(), getColor(OptionColor.String()), OptionOpacity.Float64())
+	return nil
+}
+
+func border(c imgedit.FileConverter) error {
+	c.Border(OptionWidth.Int(), getColor(OptionColor.String()))
+	return nil
 }
 
-func getTtf(ttfPath string) *truetype.Font {
-	if ttfPath == "" {
+func qrcode(c imgedit.FileConverter) error {
+	img, err := newCode()
+	if err != nil {
+		return err
+	}
+	bounds := c.Convert().Bounds()
+	// If both left and top are not set, it will be drawn at the right bottom
+	point := image.Point{X: bounds.Dx() - img.Bounds().Dx(), Y: bounds.Dy() - img.Bounds().Dy()}
+	if OptionLeft.IsSet() && OptionTop.IsSet() {
+		point = image.Point{X: OptionLeft.Int(), Y: OptionTop.Int()}
+	}
+	c.AddImage(img, point)
+	return nil
+}
+
+// newCode create the image of the QR code or the barcode, width is the size of the code
+func newCode() (image.Image, error) {
+	text := OptionText.String()
+	if OptionType.String() == "qr" {
+		return imgedit.NewQRCode(text, &imgedit.QROptions{
+			Level:      getLevel(OptionLevel.String()),
+			Size:       OptionWidth.Int(),
+			Color:      getColor(OptionColor.String()),
+			Background: getColor(OptionBackground.String()),
+		})
+	}
+	encode := imgedit.NewCode128
+	if OptionType.String() == "ean13" {
+		encode = imgedit.NewEAN13
+	}
+	options := &imgedit.BarcodeOptions{
+		Height:     OptionHeight.Int(),
+		Color:      getColor(OptionColor.String()),
+		Background: getColor(OptionBackground.String()),
+		Label:      OptionLabel.Bool(),
+	}
+	img, err := encode(text, options)
+	if err != nil || !OptionWidth.IsSet() {
+		return img, err
+	}
+	// the bars are as wide as they fit in width
+	options.ModuleWidth = int(math.Max(1, float64(OptionWidth.Int()*options.ModuleWidth/img.Bounds().Dx())))
+	return encode(text, options)
+}
+
+func getTypeface(nameOrPath string) *imgedit.Typeface {
+	if nameOrPath == "" {
 		return nil
 	}
-	ttf, err := imgedit.ReadTtf(ttfPath)
+	if OptionFontDir.IsSet() {
+		_, _ = imgedit.DefaultFontRegistry.LoadDir(OptionFontDir.String())
+	}
+	// the font name takes precedence over the file path
+	typeface, err := imgedit.DefaultFontRegistry.Lookup(nameOrPath)
+	if err == nil {
+		return typeface
+	}
+	typeface, err = imgedit.ReadTypeface(nameOrPath)
 	if err != nil {
 		return nil
 	}
-	return ttf
+	return typeface
 }
 
 func getColor(colorString string) color.Color {
@@ -191,6 +501,131 @@ func getModel(modeString string) imgedit.FilterModel {
 	}
 }
 
+// getExtension return the format of the file path, png if the path has no extension
+func getExtension(filePath string) (imgedit.Extension, error) {
+	suffix := filepath.Ext(filePath)
+	if suffix == "" {
+		return imgedit.Png, nil
+	}
+	format, ok := imgedit.FormatBySuffix(suffix)
+	if !ok {
+		return "", errors.New("extension is not supported: " + strings.TrimPrefix(suffix, "."))
+	}
+	return format.Extension, nil
+}
+
+func getLevel(levelString string) imgedit.QRLevel {
+	switch strings.ToUpper(levelString) {
+	case "L":
+		return imgedit.QRLevelL
+	case "Q":
+		return imgedit.QRLevelQ
+	case "H":
+		return imgedit.QRLevelH
+	default:
+		return imgedit.QRLevelM
+	}
+}
+
+func getFit(fitString string) imgedit.FitMode {
+	switch fitString {
+	case "cover":
+		return imgedit.FitCover
+	case "stretch":
+		return imgedit.FitStretch
+	default:
+		return imgedit.FitContain
+	}
+}
+
+// getByteSize return the bytes of the size like 200KB or 1.5MB, KB and MB are 1024 bytes and 1024KB
+func getByteSize(sizeString string) (int, error) {
+	s := strings.ToUpper(strings.TrimSpace(sizeString))
+	unit := 1.0
+	for _, u := range []struct {
+		suffix string
+		bytes  float64
+	}{
+		{suffix: "MB", bytes: 1 << 20}, {suffix: "M", bytes: 1 << 20},
+		{suffix: "KB", bytes: 1 << 10}, {suffix: "K", bytes: 1 << 10},
+		{suffix: "B", bytes: 1},
+	} {
+		if strings.HasSuffix(s, u.suffix) {
+			s, unit = strings.TrimSuffix(s, u.suffix), u.bytes
+			break
+		}
+	}
+	v, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	if err != nil || !(v*unit >= 1 && v*unit <= math.MaxInt) {
+		return 0, errors.New("max-size is invalid: " + sizeString)
+	}
+	return int(v * unit), nil
+}
+
+func getCompressionLevel(compressionString string) png.CompressionLevel {
+	switch compressionString {
+	case "none":
+		return png.NoCompression
+	case "speed":
+		return png.BestSpeed
+	case "best":
+		return png.BestCompression
+	default:
+		return png.DefaultCompression
+	}
+}
+
+func getLayout(layoutString string) imgedit.TileLayout {
+	switch layoutString {
+	case "brick":
+		return imgedit.TileBrick
+	case "halfdrop":
+		return imgedit.TileHalfDrop
+	default:
+		return imgedit.TileGrid
+	}
+}
+
+func getAlign(alignString string) imgedit.Align {
+	switch alignString {
+	case "left":
+		return imgedit.AlignLeft
+	case "right":
+		return imgedit.AlignRight
+	default:
+		return imgedit.AlignCenter
+	}
+}
+
+func getAnchor(anchorString string) imgedit.Anchor {
+	switch anchorString {
+	case "topleft":
+		return imgedit.AnchorTopLeft
+	case "top":
+		return imgedit.AnchorTop
+	case "topright":
+		return imgedit.AnchorTopRight
+	case "left":
+		return imgedit.AnchorLeft
+	case "right":
+		return imgedit.AnchorRight
+	case "bottomleft":
+		return imgedit.AnchorBottomLeft
+	case "bottom":
+		return imgedit.AnchorBottom
+	case "bottomright":
+		return imgedit.AnchorBottomRight
+	case "baselineleft":
+		return imgedit.AnchorBaselineLeft
+	case "baseline":
+		return imgedit.AnchorBaseline
+	case "baselineright":
+		return imgedit.AnchorBaselineRight
+	default:
+		return imgedit.AnchorCenter
+	}
+}
+
 func (a *App) getOutputPath(extension imgedit.Extension) (string, string, error) {
 	// Directory of the host when started by docker
 	hostDir := os.Getenv(EnvWd)
@@ -199,7 +634,13 @@ func (a *App) getOutputPath(extension imgedit.Extension) (string, string, error)
 		return "", "", err
 	}
 	var outputFileName string
-	if a.fileExtension == "" {
+	if a.subCommand.NewImage {
+		// the new image is saved with the name as it is
+		outputFileName = filepath.Base(a.filePath)
+	} else if info, err := os.Stat(a.filePath); err == nil && info.IsDir() {
+		// the animation built from the directory
+		outputFileName = filepath.Base(a.filePath) + "_imgedit." + string(extension)
+	} else if a.fileExtension == "" {
 		outputFileName = filepath.Base(a.filePath) + "_imgedit"
 	} else {
 		outputFileName = strings.Replace(filepath.Base(a.filePath), a.fileExtension, "_imgedit."+string(extension), 1)
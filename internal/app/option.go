@@ -67,10 +67,24 @@ var OptionText = &StringOption{
 	},
 	defaultVal: "",
 }
+var OptionPlaceholder = &BoolOption{
+	option: option{
+		name:  "placeholder",
+		usage: "write the text or the image size like 800x600 in the center of the gray image.",
+	},
+	defaultVal: false,
+}
 var OptionTtf = &StringOption{
 	option: option{
 		name:  "ttf",
-		usage: "ttf file path.",
+		usage: "ttf or otf file path, or font name(07LogoTypeGothic7, lightNovelPOP, goregular, or file name in font-dir).",
+	},
+	defaultVal: "",
+}
+var OptionFontDir = &StringOption{
+	option: option{
+		name:  "font-dir",
+		usage: "directory of ttf and otf files to specify by file name with ttf.",
 	},
 	defaultVal: "",
 }
@@ -84,7 +98,147 @@ var OptionSize = &UintOption{
 var OptionColor = &StringOption{
 	option: option{
 		name:  "color",
-		usage: "font color with string (back, white, red, blue, green). or specify by color code(like #FF0000)",
+		usage: "color with string (back, white, red, blue, green). or specify by color code(like #FF0000)",
+	},
+	defaultVal: "",
+}
+var OptionOffset = &UintOption{
+	option: option{
+		name:  "offset",
+		usage: "shadow offset px to the right bottom.",
+	},
+	defaultVal: 0,
+}
+var OptionBlur = &UintOption{
+	option: option{
+		name:  "blur",
+		usage: "blur radius px.",
+	},
+	defaultVal: 0,
+}
+var OptionOpacity = &Float64Option{
+	option: option{
+		name:  "opacity",
+		usage: "opacity (0 <= opacity <= 1). default 1.",
+	},
+	defaultVal: 1,
+}
+var OptionFit = &StringOption{
+	option: option{
+		name:  "fit",
+		usage: "how to resize images into the cell(contain, cover, stretch). default contain.",
+	},
+	defaultVal: "",
+}
+var OptionGutter = &UintOption{
+	option: option{
+		name:  "gutter",
+		usage: "space px between the images, and around them for montage.",
+	},
+	defaultVal: 0,
+}
+var OptionCaption = &BoolOption{
+	option: option{
+		name:  "caption",
+		usage: "add file name as caption under the cells.",
+	},
+	defaultVal: false,
+}
+var OptionMirror = &BoolOption{
+	option: option{
+		name:  "mirror",
+		usage: "flip every other tile for the seamless pattern.",
+	},
+	defaultVal: false,
+}
+var OptionLayout = &StringOption{
+	option: option{
+		name:  "layout",
+		usage: "tile layout(grid, brick, halfdrop). default grid.",
+	},
+	defaultVal: "",
+}
+var OptionAlign = &StringOption{
+	option: option{
+		name:  "align",
+		usage: "text alignment(left, center, right). default center.",
+	},
+	defaultVal: "",
+}
+var OptionAnchor = &StringOption{
+	option: option{
+		name:  "anchor",
+		usage: "text anchor placed at left and top(topleft, top, topright, left, center, right, bottomleft, bottom, bottomright, baselineleft, baseline, baselineright). default center.",
+	},
+	defaultVal: "",
+}
+var OptionMaxWidth = &UintOption{
+	option: option{
+		name:  "max-width",
+		usage: "max width px to wrap the text.",
+	},
+	defaultVal: 0,
+}
+var OptionShrink = &BoolOption{
+	option: option{
+		name:  "shrink",
+		usage: "shrink the font size until the text fits in max-width.",
+	},
+	defaultVal: false,
+}
+var OptionMarkup = &BoolOption{
+	option: option{
+		name:  "markup",
+		usage: "style the spans of the text with the tags like <b>bold</b>, <color=#FF0000>red</color>, <size=40>small</size> and <font=lightNovelPOP>font</font>.",
+	},
+	defaultVal: false,
+}
+var OptionRotation = &Float64Option{
+	option: option{
+		name:  "rotation",
+		usage: "rotation degrees clockwise around the anchor.",
+	},
+	defaultVal: 0,
+}
+var OptionRadius = &UintOption{
+	option: option{
+		name:  "radius",
+		usage: "radius px of the circle to write the text along. the center is left and top.",
+	},
+	defaultVal: 0,
+}
+var OptionShadowColor = &StringOption{
+	option: option{
+		name:  "shadow-color",
+		usage: "shadow color with string or color code. the shadow is drawn if shadow-color, offset or blur is set.",
+	},
+	defaultVal: "",
+}
+var OptionBackground = &StringOption{
+	option: option{
+		name:  "background",
+		usage: "background color of the text with string or color code.",
+	},
+	defaultVal: "",
+}
+var OptionPadding = &UintOption{
+	option: option{
+		name:  "padding",
+		usage: "padding px of the background.",
+	},
+	defaultVal: 0,
+}
+var OptionCornerRadius = &UintOption{
+	option: option{
+		name:  "corner-radius",
+		usage: "corner radius px of the background.",
+	},
+	defaultVal: 0,
+}
+var OptionGradient = &StringOption{
+	option: option{
+		name:  "gradient",
+		usage: "gradient colors separated by comma(like red,#0000FF). font color is ignored.",
 	},
 	defaultVal: "",
 }
@@ -95,6 +249,69 @@ var OptionMode = &StringOption{
 	},
 	defaultVal: "",
 }
+var OptionLevel = &StringOption{
+	option: option{
+		name:  "level",
+		usage: "error correction level of the QR code(L, M, Q, H).",
+	},
+	defaultVal: "M",
+}
+var OptionLabel = &BoolOption{
+	option: option{
+		name:  "label",
+		usage: "write the text under the barcode.",
+	},
+	defaultVal: false,
+}
+var OptionType = &StringOption{
+	option: option{
+		name:  "type",
+		usage: "type of the code(qr, code128, ean13).",
+	},
+	defaultVal: "qr",
+}
+var OptionDelay = &UintOption{
+	option: option{
+		name:  "delay",
+		usage: "delay ms of the frames of the animation. default 100.",
+	},
+	defaultVal: 0,
+}
+var OptionLoop = &UintOption{
+	option: option{
+		name:  "loop",
+		usage: "loop count of the animation, the animation is repeated loop+1 times. default 0 loops forever.",
+	},
+	defaultVal: 0,
+}
+var OptionQuality = &UintOption{
+	option: option{
+		name:  "quality",
+		usage: "jpeg quality 1-100. default 100.",
+	},
+	defaultVal: 0,
+}
+var OptionCompression = &StringOption{
+	option: option{
+		name:  "compression",
+		usage: "png and tiff compression level (none, speed, best). default the standard level.",
+	},
+	defaultVal: "",
+}
+var OptionMaxSize = &StringOption{
+	option: option{
+		name:  "max-size",
+		usage: "max file size like 200KB or 1.5MB, the quality is lowered and the image is downscaled to be within the size.",
+	},
+	defaultVal: "",
+}
+var OptionShared = &BoolOption{
+	option: option{
+		name:  "shared",
+		usage: "use one palette shared by all frames of the animated gif.",
+	},
+	defaultVal: false,
+}
 
 // Option for subcommands
 type Option interface {
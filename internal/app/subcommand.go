@@ -2,6 +2,8 @@ package app
 
 import (
 	"flag"
+
+	"github.com/icemint0828/imgedit"
 )
 
 var SupportedSubCommands = SubCommands{
@@ -12,32 +14,67 @@ var SupportedSubCommands = SubCommands{
 	SubCommandFilter,
 	SubCommandGrayscale,
 	SubCommandAddstring,
-	SubCommandPng,
-	SubCommandJpeg,
-	SubCommandGif,
+	SubCommandShadow,
+	SubCommandGlow,
+	SubCommandBorder,
+	SubCommandMontage,
+	SubCommandNew,
+	SubCommandQRCode,
+	SubCommandSprite,
+	SubCommandFavicon,
 }
 
-var SubCommandPng = &SubCommand{
-	Name:            "png",
-	Usage:           "file convert to png",
-	RequiredOptions: []Option{},
-	OptionalOptions: []Option{},
+// OutputOptions are the options for writing the output file, accepted by all subcommands
+var OutputOptions = []Option{OptionQuality, OptionCompression, OptionMaxSize}
+
+// formatSubCommands are the subcommands of the formats with the options, the others are created by AvailableSubCommands
+var formatSubCommands = map[imgedit.Extension]*SubCommand{
+	imgedit.Gif: SubCommandGif,
 }
 
-var SubCommandJpeg = &SubCommand{
-	Name:            "jpeg",
-	Usage:           "file convert to jpeg",
-	RequiredOptions: []Option{},
-	OptionalOptions: []Option{},
+// AvailableSubCommands return SupportedSubCommands and the subcommands converting to the formats registered by imgedit.RegisterFormat
+func AvailableSubCommands() SubCommands {
+	subCommands := append(SubCommands{}, SupportedSubCommands...)
+	for _, format := range imgedit.Formats() {
+		if format.Encode == nil {
+			continue
+		}
+		if subCommand, ok := formatSubCommands[format.Extension]; ok {
+			subCommands = append(subCommands, subCommand)
+			continue
+		}
+		subCommands = append(subCommands, &SubCommand{
+			Name:            string(format.Extension),
+			Usage:           "file convert to " + format.Description,
+			RequiredOptions: []Option{},
+			OptionalOptions: []Option{},
+		})
+	}
+	return subCommands
 }
 
 var SubCommandGif = &SubCommand{
 	Name:            "gif",
-	Usage:           "file convert to gif",
+	Usage:           "file convert to gif, or build the animated gif from the images or the directory. x and y split the sprite sheet into the frames",
+	RequiredOptions: []Option{},
+	OptionalOptions: []Option{OptionDelay, OptionLoop, OptionWidth, OptionHeight, OptionFit, OptionColor, OptionShared, OptionX, OptionY, OptionGutter, OptionLayout},
+	MultipleImages:  true,
+}
+
+var SubCommandFavicon = &SubCommand{
+	Name:            "favicon",
+	Usage:           "save favicon.ico of 16, 32 and 48px and the png icons of 16, 32, 180, 192 and 512px",
 	RequiredOptions: []Option{},
 	OptionalOptions: []Option{},
 }
 
+var SubCommandSprite = &SubCommand{
+	Name:            "sprite",
+	Usage:           "lay out the frames of the animated gif in the png sprite sheet, x is the number of columns and y is rows",
+	RequiredOptions: []Option{},
+	OptionalOptions: []Option{OptionX, OptionY, OptionGutter, OptionLayout, OptionColor},
+}
+
 var SubCommandReverse = &SubCommand{
 	Name:            "reverse",
 	Usage:           "reverse image",
@@ -54,9 +91,9 @@ var SubCommandResize = &SubCommand{
 
 var SubCommandTile = &SubCommand{
 	Name:            "tile",
-	Usage:           "lay down images with x * y",
-	RequiredOptions: []Option{OptionX, OptionY},
-	OptionalOptions: []Option{},
+	Usage:           "lay down images with x * y, or fill width * height px",
+	RequiredOptions: []Option{},
+	OptionalOptions: []Option{OptionX, OptionY, OptionWidth, OptionHeight, OptionMirror, OptionLayout, OptionGutter, OptionColor},
 }
 
 var SubCommandTrim = &SubCommand{
@@ -77,7 +114,11 @@ var SubCommandAddstring = &SubCommand{
 	Name:            "addstring",
 	Usage:           "add string on image",
 	RequiredOptions: []Option{OptionText},
-	OptionalOptions: []Option{OptionTtf, OptionSize, OptionTop, OptionLeft, OptionColor},
+	OptionalOptions: []Option{
+		OptionTtf, OptionFontDir, OptionSize, OptionTop, OptionLeft, OptionColor,
+		OptionAlign, OptionAnchor, OptionMaxWidth, OptionShrink, OptionRotation, OptionVertical, OptionRadius, OptionMarkup,
+		OptionOffset, OptionBlur, OptionShadowColor, OptionBackground, OptionPadding, OptionCornerRadius, OptionGradient, OptionOpacity,
+	},
 }
 
 var SubCommandFilter = &SubCommand{
@@ -87,12 +128,60 @@ var SubCommandFilter = &SubCommand{
 	OptionalOptions: []Option{},
 }
 
+var SubCommandShadow = &SubCommand{
+	Name:            "shadow",
+	Usage:           "add drop shadow to image",
+	RequiredOptions: []Option{},
+	OptionalOptions: []Option{OptionOffset, OptionBlur, OptionColor, OptionOpacity},
+}
+
+var SubCommandGlow = &SubCommand{
+	Name:            "glow",
+	Usage:           "add outer glow to image",
+	RequiredOptions: []Option{OptionWidth},
+	OptionalOptions: []Option{OptionColor, OptionOpacity},
+}
+
+var SubCommandBorder = &SubCommand{
+	Name:            "border",
+	Usage:           "add border around image",
+	RequiredOptions: []Option{OptionWidth},
+	OptionalOptions: []Option{OptionColor},
+}
+
+var SubCommandMontage = &SubCommand{
+	Name:            "montage",
+	Usage:           "lay out multiple images in rows and columns, x is the number of columns and y is rows",
+	RequiredOptions: []Option{},
+	OptionalOptions: []Option{OptionX, OptionY, OptionWidth, OptionHeight, OptionFit, OptionGutter, OptionColor, OptionCaption, OptionTtf, OptionFontDir, OptionSize},
+	MultipleImages:  true,
+}
+
+var SubCommandNew = &SubCommand{
+	Name:            "new",
+	Usage:           "create the blank image to the path, the extension of the path is the format",
+	RequiredOptions: []Option{OptionWidth, OptionHeight},
+	OptionalOptions: []Option{OptionColor, OptionText, OptionPlaceholder, OptionTtf, OptionFontDir, OptionSize},
+	NewImage:        true,
+}
+
+var SubCommandQRCode = &SubCommand{
+	Name:            "qrcode",
+	Usage:           "draw the QR code or the barcode of the text on image, it is drawn at the right bottom if left and top are not set",
+	RequiredOptions: []Option{OptionText},
+	OptionalOptions: []Option{OptionType, OptionLeft, OptionTop, OptionWidth, OptionHeight, OptionLevel, OptionColor, OptionBackground, OptionLabel},
+}
+
 // SubCommand imgedit subcommand
 type SubCommand struct {
 	Name            string
 	Usage           string
 	RequiredOptions []Option
 	OptionalOptions []Option
+	// MultipleImages accept multiple image paths
+	MultipleImages bool
+	// NewImage create the image instead of loading, the image path is the output file
+	NewImage bool
 }
 
 // ValidOption check the validity of options
@@ -106,7 +195,7 @@ func (s *SubCommand) ValidOption() bool {
 				return
 			}
 		}
-		for _, v := range s.OptionalOptions {
+		for _, v := range append(s.OptionalOptions, OutputOptions...) {
 			if f.Name == v.Name() {
 				return
 			}
@@ -118,6 +207,18 @@ func (s *SubCommand) ValidOption() bool {
 
 type SubCommands []*SubCommand
 
+// IsBoolOption return true, if the option of the name does not take a value
+func (s SubCommands) IsBoolOption(optionName string) bool {
+	for _, v := range s {
+		for _, option := range append(v.RequiredOptions, v.OptionalOptions...) {
+			if _, ok := option.(*BoolOption); ok && option.Name() == optionName {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 func (s SubCommands) FindSubCommand(subCommandName string) *SubCommand {
 	for _, v := range s {
 		if v.Name == subCommandName {
@@ -0,0 +1,169 @@
+package imgedit
+
+import (
+	"errors"
+	"image/color"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// boldWidth ratio of the width px added to the bold letters to the font size
+const boldWidth = 0.04
+
+// markupEntities the escaped letters in the markup
+var markupEntities = map[string]rune{"&lt;": '<', "&gt;": '>', "&amp;": '&'}
+
+// textSpan is the style of the letters set by the markup
+type textSpan struct {
+	bold bool
+	// color nil is the fill of the options
+	color color.Color
+	// size 0 is Font.Size
+	size float64
+	// typeface nil is Font.Typeface
+	typeface *Typeface
+}
+
+// plainSpan is the span of the text without the markup
+var plainSpan = &textSpan{}
+
+// textRun is the letter with the span
+type textRun struct {
+	r    rune
+	span *textSpan
+}
+
+// plainRuns return the letters of the text without the markup
+func plainRuns(text string) []textRun {
+	runs := make([]textRun, 0, len(text))
+	for _, r := range text {
+		runs = append(runs, textRun{r: r, span: plainSpan})
+	}
+	return runs
+}
+
+// parseMarkup return the letters of the text with the spans set by the tags.
+// the tags are <b>, <color=#FF0000>, <size=40> and <font=name>, closed by </b>, </color>, </size> and </font>.
+// the unclosed tags last until the end of the text, unknown or malformed tags are written as they are.
+func parseMarkup(text string) []textRun {
+	type openTag struct {
+		name string
+		span *textSpan
+	}
+	tags := []openTag{{span: plainSpan}}
+	var runs []textRun
+	for len(text) > 0 {
+		current := tags[len(tags)-1].span
+		if text[0] == '&' {
+			if r, n := markupEntity(text); n > 0 {
+				runs = append(runs, textRun{r: r, span: current})
+				text = text[n:]
+				continue
+			}
+		}
+		if end := strings.IndexByte(text, '>'); text[0] == '<' && end > 0 {
+			tag := text[1:end]
+			if strings.HasPrefix(tag, "/") {
+				// close the last tag of the name and the tags opened after it
+				closed := false
+				for i := len(tags) - 1; i > 0 && !closed; i-- {
+					if tags[i].name == tag[1:] {
+						tags, closed = tags[:i], true
+					}
+				}
+				if closed {
+					text = text[end+1:]
+					continue
+				}
+			} else if name, span, err := current.with(tag); err == nil {
+				tags = append(tags, openTag{name: name, span: span})
+				text = text[end+1:]
+				continue
+			}
+		}
+		r, n := utf8.DecodeRuneInString(text)
+		runs = append(runs, textRun{r: r, span: current})
+		text = text[n:]
+	}
+	return runs
+}
+
+// markupEntity return the escaped letter at the start of the text and its length, 0 if it is not escaped
+func markupEntity(text string) (rune, int) {
+	for entity, r := range markupEntities {
+		if strings.HasPrefix(text, entity) {
+			return r, len(entity)
+		}
+	}
+	return 0, 0
+}
+
+// with return the name of the tag and the span changed by it
+func (s *textSpan) with(tag string) (string, *textSpan, error) {
+	name, value, hasValue := strings.Cut(tag, "=")
+	span := *s
+	switch {
+	case name == "b" && !hasValue:
+		span.bold = true
+	case name == "color" && hasValue:
+		c, err := parseColor(value)
+		if err != nil {
+			return "", nil, err
+		}
+		span.color = c
+	case name == "size" && hasValue:
+		size, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return "", nil, err
+		}
+		if size <= 0 {
+			return "", nil, errors.New("size must be positive: " + value)
+		}
+		span.size = size
+	case name == "font" && hasValue:
+		typeface, err := DefaultFontRegistry.Lookup(value)
+		if err != nil {
+			return "", nil, err
+		}
+		span.typeface = typeface
+	default:
+		return "", nil, errors.New("unknown tag: " + tag)
+	}
+	return name, &span, nil
+}
+
+// parseColor return the color of the name or the code like #FF0000, #F00 or #FF000080
+func parseColor(s string) (color.Color, error) {
+	switch s {
+	case "black":
+		return color.Black, nil
+	case "white":
+		return color.White, nil
+	case "red":
+		return color.RGBA{R: 255, A: 255}, nil
+	case "blue":
+		return color.RGBA{B: 255, A: 255}, nil
+	case "green":
+		return color.RGBA{G: 255, A: 255}, nil
+	}
+	if !strings.HasPrefix(s, "#") {
+		return nil, errors.New("invalid color: " + s)
+	}
+	code := s[1:]
+	if len(code) == 3 {
+		code = string([]byte{code[0], code[0], code[1], code[1], code[2], code[2]})
+	}
+	if len(code) == 6 {
+		code += "ff"
+	}
+	if len(code) != 8 {
+		return nil, errors.New("invalid color: " + s)
+	}
+	v, err := strconv.ParseUint(code, 16, 32)
+	if err != nil {
+		return nil, errors.New("invalid color: " + s)
+	}
+	// the color code is not premultiplied
+	return color.NRGBA{R: uint8(v >> 24), G: uint8(v >> 16), B: uint8(v >> 8), A: uint8(v)}, nil
+}
@@ -0,0 +1,141 @@
+package imgedit
+
+import (
+	"image/color"
+	"testing"
+
+	"github.com/magiconair/properties/assert"
+)
+
+func Test_parseMarkup(t *testing.T) {
+	red := color.NRGBA{R: 255, A: 255}
+	pop, _ := DefaultFontRegistry.Lookup(FontLightNovelPOP)
+	tests := []struct {
+		name string
+		text string
+		// want is the text and the span of each letter
+		want     string
+		wantSpan []textSpan
+	}{
+		{
+			name:     "plain",
+			text:     "ab",
+			want:     "ab",
+			wantSpan: []textSpan{{}, {}},
+		},
+		{
+			name:     "bold",
+			text:     "a<b>b</b>c",
+			want:     "abc",
+			wantSpan: []textSpan{{}, {bold: true}, {}},
+		},
+		{
+			name:     "nested",
+			text:     "<color=#ff0000>a<size=40>b</size></color>",
+			want:     "ab",
+			wantSpan: []textSpan{{color: red}, {color: red, size: 40}},
+		},
+		{
+			name:     "font",
+			text:     "<font=lightNovelPOP>a</font>",
+			want:     "a",
+			wantSpan: []textSpan{{typeface: pop}},
+		},
+		{
+			name:     "closing outer tag closes inner tags",
+			text:     "<b><size=40>a</b>b",
+			want:     "ab",
+			wantSpan: []textSpan{{bold: true, size: 40}, {}},
+		},
+		{
+			name:     "unclosed tag",
+			text:     "<b>a\nb",
+			want:     "a\nb",
+			wantSpan: []textSpan{{bold: true}, {bold: true}, {bold: true}},
+		},
+		{
+			name:     "unknown tag is written",
+			text:     "<i>a",
+			want:     "<i>a",
+			wantSpan: []textSpan{{}, {}, {}, {}},
+		},
+		{
+			name:     "invalid value is written",
+			text:     "<size=x>",
+			want:     "<size=x>",
+			wantSpan: []textSpan{{}, {}, {}, {}, {}, {}, {}, {}},
+		},
+		{
+			name:     "unmatched closing tag is written",
+			text:     "</b>",
+			want:     "</b>",
+			wantSpan: []textSpan{{}, {}, {}, {}},
+		},
+		{
+			name:     "escaped",
+			text:     "&lt;b&gt;&amp;",
+			want:     "<b>&",
+			wantSpan: []textSpan{{}, {}, {}, {}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runs := parseMarkup(tt.text)
+			var got []rune
+			var gotSpan []textSpan
+			for _, run := range runs {
+				got = append(got, run.r)
+				gotSpan = append(gotSpan, *run.span)
+			}
+			assert.Equal(t, string(got), tt.want)
+			assert.Equal(t, gotSpan, tt.wantSpan)
+		})
+	}
+}
+
+func Test_parseColor(t *testing.T) {
+	tests := []struct {
+		name    string
+		s       string
+		want    color.Color
+		wantErr bool
+	}{
+		{
+			name: "name",
+			s:    "blue",
+			want: color.RGBA{B: 255, A: 255},
+		},
+		{
+			name: "code",
+			s:    "#FF8000",
+			want: color.NRGBA{R: 255, G: 128, A: 255},
+		},
+		{
+			name: "short code",
+			s:    "#f80",
+			want: color.NRGBA{R: 255, G: 136, A: 255},
+		},
+		{
+			name: "code with alpha",
+			s:    "#ff000080",
+			want: color.NRGBA{R: 255, A: 128},
+		},
+		{
+			name:    "invalid code",
+			s:       "#ff00",
+			wantErr: true,
+		},
+		{
+			name:    "unknown name",
+			s:       "purple",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseColor(tt.s)
+			assert.Equal(t, err != nil, tt.wantErr)
+			assert.Equal(t, got, tt.want)
+		})
+	}
+}
@@ -0,0 +1,164 @@
+package imgedit
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+	"regexp"
+)
+
+// DefaultCaptionFontSize used when caption font size is not specified in MontageOptions
+const DefaultCaptionFontSize = 30
+
+// FitMode how to resize the image into the cell
+type FitMode int
+
+const (
+	// FitContain resize the image to fit in the cell keeping aspect ratio
+	FitContain FitMode = iota
+	// FitCover resize the image to cover the cell keeping aspect ratio, the overflow is trimmed
+	FitCover
+	// FitStretch resize the image to the cell size ignoring aspect ratio
+	FitStretch
+)
+
+// MontageOptions options for NewMontage
+type MontageOptions struct {
+	// Cols default calculated from Rows, or the square root of the number of images
+	Cols int
+	// Rows default calculated from Cols
+	Rows int
+	// CellWidth default max width of images
+	CellWidth int
+	// CellHeight default max height of images
+	CellHeight int
+	// Fit default FitContain
+	Fit FitMode
+	// Gutter space px between and around cells
+	Gutter int
+	// Background default transparent
+	Background color.Color
+	// Captions drawn under each cell in the order of images
+	Captions []string
+	// Caption font and outline of captions, Point is ignored
+	Caption *StringOptions
+}
+
+func (o *MontageOptions) setDefault(images []image.Image) {
+	count := len(images)
+	if o.Cols <= 0 && o.Rows <= 0 {
+		o.Cols = int(math.Ceil(math.Sqrt(float64(count))))
+	}
+	if o.Cols <= 0 {
+		o.Cols = (count + o.Rows - 1) / o.Rows
+	}
+	if o.Rows <= 0 {
+		o.Rows = (count + o.Cols - 1) / o.Cols
+	}
+	if o.CellWidth <= 0 || o.CellHeight <= 0 {
+		var maxWidth, maxHeight int
+		for _, img := range images {
+			if img.Bounds().Dx() > maxWidth {
+				maxWidth = img.Bounds().Dx()
+			}
+			if img.Bounds().Dy() > maxHeight {
+				maxHeight = img.Bounds().Dy()
+			}
+		}
+		if o.CellWidth <= 0 {
+			o.CellWidth = maxWidth
+		}
+		if o.CellHeight <= 0 {
+			o.CellHeight = maxHeight
+		}
+	}
+	if o.Background == nil {
+		o.Background = color.Transparent
+	}
+	if len(o.Captions) > 0 {
+		if o.Caption == nil {
+			o.Caption = &StringOptions{}
+		}
+		if o.Caption.Font == nil {
+			o.Caption.Font = &Font{Size: DefaultCaptionFontSize}
+		}
+		o.Caption.setDefault()
+	}
+}
+
+// captionHeight return the height px of the caption area
+func (o *MontageOptions) captionHeight() int {
+	if len(o.Captions) == 0 {
+		return 0
+	}
+	maxLines := 1
+	for _, caption := range o.Captions {
+		lines := len(regexp.MustCompile("\r\n|\n\r|\n|\r").Split(caption, -1))
+		if lines > maxLines {
+			maxLines = lines
+		}
+	}
+	return maxLines * o.Caption.face().Metrics().Height.Ceil()
+}
+
+// NewMontage create converter with images laid out in rows and columns
+func NewMontage(images []image.Image, options *MontageOptions) Converter {
+	if options == nil {
+		options = &MontageOptions{}
+	}
+	if len(images) == 0 {
+		return &converter{image.NewRGBA(image.Rect(0, 0, 0, 0))}
+	}
+	options.setDefault(images)
+
+	captionHeight := options.captionHeight()
+	cellSize := image.Point{X: options.CellWidth, Y: options.CellHeight}
+	pitch := image.Point{X: cellSize.X + options.Gutter, Y: cellSize.Y + captionHeight + options.Gutter}
+	dst := image.NewRGBA(image.Rect(0, 0, pitch.X*options.Cols+options.Gutter, pitch.Y*options.Rows+options.Gutter))
+	draw.Draw(dst, dst.Bounds(), image.NewUniform(options.Background), image.Point{}, draw.Src)
+
+	for i, img := range images {
+		if i >= options.Cols*options.Rows {
+			break
+		}
+		col, row := i%options.Cols, i/options.Cols
+		cell := image.Rectangle{Min: image.Point{X: options.Gutter + col*pitch.X, Y: options.Gutter + row*pitch.Y}}
+		cell.Max = cell.Min.Add(cellSize)
+
+		// place the fitted image at the center of the cell
+		fitted := fitImage(img, cellSize, options.Fit)
+		offset := cellSize.Sub(fitted.Bounds().Size()).Div(2)
+		draw.Draw(dst, fitted.Bounds().Sub(fitted.Bounds().Min).Add(cell.Min.Add(offset)), fitted, fitted.Bounds().Min, draw.Over)
+
+		if i < len(options.Captions) && options.Captions[i] != "" {
+			caption := *options.Caption
+			caption.Point = &image.Point{X: cell.Min.X + cellSize.X/2, Y: cell.Max.Y + captionHeight/2}
+			drawText(dst, options.Captions[i], &caption)
+		}
+	}
+	return &converter{dst}
+}
+
+// fitImage resize the image into the size with FitMode
+func fitImage(img image.Image, size image.Point, fit FitMode) image.Image {
+	srcSize := img.Bounds().Size()
+	if srcSize == size || srcSize.X == 0 || srcSize.Y == 0 {
+		return img
+	}
+	c := &converter{img}
+	xRatio, yRatio := float64(size.X)/float64(srcSize.X), float64(size.Y)/float64(srcSize.Y)
+	switch fit {
+	case FitStretch:
+		c.Resize(size.X, size.Y)
+	case FitCover:
+		ratio := math.Max(xRatio, yRatio)
+		width, height := int(math.Ceil(float64(srcSize.X)*ratio)), int(math.Ceil(float64(srcSize.Y)*ratio))
+		c.Resize(width, height)
+		c.Trim((width-size.X)/2, (height-size.Y)/2, size.X, size.Y)
+	default:
+		ratio := math.Min(xRatio, yRatio)
+		c.Resize(int(math.Round(float64(srcSize.X)*ratio)), int(math.Round(float64(srcSize.Y)*ratio)))
+	}
+	return c.Convert()
+}
@@ -0,0 +1,78 @@
+package imgedit
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/magiconair/properties/assert"
+)
+
+func TestNewMontage(t *testing.T) {
+	type args struct {
+		images  []image.Image
+		options *MontageOptions
+	}
+	tests := []struct {
+		name       string
+		args       args
+		wantWidth  int
+		wantHeight int
+	}{
+		{
+			name:       "default",
+			args:       args{images: []image.Image{image.NewRGBA(image.Rect(0, 0, 100, 50)), image.NewRGBA(image.Rect(0, 0, 50, 100)), image.NewRGBA(image.Rect(0, 0, 10, 10))}},
+			wantWidth:  200,
+			wantHeight: 200,
+		},
+		{
+			name: "cols with gutter",
+			args: args{
+				images:  []image.Image{GetPngImage(), GetJpegImage(), GetGifImage()},
+				options: &MontageOptions{Cols: 3, CellWidth: 300, CellHeight: 200, Gutter: 10, Background: color.White},
+			},
+			wantWidth:  940,
+			wantHeight: 220,
+		},
+		{
+			name: "rows with cover",
+			args: args{
+				images:  []image.Image{GetPngImage(), GetAlphaPngImage(), GetGifImage()},
+				options: &MontageOptions{Rows: 3, CellWidth: 300, CellHeight: 100, Fit: FitCover},
+			},
+			wantWidth:  300,
+			wantHeight: 300,
+		},
+		{
+			name: "stretch with captions",
+			args: args{
+				images:  []image.Image{GetPngImage(), GetAlphaPngImage()},
+				options: &MontageOptions{Cols: 2, CellWidth: 300, CellHeight: 300, Fit: FitStretch, Captions: []string{"rabbit", "logo"}},
+			},
+			wantWidth:  600,
+			wantHeight: 300 + captionLineHeight(),
+		},
+		{
+			name:       "empty",
+			args:       args{},
+			wantWidth:  0,
+			wantHeight: 0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			img := NewMontage(tt.args.images, tt.args.options).Convert()
+			assert.Equal(t, img.Bounds().Dx(), tt.wantWidth)
+			assert.Equal(t, img.Bounds().Dy(), tt.wantHeight)
+			if !img.Bounds().Empty() {
+				SaveTestImageAsPng(img)
+			}
+		})
+	}
+}
+
+func captionLineHeight() int {
+	options := &StringOptions{Font: &Font{Size: DefaultCaptionFontSize}}
+	options.setDefault()
+	return options.face().Metrics().Height.Ceil()
+}
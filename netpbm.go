@@ -0,0 +1,379 @@
+package imgedit
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// pnmMaxSamples is the limit of width * height * depth to decode
+const pnmMaxSamples = 1 << 30
+
+// pnmHeader is the header of the Netpbm formats, the depth and the max value of pbm are 1
+type pnmHeader struct {
+	magic         string
+	width, height int
+	depth         int
+	maxValue      int
+}
+
+// plain return true if the samples are written in ascii
+func (h *pnmHeader) plain() bool {
+	return h.magic == "P1" || h.magic == "P2" || h.magic == "P3"
+}
+
+// bitmap return true if 1 is black
+func (h *pnmHeader) bitmap() bool {
+	return h.magic == "P1" || h.magic == "P4"
+}
+
+// colorModel return the model of the decoded image, the samples over 8 bits are decoded to 16 bits
+func (h *pnmHeader) colorModel() color.Model {
+	deep := h.maxValue > 0xff
+	switch {
+	case h.depth == 1 && deep:
+		return color.Gray16Model
+	case h.depth == 1:
+		return color.GrayModel
+	case deep:
+		return color.NRGBA64Model
+	default:
+		return color.NRGBAModel
+	}
+}
+
+func readPnmHeader(r *bufio.Reader) (*pnmHeader, error) {
+	magic := make([]byte, 2)
+	if _, err := io.ReadFull(r, magic); err != nil {
+		return nil, err
+	}
+	h := &pnmHeader{magic: string(magic), depth: 1, maxValue: 1}
+	var err error
+	switch h.magic {
+	case "P1", "P4":
+		err = readPnmValues(r, &h.width, &h.height)
+	case "P2", "P5":
+		err = readPnmValues(r, &h.width, &h.height, &h.maxValue)
+	case "P3", "P6":
+		h.depth = 3
+		err = readPnmValues(r, &h.width, &h.height, &h.maxValue)
+	case "P7":
+		err = readPamHeader(r, h)
+	default:
+		return nil, errors.New("netpbm: invalid format")
+	}
+	if err != nil {
+		return nil, err
+	}
+	if h.width <= 0 || h.height <= 0 || h.depth < 1 || h.depth > 4 || h.maxValue < 1 || h.maxValue > 0xffff {
+		return nil, errors.New("netpbm: invalid header")
+	}
+	// the product of the sizes can overflow
+	if h.width > pnmMaxSamples/h.depth/h.height {
+		return nil, errors.New("netpbm: image is too large to decode")
+	}
+	return h, nil
+}
+
+// readPnmValues read the numbers in the header, the last number is followed by a single whitespace
+func readPnmValues(r *bufio.Reader, values ...*int) error {
+	for _, v := range values {
+		token, err := readPnmToken(r)
+		if err != nil {
+			return err
+		}
+		if *v, err = strconv.Atoi(token); err != nil {
+			return fmt.Errorf("netpbm: invalid header: %w", err)
+		}
+	}
+	return nil
+}
+
+// readPnmToken read the token separated by whitespaces, and skip the comments
+func readPnmToken(r *bufio.Reader) (string, error) {
+	var token []byte
+	for {
+		b, err := r.ReadByte()
+		if err != nil {
+			if err == io.EOF && len(token) > 0 {
+				return string(token), nil
+			}
+			return "", err
+		}
+		switch {
+		case b == '#' && len(token) == 0:
+			if _, err = r.ReadString('\n'); err != nil {
+				return "", err
+			}
+		case isPnmSpace(b):
+			if len(token) > 0 {
+				return string(token), nil
+			}
+		default:
+			token = append(token, b)
+		}
+	}
+}
+
+func isPnmSpace(b byte) bool {
+	return b == ' ' || b == '\t' || b == '\n' || b == '\r' || b == '\v' || b == '\f'
+}
+
+// readPamHeader read the lines of the header until ENDHDR
+func readPamHeader(r *bufio.Reader, h *pnmHeader) error {
+	h.depth, h.maxValue = 0, 0
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return err
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "#") {
+			continue
+		}
+		var value *int
+		switch fields[0] {
+		case "ENDHDR":
+			return nil
+		case "TUPLTYPE":
+			// the channels are decided by the depth
+			continue
+		case "WIDTH":
+			value = &h.width
+		case "HEIGHT":
+			value = &h.height
+		case "DEPTH":
+			value = &h.depth
+		case "MAXVAL":
+			value = &h.maxValue
+		default:
+			return errors.New("netpbm: invalid pam header: " + fields[0])
+		}
+		if len(fields) != 2 {
+			return errors.New("netpbm: invalid pam header: " + fields[0])
+		}
+		if *value, err = strconv.Atoi(fields[1]); err != nil {
+			return fmt.Errorf("netpbm: invalid pam header: %w", err)
+		}
+	}
+}
+
+// readPnmSamples read all samples in the order of the pixels and the channels
+func readPnmSamples(r *bufio.Reader, h *pnmHeader) ([]uint16, error) {
+	samples := make([]uint16, h.width*h.height*h.depth)
+	switch {
+	case h.magic == "P1":
+		// the digits of pbm may not be separated
+		for i := range samples {
+			b, err := r.ReadByte()
+			for err == nil && (isPnmSpace(b) || b == '#') {
+				if b == '#' {
+					_, err = r.ReadString('\n')
+				}
+				if err == nil {
+					b, err = r.ReadByte()
+				}
+			}
+			if err != nil {
+				return nil, err
+			}
+			if b != '0' && b != '1' {
+				return nil, errors.New("netpbm: invalid sample")
+			}
+			samples[i] = uint16(b - '0')
+		}
+	case h.plain():
+		for i := range samples {
+			token, err := readPnmToken(r)
+			if err != nil {
+				return nil, err
+			}
+			v, err := strconv.Atoi(token)
+			if err != nil || v < 0 || v > h.maxValue {
+				return nil, errors.New("netpbm: invalid sample")
+			}
+			samples[i] = uint16(v)
+		}
+	case h.magic == "P4":
+		// the rows are padded to bytes
+		row := make([]byte, (h.width+7)/8)
+		for y := 0; y < h.height; y++ {
+			if _, err := io.ReadFull(r, row); err != nil {
+				return nil, err
+			}
+			for x := 0; x < h.width; x++ {
+				samples[y*h.width+x] = uint16(row[x/8]>>(7-x%8)) & 1
+			}
+		}
+	default:
+		bytesPerSample := 1
+		if h.maxValue > 0xff {
+			bytesPerSample = 2
+		}
+		data := make([]byte, len(samples)*bytesPerSample)
+		if _, err := io.ReadFull(r, data); err != nil {
+			return nil, err
+		}
+		for i := range samples {
+			if bytesPerSample == 2 {
+				samples[i] = uint16(data[i*2])<<8 | uint16(data[i*2+1])
+			} else {
+				samples[i] = uint16(data[i])
+			}
+			if int(samples[i]) > h.maxValue {
+				return nil, errors.New("netpbm: invalid sample")
+			}
+		}
+	}
+	return samples, nil
+}
+
+func decodePnm(r io.Reader) (image.Image, error) {
+	br := bufio.NewReader(r)
+	h, err := readPnmHeader(br)
+	if err != nil {
+		return nil, err
+	}
+	samples, err := readPnmSamples(br, h)
+	if err != nil {
+		return nil, err
+	}
+
+	rect := image.Rect(0, 0, h.width, h.height)
+	// scale return the sample in 16 bits
+	scale := func(v uint16) uint16 {
+		return uint16((uint32(v)*0xffff + uint32(h.maxValue)/2) / uint32(h.maxValue))
+	}
+	pixels := make([]color.NRGBA64, h.width*h.height)
+	for i := range pixels {
+		s := samples[i*h.depth : (i+1)*h.depth]
+		switch h.depth {
+		case 1, 2:
+			v := scale(s[0])
+			if h.bitmap() {
+				v = 0xffff - v
+			}
+			pixels[i] = color.NRGBA64{R: v, G: v, B: v, A: 0xffff}
+		default:
+			pixels[i] = color.NRGBA64{R: scale(s[0]), G: scale(s[1]), B: scale(s[2]), A: 0xffff}
+		}
+		if h.depth == 2 || h.depth == 4 {
+			pixels[i].A = scale(s[h.depth-1])
+		}
+	}
+
+	// the pixels are set without the conversion to keep the colors of the transparent pixels
+	switch h.colorModel() {
+	case color.Gray16Model:
+		dst := image.NewGray16(rect)
+		for i, c := range pixels {
+			dst.SetGray16(i%h.width, i/h.width, color.Gray16{Y: c.R})
+		}
+		return dst, nil
+	case color.GrayModel:
+		dst := image.NewGray(rect)
+		for i, c := range pixels {
+			dst.Pix[i] = uint8(c.R >> 8)
+		}
+		return dst, nil
+	case color.NRGBA64Model:
+		dst := image.NewNRGBA64(rect)
+		for i, c := range pixels {
+			dst.SetNRGBA64(i%h.width, i/h.width, c)
+		}
+		return dst, nil
+	default:
+		dst := image.NewNRGBA(rect)
+		for i, c := range pixels {
+			dst.SetNRGBA(i%h.width, i/h.width, color.NRGBA{R: uint8(c.R >> 8), G: uint8(c.G >> 8), B: uint8(c.B >> 8), A: uint8(c.A >> 8)})
+		}
+		return dst, nil
+	}
+}
+
+func decodePnmConfig(r io.Reader) (image.Config, error) {
+	h, err := readPnmHeader(bufio.NewReader(r))
+	if err != nil {
+		return image.Config{}, err
+	}
+	return image.Config{ColorModel: h.colorModel(), Width: h.width, Height: h.height}, nil
+}
+
+// pnmEncode write the image in the binary format of the extension, the samples are 16 bits if the image is 16 bits.
+// pbm is black under the half of the brightness, and ppm and pgm drop the alpha same as jpeg.
+func pnmEncode(w io.Writer, m image.Image, extension Extension) error {
+	b := m.Bounds()
+	deep := false
+	switch m.ColorModel() {
+	case color.Gray16Model, color.RGBA64Model, color.NRGBA64Model:
+		deep = true
+	}
+	maxValue := 0xff
+	if deep {
+		maxValue = 0xffff
+	}
+
+	bw := bufio.NewWriter(w)
+	var err error
+	switch extension {
+	case Pbm:
+		_, err = fmt.Fprintf(bw, "P4\n%d %d\n", b.Dx(), b.Dy())
+	case Pgm:
+		_, err = fmt.Fprintf(bw, "P5\n%d %d\n%d\n", b.Dx(), b.Dy(), maxValue)
+	case Ppm:
+		_, err = fmt.Fprintf(bw, "P6\n%d %d\n%d\n", b.Dx(), b.Dy(), maxValue)
+	case Pam:
+		_, err = fmt.Fprintf(bw, "P7\nWIDTH %d\nHEIGHT %d\nDEPTH 4\nMAXVAL %d\nTUPLTYPE RGB_ALPHA\nENDHDR\n", b.Dx(), b.Dy(), maxValue)
+	default:
+		return errors.New("netpbm: extension is unsupported")
+	}
+	if err != nil {
+		return err
+	}
+
+	writeSample := func(v uint32) {
+		if deep {
+			_ = bw.WriteByte(byte(v >> 8))
+			_ = bw.WriteByte(byte(v))
+		} else {
+			_ = bw.WriteByte(byte(v >> 8))
+		}
+	}
+	row := make([]byte, (b.Dx()+7)/8)
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for i := range row {
+			row[i] = 0
+		}
+		for x := b.Min.X; x < b.Max.X; x++ {
+			c := m.At(x, y)
+			switch extension {
+			case Pbm:
+				if color.Gray16Model.Convert(c).(color.Gray16).Y < 0x8000 {
+					row[(x-b.Min.X)/8] |= 0x80 >> ((x - b.Min.X) % 8)
+				}
+			case Pgm:
+				writeSample(uint32(color.Gray16Model.Convert(c).(color.Gray16).Y))
+			case Ppm:
+				red, green, blue, _ := c.RGBA()
+				writeSample(red)
+				writeSample(green)
+				writeSample(blue)
+			case Pam:
+				n := color.NRGBA64Model.Convert(c).(color.NRGBA64)
+				writeSample(uint32(n.R))
+				writeSample(uint32(n.G))
+				writeSample(uint32(n.B))
+				writeSample(uint32(n.A))
+			}
+		}
+		if extension == Pbm {
+			_, _ = bw.Write(row)
+		}
+	}
+	return bw.Flush()
+}
@@ -0,0 +1,178 @@
+package imgedit
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/magiconair/properties/assert"
+)
+
+func Test_decodePnm(t *testing.T) {
+	type args struct {
+		data string
+	}
+	tests := []struct {
+		name       string
+		args       args
+		wantFormat string
+		wantModel  color.Model
+		want       []color.Color
+	}{
+		{
+			name:       "plain pbm",
+			args:       args{data: "P1\n# comment\n3 1\n1 01"},
+			wantFormat: "pbm",
+			wantModel:  color.GrayModel,
+			want:       []color.Color{color.Gray{}, color.Gray{Y: 255}, color.Gray{}},
+		},
+		{
+			name:       "pbm",
+			args:       args{data: "P4 3 1\n\xa0"},
+			wantFormat: "pbm",
+			wantModel:  color.GrayModel,
+			want:       []color.Color{color.Gray{}, color.Gray{Y: 255}, color.Gray{}},
+		},
+		{
+			name:       "plain pgm",
+			args:       args{data: "P2 3 1 15\n0 15 5\n"},
+			wantFormat: "pgm",
+			wantModel:  color.GrayModel,
+			want:       []color.Color{color.Gray{}, color.Gray{Y: 255}, color.Gray{Y: 85}},
+		},
+		{
+			name:       "pgm of 16 bits",
+			args:       args{data: "P5 2 1 65535\n\x12\x34\xff\xff"},
+			wantFormat: "pgm",
+			wantModel:  color.Gray16Model,
+			want:       []color.Color{color.Gray16{Y: 0x1234}, color.Gray16{Y: 0xffff}},
+		},
+		{
+			name:       "plain ppm",
+			args:       args{data: "P3\n2 1\n255\n255 0 0  0 0 255\n"},
+			wantFormat: "ppm",
+			wantModel:  color.NRGBAModel,
+			want:       []color.Color{color.NRGBA{R: 255, A: 255}, color.NRGBA{B: 255, A: 255}},
+		},
+		{
+			name:       "ppm",
+			args:       args{data: "P6 1 1 255\n\x01\x02\x03"},
+			wantFormat: "ppm",
+			wantModel:  color.NRGBAModel,
+			want:       []color.Color{color.NRGBA{R: 1, G: 2, B: 3, A: 255}},
+		},
+		{
+			name:       "pam",
+			args:       args{data: "P7\nWIDTH 2\nHEIGHT 1\nDEPTH 2\nMAXVAL 255\nTUPLTYPE GRAYSCALE_ALPHA\nENDHDR\n\x10\x80\xff\x00"},
+			wantFormat: "pam",
+			wantModel:  color.NRGBAModel,
+			want:       []color.Color{color.NRGBA{R: 16, G: 16, B: 16, A: 128}, color.NRGBA{R: 255, G: 255, B: 255}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, format, err := image.Decode(bytes.NewReader([]byte(tt.args.data)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			assert.Equal(t, format, tt.wantFormat)
+			assert.Equal(t, got.ColorModel(), tt.wantModel)
+			assert.Equal(t, got.Bounds(), image.Rect(0, 0, len(tt.want), 1))
+			for x, want := range tt.want {
+				assert.Equal(t, got.At(x, 0), want)
+			}
+			config, _, err := image.DecodeConfig(bytes.NewReader([]byte(tt.args.data)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			assert.Equal(t, config.Width, len(tt.want))
+		})
+	}
+}
+
+func Test_decodePnm_error(t *testing.T) {
+	for _, data := range []string{
+		"P5 2 1 255\n\x00",
+		"P2 1 1 15\n16\n",
+		"P6 0 1 255\n",
+		"P7\nWIDTH 1\nHEIGHT 1\nDEPTH 5\nMAXVAL 255\nENDHDR\n",
+		// the sizes over the limit
+		"P1 4294967296 4294967296\n",
+		"P5 4294967296 4294967296 255\n",
+		"P6 4294967296 4294967296 255\n",
+		"P5 65536 65536 255\n",
+	} {
+		_, _, err := image.Decode(bytes.NewReader([]byte(data)))
+		assert.Equal(t, err != nil, true)
+	}
+}
+
+func Test_pnmEncode(t *testing.T) {
+	src := image.NewNRGBA64(image.Rect(0, 0, 10, 3))
+	for i := range src.Pix {
+		src.Pix[i] = uint8(i * 13)
+	}
+	type args struct {
+		m         image.Image
+		extension Extension
+	}
+	tests := []struct {
+		name string
+		args args
+		want func(c color.Color) color.Color
+	}{
+		{
+			name: "pbm",
+			args: args{m: src, extension: Pbm},
+			want: func(c color.Color) color.Color {
+				if color.Gray16Model.Convert(c).(color.Gray16).Y < 0x8000 {
+					return color.Gray{}
+				}
+				return color.Gray{Y: 255}
+			},
+		},
+		{
+			name: "pgm",
+			args: args{m: src, extension: Pgm},
+			want: func(c color.Color) color.Color { return color.Gray16Model.Convert(c) },
+		},
+		{
+			name: "ppm",
+			args: args{m: src, extension: Ppm},
+			want: func(c color.Color) color.Color {
+				r, g, b, _ := c.RGBA()
+				return color.NRGBA64{R: uint16(r), G: uint16(g), B: uint16(b), A: 0xffff}
+			},
+		},
+		{
+			name: "pam",
+			args: args{m: src, extension: Pam},
+			want: func(c color.Color) color.Color { return c },
+		},
+		{
+			name: "pam of 8 bits",
+			args: args{m: GetAlphaPngImage(), extension: Pam},
+			want: func(c color.Color) color.Color { return color.NRGBAModel.Convert(c) },
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := &bytes.Buffer{}
+			if err := NewByteConverterFromImage(tt.args.m).WriteAs(w, tt.args.extension); err != nil {
+				t.Fatal(err)
+			}
+			got, extension, err := NewByteConverter(w)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assert.Equal(t, extension, tt.args.extension)
+			b := tt.args.m.Bounds()
+			for y := b.Min.Y; y < b.Max.Y; y += 7 {
+				for x := b.Min.X; x < b.Max.X; x += 3 {
+					assert.Equal(t, got.Convert().At(x-b.Min.X, y-b.Min.Y), tt.want(tt.args.m.At(x, y)))
+				}
+			}
+		})
+	}
+}
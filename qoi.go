@@ -0,0 +1,194 @@
+package imgedit
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/color"
+	"io"
+)
+
+const (
+	qoiMagic      = "qoif"
+	qoiHeaderSize = 14
+	// qoiMaxPixels is the same limit as the reference implementation
+	qoiMaxPixels = 400000000
+
+	qoiOpIndex = 0x00
+	qoiOpDiff  = 0x40
+	qoiOpLuma  = 0x80
+	qoiOpRun   = 0xc0
+	qoiOpRGB   = 0xfe
+	qoiOpRGBA  = 0xff
+	qoiMask    = 0xc0
+	qoiMaxRun  = 62
+)
+
+// qoiEnd is the padding at the end of the stream
+var qoiEnd = []byte{0, 0, 0, 0, 0, 0, 0, 1}
+
+func qoiHash(c color.NRGBA) byte {
+	return (c.R*3 + c.G*5 + c.B*7 + c.A*11) % 64
+}
+
+type qoiHeader struct {
+	width, height int
+	channels      byte
+}
+
+func readQoiHeader(r io.Reader) (*qoiHeader, error) {
+	data := make([]byte, qoiHeaderSize)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return nil, err
+	}
+	if string(data[:4]) != qoiMagic {
+		return nil, errors.New("qoi: invalid format")
+	}
+	h := &qoiHeader{
+		width:    int(binary.BigEndian.Uint32(data[4:])),
+		height:   int(binary.BigEndian.Uint32(data[8:])),
+		channels: data[12],
+	}
+	if h.width == 0 || h.height == 0 || (h.channels != 3 && h.channels != 4) || data[13] > 1 {
+		return nil, errors.New("qoi: invalid header")
+	}
+	if h.height >= qoiMaxPixels/h.width {
+		return nil, errors.New("qoi: image is too large to decode")
+	}
+	return h, nil
+}
+
+func decodeQoi(r io.Reader) (image.Image, error) {
+	br := bufio.NewReader(r)
+	h, err := readQoiHeader(br)
+	if err != nil {
+		return nil, err
+	}
+	dst := image.NewNRGBA(image.Rect(0, 0, h.width, h.height))
+	var index [64]color.NRGBA
+	px := color.NRGBA{A: 0xff}
+	run := 0
+	// the stream ends unexpectedly if the bytes are not enough
+	read := func() byte {
+		b, e := br.ReadByte()
+		if e != nil && err == nil {
+			err = io.ErrUnexpectedEOF
+		}
+		return b
+	}
+	for i := 0; i < len(dst.Pix); i += 4 {
+		if run > 0 {
+			run--
+		} else {
+			b1 := read()
+			switch {
+			case b1 == qoiOpRGB:
+				px.R, px.G, px.B = read(), read(), read()
+			case b1 == qoiOpRGBA:
+				px.R, px.G, px.B, px.A = read(), read(), read(), read()
+			case b1&qoiMask == qoiOpIndex:
+				px = index[b1]
+			case b1&qoiMask == qoiOpDiff:
+				px.R += (b1>>4)&0x03 - 2
+				px.G += (b1>>2)&0x03 - 2
+				px.B += b1&0x03 - 2
+			case b1&qoiMask == qoiOpLuma:
+				b2 := read()
+				vg := b1&0x3f - 32
+				px.R += vg - 8 + (b2>>4)&0x0f
+				px.G += vg
+				px.B += vg - 8 + b2&0x0f
+			default:
+				run = int(b1 & 0x3f)
+			}
+			if err != nil {
+				return nil, err
+			}
+			index[qoiHash(px)] = px
+		}
+		dst.Pix[i], dst.Pix[i+1], dst.Pix[i+2], dst.Pix[i+3] = px.R, px.G, px.B, px.A
+	}
+	return dst, nil
+}
+
+func decodeQoiConfig(r io.Reader) (image.Config, error) {
+	h, err := readQoiHeader(r)
+	if err != nil {
+		return image.Config{}, err
+	}
+	return image.Config{ColorModel: color.NRGBAModel, Width: h.width, Height: h.height}, nil
+}
+
+// qoiEncode write the image in qoi, the alpha channel is written only if the image has the transparent pixels
+func qoiEncode(w io.Writer, m image.Image) error {
+	b := m.Bounds()
+	if b.Dx() == 0 || b.Dy() == 0 || b.Dy() >= qoiMaxPixels/b.Dx() {
+		return errors.New("qoi: image size is out of range")
+	}
+	pixels := make([]color.NRGBA, 0, b.Dx()*b.Dy())
+	channels := byte(3)
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			c := color.NRGBAModel.Convert(m.At(x, y)).(color.NRGBA)
+			if c.A != 0xff {
+				channels = 4
+			}
+			pixels = append(pixels, c)
+		}
+	}
+
+	bw := bufio.NewWriter(w)
+	header := make([]byte, qoiHeaderSize)
+	copy(header, qoiMagic)
+	binary.BigEndian.PutUint32(header[4:], uint32(b.Dx()))
+	binary.BigEndian.PutUint32(header[8:], uint32(b.Dy()))
+	header[12] = channels
+	_, _ = bw.Write(header)
+
+	var index [64]color.NRGBA
+	prev := color.NRGBA{A: 0xff}
+	run := 0
+	for i, px := range pixels {
+		if px == prev {
+			run++
+			if run == qoiMaxRun || i == len(pixels)-1 {
+				_ = bw.WriteByte(qoiOpRun | byte(run-1))
+				run = 0
+			}
+			continue
+		}
+		if run > 0 {
+			_ = bw.WriteByte(qoiOpRun | byte(run-1))
+			run = 0
+		}
+		hash := qoiHash(px)
+		switch {
+		case index[hash] == px:
+			_ = bw.WriteByte(qoiOpIndex | hash)
+		case px.A != prev.A:
+			index[hash] = px
+			_, _ = bw.Write([]byte{qoiOpRGBA, px.R, px.G, px.B, px.A})
+		default:
+			index[hash] = px
+			qoiWriteDiff(bw, prev, px)
+		}
+		prev = px
+	}
+	_, _ = bw.Write(qoiEnd)
+	return bw.Flush()
+}
+
+// qoiWriteDiff write the pixel of the same alpha as the difference from the previous pixel if it is small
+func qoiWriteDiff(bw *bufio.Writer, prev, px color.NRGBA) {
+	vr, vg, vb := int8(px.R-prev.R), int8(px.G-prev.G), int8(px.B-prev.B)
+	vgr, vgb := vr-vg, vb-vg
+	switch {
+	case vr > -3 && vr < 2 && vg > -3 && vg < 2 && vb > -3 && vb < 2:
+		_ = bw.WriteByte(qoiOpDiff | byte(vr+2)<<4 | byte(vg+2)<<2 | byte(vb+2))
+	case vgr > -9 && vgr < 8 && vg > -33 && vg < 32 && vgb > -9 && vgb < 8:
+		_, _ = bw.Write([]byte{qoiOpLuma | byte(vg+32), byte(vgr+8)<<4 | byte(vgb+8)})
+	default:
+		_, _ = bw.Write([]byte{qoiOpRGB, px.R, px.G, px.B})
+	}
+}
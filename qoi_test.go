@@ -0,0 +1,106 @@
+package imgedit
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"math/rand"
+	"testing"
+
+	"github.com/magiconair/properties/assert"
+)
+
+func Test_qoiEncode(t *testing.T) {
+	noise := image.NewNRGBA(image.Rect(0, 0, 37, 23))
+	rand.New(rand.NewSource(1)).Read(noise.Pix)
+	// the small differences and the runs
+	gradient := image.NewNRGBA(image.Rect(0, 0, 300, 20))
+	for x := 0; x < 300; x++ {
+		for y := 0; y < 20; y++ {
+			gradient.SetNRGBA(x, y, color.NRGBA{R: uint8(x), G: uint8(x / 2), B: uint8(x / 30), A: 255})
+		}
+	}
+	type args struct {
+		m image.Image
+	}
+	tests := []struct {
+		name         string
+		args         args
+		wantChannels byte
+	}{
+		{
+			name:         "png",
+			args:         args{m: GetPngImage()},
+			wantChannels: 3,
+		},
+		{
+			name:         "alpha png",
+			args:         args{m: GetAlphaPngImage()},
+			wantChannels: 4,
+		},
+		{
+			name:         "noise",
+			args:         args{m: noise},
+			wantChannels: 4,
+		},
+		{
+			name:         "gradient",
+			args:         args{m: gradient},
+			wantChannels: 3,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := &bytes.Buffer{}
+			if err := qoiEncode(w, tt.args.m); err != nil {
+				t.Fatal(err)
+			}
+			assert.Equal(t, w.Bytes()[12], tt.wantChannels)
+			assert.Equal(t, bytes.HasSuffix(w.Bytes(), qoiEnd), true)
+
+			got, format, err := image.Decode(bytes.NewReader(w.Bytes()))
+			if err != nil {
+				t.Fatal(err)
+			}
+			assert.Equal(t, format, string(Qoi))
+			b := tt.args.m.Bounds()
+			assert.Equal(t, got.Bounds().Size(), b.Size())
+			for y := b.Min.Y; y < b.Max.Y; y++ {
+				for x := b.Min.X; x < b.Max.X; x++ {
+					if want := color.NRGBAModel.Convert(tt.args.m.At(x, y)); got.At(x-b.Min.X, y-b.Min.Y) != want {
+						t.Fatalf("qoiEncode() color at (%d, %d) = %v, want %v", x, y, got.At(x-b.Min.X, y-b.Min.Y), want)
+					}
+				}
+			}
+		})
+	}
+}
+
+func Test_decodeQoi(t *testing.T) {
+	// RGB red, DIFF +1 green, LUMA, RUN 2, INDEX of red
+	data := []byte("qoif\x00\x00\x00\x06\x00\x00\x00\x01\x03\x00")
+	data = append(data, qoiOpRGB, 200, 0, 0)
+	data = append(data, qoiOpDiff|2<<4|3<<2|2)
+	data = append(data, qoiOpLuma|(10+32), (0+8)<<4|(2+8))
+	data = append(data, qoiOpRun|1)
+	data = append(data, qoiOpIndex|qoiHash(color.NRGBA{R: 200, A: 255}))
+	data = append(data, qoiEnd...)
+	got, err := decodeQoi(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []color.NRGBA{
+		{R: 200, A: 255},
+		{R: 200, G: 1, A: 255},
+		{R: 210, G: 11, B: 12, A: 255},
+		{R: 210, G: 11, B: 12, A: 255},
+		{R: 210, G: 11, B: 12, A: 255},
+		{R: 200, A: 255},
+	}
+	for x, c := range want {
+		assert.Equal(t, got.At(x, 0), c)
+	}
+
+	_, err = decodeQoi(bytes.NewReader(data[:20]))
+	assert.Equal(t, err != nil, true)
+}
@@ -0,0 +1,611 @@
+package imgedit
+
+import (
+	"errors"
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+	"strings"
+)
+
+// QRLevel the error correction level of the QR code
+type QRLevel int
+
+const (
+	// QRLevelM restore about 15% of the code
+	QRLevelM QRLevel = iota
+	// QRLevelL restore about 7% of the code
+	QRLevelL
+	// QRLevelQ restore about 25% of the code
+	QRLevelQ
+	// QRLevelH restore about 30% of the code
+	QRLevelH
+)
+
+const (
+	// DefaultModuleSize px of the module used when the size is not specified in QROptions and BarcodeOptions
+	DefaultModuleSize = 4
+	// DefaultQRQuietZone modules of the margin around the QR code
+	DefaultQRQuietZone = 4
+)
+
+// qrAlphanumeric the letters of the alphanumeric mode in the order of the values
+const qrAlphanumeric = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZ $%*+-./:"
+
+// qrEccCodewords the error correction codewords per block indexed by the level and the version
+var qrEccCodewords = [4][41]int{
+	{-1, 10, 16, 26, 18, 24, 16, 18, 22, 22, 26, 30, 22, 22, 24, 24, 28, 28, 26, 26, 26, 26, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28, 28},
+	{-1, 7, 10, 15, 20, 26, 18, 20, 24, 30, 18, 20, 24, 26, 30, 22, 24, 28, 30, 28, 28, 28, 28, 30, 30, 26, 28, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30},
+	{-1, 13, 22, 18, 26, 18, 24, 18, 22, 20, 24, 28, 26, 24, 20, 30, 24, 28, 28, 26, 30, 28, 30, 30, 30, 30, 28, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30},
+	{-1, 17, 28, 22, 16, 22, 28, 26, 26, 24, 28, 24, 28, 22, 24, 24, 30, 28, 28, 26, 28, 30, 24, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30, 30},
+}
+
+// qrEccBlocks the number of the error correction blocks indexed by the level and the version
+var qrEccBlocks = [4][41]int{
+	{-1, 1, 1, 1, 2, 2, 4, 4, 4, 5, 5, 5, 8, 9, 9, 10, 10, 11, 13, 14, 16, 17, 17, 18, 20, 21, 23, 25, 26, 28, 29, 31, 33, 35, 37, 38, 40, 43, 45, 47, 49},
+	{-1, 1, 1, 1, 1, 1, 2, 2, 2, 2, 4, 4, 4, 4, 4, 6, 6, 6, 6, 7, 8, 8, 9, 9, 10, 12, 12, 12, 13, 14, 15, 16, 17, 18, 19, 19, 20, 21, 22, 24, 25},
+	{-1, 1, 1, 2, 2, 4, 4, 6, 6, 8, 8, 8, 10, 12, 16, 12, 17, 16, 18, 21, 20, 23, 23, 25, 27, 29, 34, 34, 35, 38, 40, 43, 45, 48, 51, 53, 56, 59, 62, 65, 68},
+	{-1, 1, 1, 2, 4, 4, 4, 5, 6, 8, 8, 11, 11, 16, 16, 18, 16, 19, 21, 25, 25, 25, 34, 30, 32, 35, 37, 40, 42, 45, 48, 51, 54, 57, 60, 63, 66, 70, 74, 77, 81},
+}
+
+// formatBits return the level bits in the format information
+func (l QRLevel) formatBits() int {
+	switch l {
+	case QRLevelL:
+		return 1
+	case QRLevelQ:
+		return 3
+	case QRLevelH:
+		return 2
+	default:
+		return 0
+	}
+}
+
+// QROptions options for NewQRCode
+type QROptions struct {
+	// Level default QRLevelM
+	Level QRLevel
+	// Size px of the image including the quiet zone, default 4px per module.
+	// the modules are as large as they fit, and the remainder is added to the quiet zone.
+	Size int
+	// QuietZone modules of the margin around the code, default 4. negative is no margin
+	QuietZone int
+	// Color of the dark modules, default color.Black
+	Color color.Color
+	// Background color of the light modules and the quiet zone, default color.White
+	Background color.Color
+}
+
+func (o *QROptions) setDefault() {
+	if o.QuietZone == 0 {
+		o.QuietZone = DefaultQRQuietZone
+	}
+	if o.QuietZone < 0 {
+		o.QuietZone = 0
+	}
+	if o.Color == nil {
+		o.Color = color.Black
+	}
+	if o.Background == nil {
+		o.Background = color.White
+	}
+}
+
+// NewQRCode return the image of the QR code of the text
+func NewQRCode(text string, options *QROptions) (image.Image, error) {
+	if options == nil {
+		options = &QROptions{}
+	}
+	options.setDefault()
+	modules, err := encodeQR(text, options.Level)
+	if err != nil {
+		return nil, err
+	}
+	return drawModules(modules, options.Size, options.QuietZone, options.Color, options.Background), nil
+}
+
+// drawModules return the image of the square modules with the margin of quietZone modules
+func drawModules(modules [][]bool, size, quietZone int, dark, light color.Color) image.Image {
+	n := len(modules) + quietZone*2
+	moduleSize := DefaultModuleSize
+	if size > 0 {
+		moduleSize = int(math.Max(1, float64(size/n)))
+	} else {
+		size = n * moduleSize
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, size, size))
+	draw.Draw(dst, dst.Bounds(), image.NewUniform(light), image.Point{}, draw.Src)
+	// the code is centered in the image
+	offset := (size - len(modules)*moduleSize) / 2
+	for y, row := range modules {
+		for x, isDark := range row {
+			if isDark {
+				rect := image.Rect(x*moduleSize, y*moduleSize, (x+1)*moduleSize, (y+1)*moduleSize).Add(image.Point{X: offset, Y: offset})
+				draw.Draw(dst, rect, image.NewUniform(dark), image.Point{}, draw.Src)
+			}
+		}
+	}
+	return dst
+}
+
+// qrCode is the matrix of the QR code while encoding
+type qrCode struct {
+	version int
+	size    int
+	modules [][]bool
+	// function is true for the modules of the patterns not to be masked
+	function [][]bool
+}
+
+// encodeQR return the modules of the QR code of the text, true is dark
+func encodeQR(text string, level QRLevel) ([][]bool, error) {
+	version, data, err := qrData(text, level)
+	if err != nil {
+		return nil, err
+	}
+	q := newQRCode(version)
+	q.drawCodewords(qrCodewords(data, version, level))
+	// choose the mask with the lowest penalty
+	best, bestPenalty := 0, math.MaxInt
+	for mask := 0; mask < 8; mask++ {
+		q.applyMask(mask)
+		q.drawFormat(level, mask)
+		if penalty := q.penalty(); penalty < bestPenalty {
+			best, bestPenalty = mask, penalty
+		}
+		q.applyMask(mask)
+	}
+	q.applyMask(best)
+	q.drawFormat(level, best)
+	return q.modules, nil
+}
+
+// qrData return the smallest version for the text and the data codewords with the padding
+func qrData(text string, level QRLevel) (int, []byte, error) {
+	mode, count, segment := qrSegment(text)
+	var version int
+	var bits qrBits
+	for version = 1; ; version++ {
+		if version > 40 {
+			return 0, nil, errors.New("text is too long for QR code")
+		}
+		bits = qrBits{}
+		bits.append(mode, 4)
+		bits.append(count, qrCountBits(mode, version))
+		bits = append(bits, segment...)
+		if len(bits) <= qrDataCodewords(version, level)*8 {
+			break
+		}
+	}
+
+	// add the terminator and the padding
+	capacity := qrDataCodewords(version, level) * 8
+	bits.append(0, int(math.Min(4, float64(capacity-len(bits)))))
+	bits.append(0, (8-len(bits)%8)%8)
+	for pad := 0xEC; len(bits) < capacity; pad ^= 0xEC ^ 0x11 {
+		bits.append(pad, 8)
+	}
+
+	return version, bits.bytes(), nil
+}
+
+// qrSegment return the mode, the number of the letters and the bits of the text in the smallest mode
+func qrSegment(text string) (int, int, qrBits) {
+	var bits qrBits
+	switch {
+	case text != "" && strings.Trim(text, "0123456789") == "":
+		for i := 0; i < len(text); i += 3 {
+			group := text[i:int(math.Min(float64(i+3), float64(len(text))))]
+			v := 0
+			for _, r := range group {
+				v = v*10 + int(r-'0')
+			}
+			bits.append(v, len(group)*3+1)
+		}
+		return 0x1, len(text), bits
+	case text != "" && strings.Trim(text, qrAlphanumeric) == "":
+		for i := 0; i+1 < len(text); i += 2 {
+			bits.append(strings.IndexByte(qrAlphanumeric, text[i])*45+strings.IndexByte(qrAlphanumeric, text[i+1]), 11)
+		}
+		if len(text)%2 == 1 {
+			bits.append(strings.IndexByte(qrAlphanumeric, text[len(text)-1]), 6)
+		}
+		return 0x2, len(text), bits
+	default:
+		for _, b := range []byte(text) {
+			bits.append(int(b), 8)
+		}
+		return 0x4, len(text), bits
+	}
+}
+
+// qrCountBits return the length of the letter count of the mode
+func qrCountBits(mode, version int) int {
+	i := 0
+	if version >= 27 {
+		i = 2
+	} else if version >= 10 {
+		i = 1
+	}
+	switch mode {
+	case 0x1:
+		return []int{10, 12, 14}[i]
+	case 0x2:
+		return []int{9, 11, 13}[i]
+	default:
+		return []int{8, 16, 16}[i]
+	}
+}
+
+// qrRawModules return the number of the modules for the data and the error correction
+func qrRawModules(version int) int {
+	n := (16*version+128)*version + 64
+	if version >= 2 {
+		alignments := version/7 + 2
+		n -= (25*alignments-10)*alignments - 55
+		if version >= 7 {
+			n -= 36
+		}
+	}
+	return n
+}
+
+// qrDataCodewords return the number of the data codewords
+func qrDataCodewords(version int, level QRLevel) int {
+	return qrRawModules(version)/8 - qrEccCodewords[level][version]*qrEccBlocks[level][version]
+}
+
+// qrCodewords split the data into the blocks, add the error correction codewords and interleave them
+func qrCodewords(data []byte, version int, level QRLevel) []byte {
+	numBlocks, eccLength := qrEccBlocks[level][version], qrEccCodewords[level][version]
+	rawCodewords := qrRawModules(version) / 8
+	numShortBlocks := numBlocks - rawCodewords%numBlocks
+	shortDataLength := rawCodewords/numBlocks - eccLength
+
+	divisor := rsDivisor(eccLength)
+	var blocks, eccs [][]byte
+	for i := 0; i < numBlocks; i++ {
+		length := shortDataLength
+		if i >= numShortBlocks {
+			length++
+		}
+		blocks = append(blocks, data[:length])
+		eccs = append(eccs, rsRemainder(data[:length], divisor))
+		data = data[length:]
+	}
+
+	var result []byte
+	for i := 0; i <= shortDataLength; i++ {
+		for _, block := range blocks {
+			if i < len(block) {
+				result = append(result, block[i])
+			}
+		}
+	}
+	for i := 0; i < eccLength; i++ {
+		for _, ecc := range eccs {
+			result = append(result, ecc[i])
+		}
+	}
+	return result
+}
+
+// rsDivisor return the generator polynomial of the degree for the Reed-Solomon code, the leading 1 is omitted
+func rsDivisor(degree int) []byte {
+	result := make([]byte, degree)
+	result[degree-1] = 1
+	root := byte(1)
+	for i := 0; i < degree; i++ {
+		for j := range result {
+			result[j] = gfMultiply(result[j], root)
+			if j+1 < len(result) {
+				result[j] ^= result[j+1]
+			}
+		}
+		root = gfMultiply(root, 0x02)
+	}
+	return result
+}
+
+// rsRemainder return the error correction codewords of the data
+func rsRemainder(data, divisor []byte) []byte {
+	result := make([]byte, len(divisor))
+	for _, b := range data {
+		factor := b ^ result[0]
+		copy(result, result[1:])
+		result[len(result)-1] = 0
+		for i := range result {
+			result[i] ^= gfMultiply(divisor[i], factor)
+		}
+	}
+	return result
+}
+
+// gfMultiply return the product in GF(2^8) modulo x^8 + x^4 + x^3 + x^2 + 1
+func gfMultiply(x, y byte) byte {
+	z := 0
+	for i := 7; i >= 0; i-- {
+		z = (z << 1) ^ ((z >> 7) * 0x11D)
+		z ^= int((y>>i)&1) * int(x)
+	}
+	return byte(z)
+}
+
+// qrBits is the bit sequence of the data
+type qrBits []bool
+
+// append add the lower length bits of v from the most significant bit
+func (b *qrBits) append(v, length int) {
+	for i := length - 1; i >= 0; i-- {
+		*b = append(*b, (v>>i)&1 == 1)
+	}
+}
+
+// bytes return the bits packed into the bytes
+func (b qrBits) bytes() []byte {
+	result := make([]byte, (len(b)+7)/8)
+	for i, bit := range b {
+		if bit {
+			result[i/8] |= 0x80 >> (i % 8)
+		}
+	}
+	return result
+}
+
+// newQRCode create the matrix with the function patterns of the version
+func newQRCode(version int) *qrCode {
+	size := version*4 + 17
+	q := &qrCode{version: version, size: size, modules: make([][]bool, size), function: make([][]bool, size)}
+	for i := range q.modules {
+		q.modules[i] = make([]bool, size)
+		q.function[i] = make([]bool, size)
+	}
+
+	// timing patterns
+	for i := 0; i < size; i++ {
+		q.setFunction(6, i, i%2 == 0)
+		q.setFunction(i, 6, i%2 == 0)
+	}
+	// finder patterns with the separators
+	for _, corner := range [][2]int{{3, 3}, {size - 4, 3}, {3, size - 4}} {
+		for dy := -4; dy <= 4; dy++ {
+			for dx := -4; dx <= 4; dx++ {
+				x, y := corner[0]+dx, corner[1]+dy
+				if x < 0 || x >= size || y < 0 || y >= size {
+					continue
+				}
+				d := int(math.Max(math.Abs(float64(dx)), math.Abs(float64(dy))))
+				q.setFunction(x, y, d != 2 && d != 4)
+			}
+		}
+	}
+	// alignment patterns except at the finder patterns
+	positions := qrAlignmentPositions(version)
+	for i, cy := range positions {
+		for j, cx := range positions {
+			last := len(positions) - 1
+			if (i == 0 && j == 0) || (i == 0 && j == last) || (i == last && j == 0) {
+				continue
+			}
+			for dy := -2; dy <= 2; dy++ {
+				for dx := -2; dx <= 2; dx++ {
+					d := int(math.Max(math.Abs(float64(dx)), math.Abs(float64(dy))))
+					q.setFunction(cx+dx, cy+dy, d != 1)
+				}
+			}
+		}
+	}
+	// reserve the format information, it is drawn after masking
+	q.drawFormat(QRLevelM, 0)
+	q.drawVersion()
+	return q
+}
+
+// qrAlignmentPositions return the centers of the alignment patterns
+func qrAlignmentPositions(version int) []int {
+	if version == 1 {
+		return nil
+	}
+	n := version/7 + 2
+	step := (version*8 + n*3 + 5) / (n*4 - 4) * 2
+	positions := make([]int, n)
+	positions[0] = 6
+	for i, pos := n-1, version*4+17-7; i >= 1; i, pos = i-1, pos-step {
+		positions[i] = pos
+	}
+	return positions
+}
+
+func (q *qrCode) setFunction(x, y int, isDark bool) {
+	q.modules[y][x] = isDark
+	q.function[y][x] = true
+}
+
+// drawFormat draw the level and the mask with the error correction bits at the two places
+func (q *qrCode) drawFormat(level QRLevel, mask int) {
+	bits := qrFormatBits(level, mask)
+	bit := func(i int) bool {
+		return (bits>>i)&1 == 1
+	}
+	for i := 0; i <= 5; i++ {
+		q.setFunction(8, i, bit(i))
+	}
+	q.setFunction(8, 7, bit(6))
+	q.setFunction(8, 8, bit(7))
+	q.setFunction(7, 8, bit(8))
+	for i := 9; i < 15; i++ {
+		q.setFunction(14-i, 8, bit(i))
+	}
+	for i := 0; i < 8; i++ {
+		q.setFunction(q.size-1-i, 8, bit(i))
+	}
+	for i := 8; i < 15; i++ {
+		q.setFunction(8, q.size-15+i, bit(i))
+	}
+	// the dark module
+	q.setFunction(8, q.size-8, true)
+}
+
+// qrFormatBits return the 15 bits of the format information
+func qrFormatBits(level QRLevel, mask int) int {
+	data := level.formatBits()<<3 | mask
+	rem := data
+	for i := 0; i < 10; i++ {
+		rem = (rem << 1) ^ ((rem >> 9) * 0x537)
+	}
+	return (data<<10 | rem) ^ 0x5412
+}
+
+// drawVersion draw the version with the error correction bits for the version 7 or later
+func (q *qrCode) drawVersion() {
+	if q.version < 7 {
+		return
+	}
+	bits := qrVersionBits(q.version)
+	for i := 0; i < 18; i++ {
+		isDark := (bits>>i)&1 == 1
+		a, b := q.size-11+i%3, i/3
+		q.setFunction(a, b, isDark)
+		q.setFunction(b, a, isDark)
+	}
+}
+
+// qrVersionBits return the 18 bits of the version information
+func qrVersionBits(version int) int {
+	rem := version
+	for i := 0; i < 12; i++ {
+		rem = (rem << 1) ^ ((rem >> 11) * 0x1F25)
+	}
+	return version<<12 | rem
+}
+
+// drawCodewords place the bits in the zigzag from the right bottom, skipping the function patterns
+func (q *qrCode) drawCodewords(codewords []byte) {
+	i := 0
+	for right := q.size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			// skip the vertical timing pattern
+			right = 5
+		}
+		for vert := 0; vert < q.size; vert++ {
+			for j := 0; j < 2; j++ {
+				x := right - j
+				y := vert
+				if (right+1)&2 == 0 {
+					// upward
+					y = q.size - 1 - vert
+				}
+				if !q.function[y][x] && i < len(codewords)*8 {
+					q.modules[y][x] = (codewords[i/8]>>(7-i%8))&1 == 1
+					i++
+				}
+			}
+		}
+	}
+}
+
+// applyMask flip the data modules by the mask pattern, apply again to undo
+func (q *qrCode) applyMask(mask int) {
+	for y := 0; y < q.size; y++ {
+		for x := 0; x < q.size; x++ {
+			if !q.function[y][x] && qrMask(mask, x, y) {
+				q.modules[y][x] = !q.modules[y][x]
+			}
+		}
+	}
+}
+
+// qrMask return true, if the module at (x, y) is flipped by the mask
+func qrMask(mask, x, y int) bool {
+	switch mask {
+	case 0:
+		return (x+y)%2 == 0
+	case 1:
+		return y%2 == 0
+	case 2:
+		return x%3 == 0
+	case 3:
+		return (x+y)%3 == 0
+	case 4:
+		return (x/3+y/2)%2 == 0
+	case 5:
+		return x*y%2+x*y%3 == 0
+	case 6:
+		return (x*y%2+x*y%3)%2 == 0
+	default:
+		return ((x+y)%2+x*y%3)%2 == 0
+	}
+}
+
+// penalty return the score of the patterns hard to read, lower is better
+func (q *qrCode) penalty() int {
+	penalty := 0
+	at := func(x, y int, vertical bool) bool {
+		if vertical {
+			return q.modules[x][y]
+		}
+		return q.modules[y][x]
+	}
+	finderLike := []bool{true, false, true, true, true, false, true}
+	for _, vertical := range []bool{false, true} {
+		for y := 0; y < q.size; y++ {
+			run := 1
+			for x := 1; x <= q.size; x++ {
+				// the runs of the same color longer than 5 modules
+				if x < q.size && at(x, y, vertical) == at(x-1, y, vertical) {
+					run++
+					continue
+				}
+				if run >= 5 {
+					penalty += run - 2
+				}
+				run = 1
+			}
+			// the patterns like the finder pattern with the light area of 4 modules
+			for x := 0; x+7 <= q.size; x++ {
+				matched := true
+				for i, isDark := range finderLike {
+					if at(x+i, y, vertical) != isDark {
+						matched = false
+						break
+					}
+				}
+				if !matched {
+					continue
+				}
+				light := func(from, to int) bool {
+					for i := from; i < to; i++ {
+						if i >= 0 && i < q.size && at(i, y, vertical) {
+							return false
+						}
+					}
+					return true
+				}
+				if light(x-4, x) || light(x+7, x+11) {
+					penalty += 40
+				}
+			}
+		}
+	}
+	dark := 0
+	for y := 0; y < q.size; y++ {
+		for x := 0; x < q.size; x++ {
+			if q.modules[y][x] {
+				dark++
+			}
+			// the blocks of the same color
+			if x+1 < q.size && y+1 < q.size {
+				c := q.modules[y][x]
+				if q.modules[y][x+1] == c && q.modules[y+1][x] == c && q.modules[y+1][x+1] == c {
+					penalty += 3
+				}
+			}
+		}
+	}
+	// the balance of the dark and light modules
+	total := q.size * q.size
+	k := (int(math.Abs(float64(dark*20-total*10)))+total-1)/total - 1
+	return penalty + k*10
+}
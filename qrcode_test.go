@@ -0,0 +1,97 @@
+package imgedit
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/magiconair/properties/assert"
+)
+
+func Test_qrData(t *testing.T) {
+	version, data, err := qrData("HELLO WORLD", QRLevelM)
+	assert.Equal(t, err, nil)
+	assert.Equal(t, version, 1)
+	assert.Equal(t, data, []byte{0x20, 0x5B, 0x0B, 0x78, 0xD1, 0x72, 0xDC, 0x4D, 0x43, 0x40, 0xEC, 0x11, 0xEC, 0x11, 0xEC, 0x11})
+	assert.Equal(t, rsRemainder(data, rsDivisor(10)), []byte{196, 35, 39, 119, 235, 215, 231, 226, 93, 23})
+}
+
+func Test_qrSegment(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		wantMode int
+		wantBits int
+	}{
+		{name: "numeric", text: "01234567", wantMode: 0x1, wantBits: 27},
+		{name: "alphanumeric", text: "AC-42", wantMode: 0x2, wantBits: 28},
+		{name: "byte", text: "hello", wantMode: 0x4, wantBits: 40},
+		{name: "utf-8", text: "うさぎ", wantMode: 0x4, wantBits: 72},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mode, count, bits := qrSegment(tt.text)
+			assert.Equal(t, mode, tt.wantMode)
+			assert.Equal(t, count, len(tt.text))
+			assert.Equal(t, len(bits), tt.wantBits)
+		})
+	}
+}
+
+func Test_qrFormatBits(t *testing.T) {
+	tests := []struct {
+		level QRLevel
+		mask  int
+		want  int
+	}{
+		{level: QRLevelL, mask: 0, want: 0b111011111000100},
+		{level: QRLevelM, mask: 0, want: 0b101010000010010},
+		{level: QRLevelQ, mask: 0, want: 0b011010101011111},
+		{level: QRLevelH, mask: 0, want: 0b001011010001001},
+		{level: QRLevelL, mask: 4, want: 0b110011000101111},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, qrFormatBits(tt.level, tt.mask), tt.want)
+	}
+	assert.Equal(t, qrVersionBits(7), 0b000111110010010100)
+}
+
+func Test_qrAlignmentPositions(t *testing.T) {
+	assert.Equal(t, len(qrAlignmentPositions(1)), 0)
+	assert.Equal(t, qrAlignmentPositions(2), []int{6, 18})
+	assert.Equal(t, qrAlignmentPositions(7), []int{6, 22, 38})
+	assert.Equal(t, qrAlignmentPositions(32), []int{6, 34, 60, 86, 112, 138})
+	assert.Equal(t, qrAlignmentPositions(36), []int{6, 24, 50, 76, 102, 128, 154})
+}
+
+func TestNewQRCode(t *testing.T) {
+	tests := []struct {
+		name    string
+		text    string
+		options *QROptions
+		// wantSize px of the image
+		wantSize int
+		// wantFinder the left top px of the left top finder pattern
+		wantFinder image.Point
+		wantErr    bool
+	}{
+		{name: "default", text: "HELLO WORLD", wantSize: (21 + 8) * 4, wantFinder: image.Point{X: 16, Y: 16}},
+		{name: "no quiet zone", text: "HELLO WORLD", options: &QROptions{Size: 100, QuietZone: -1}, wantSize: 100, wantFinder: image.Point{X: 8, Y: 8}},
+		{name: "level H", text: "https://github.com/icemint0828/imgedit", options: &QROptions{Level: QRLevelH, Size: 300}, wantSize: 300, wantFinder: image.Point{X: 39, Y: 39}},
+		{name: "large", text: string(make([]byte, 2000)), options: &QROptions{Level: QRLevelL}, wantSize: (4*33 + 17 + 8) * 4, wantFinder: image.Point{X: 16, Y: 16}},
+		{name: "too long", text: string(make([]byte, 3000)), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			img, err := NewQRCode(tt.text, tt.options)
+			if tt.wantErr {
+				assert.Equal(t, err != nil, true)
+				return
+			}
+			SaveTestImageAsPng(img)
+			assert.Equal(t, img.Bounds(), image.Rect(0, 0, tt.wantSize, tt.wantSize))
+			assert.Equal(t, color.GrayModel.Convert(img.At(tt.wantFinder.X, tt.wantFinder.Y)), color.Gray{})
+			assert.Equal(t, color.GrayModel.Convert(img.At(tt.wantFinder.X-1, tt.wantFinder.Y-1)), color.Gray{Y: 255})
+		})
+	}
+}
@@ -0,0 +1,752 @@
+package imgedit
+
+import (
+	"errors"
+	"image"
+	"math"
+	"sort"
+	"strconv"
+)
+
+// finderPattern the finder pattern found in the image
+type finderPattern struct {
+	center vec
+	// module px of the module
+	module float64
+	// count the number of the lines the pattern is found on
+	count int
+}
+
+// maxFinderPatterns the number of the finder patterns tried to be combined
+const maxFinderPatterns = 20
+
+// scanQRCodes find the QR codes by the 3 finder patterns and decode them
+func scanQRCodes(m *bitMatrix) []ScannedCode {
+	patterns := findFinderPatterns(m)
+	var codes []ScannedCode
+	used := make([]bool, len(patterns))
+	for _, triple := range finderTriples(patterns) {
+		if used[triple[0]] || used[triple[1]] || used[triple[2]] {
+			continue
+		}
+		code, ok := decodeQRAt(m, patterns[triple[0]], patterns[triple[1]], patterns[triple[2]])
+		if !ok {
+			continue
+		}
+		codes = append(codes, code)
+		for i, p := range patterns {
+			if image.Pt(int(p.center.x), int(p.center.y)).In(code.Bounds) {
+				used[i] = true
+			}
+		}
+	}
+	return codes
+}
+
+// findFinderPatterns return the centers of the patterns of the ratio 1:1:3:1:1 found horizontally and vertically
+func findFinderPatterns(m *bitMatrix) []finderPattern {
+	var patterns []finderPattern
+	for y := 0; y < m.height; y++ {
+		widths := m.runs(y, false)
+		x := widths[0]
+		for i := 1; i+4 < len(widths); i += 2 {
+			if isFinderRatio(widths[i : i+5]) {
+				total := 0
+				for _, w := range widths[i : i+5] {
+					total += w
+				}
+				centerX := x + widths[i] + widths[i+1] + widths[i+2]/2
+				if cy, ok := crossCheckFinder(m, centerX, y, 0, 1, total); ok {
+					if cx, ok := crossCheckFinder(m, centerX, int(cy), 1, 0, total); ok {
+						patterns = addFinderPattern(patterns, finderPattern{center: vec{cx, cy}, module: float64(total) / 7, count: 1})
+					}
+				}
+			}
+			x += widths[i] + widths[i+1]
+		}
+	}
+	var confirmed []finderPattern
+	for _, p := range patterns {
+		if p.count >= 2 {
+			confirmed = append(confirmed, p)
+		}
+	}
+	sort.SliceStable(confirmed, func(i, j int) bool {
+		return confirmed[i].count > confirmed[j].count
+	})
+	if len(confirmed) > maxFinderPatterns {
+		confirmed = confirmed[:maxFinderPatterns]
+	}
+	return confirmed
+}
+
+// isFinderRatio return true if the 5 runs from the dark run are the ratio 1:1:3:1:1
+func isFinderRatio(widths []int) bool {
+	total := 0
+	for _, w := range widths {
+		if w == 0 {
+			return false
+		}
+		total += w
+	}
+	if total < 7 {
+		return false
+	}
+	module := float64(total) / 7
+	for i, ratio := range []float64{1, 1, 3, 1, 1} {
+		if math.Abs(float64(widths[i])-ratio*module) >= ratio*module/2 {
+			return false
+		}
+	}
+	return true
+}
+
+// crossCheckFinder check the finder ratio on the line through (x, y) in the direction,
+// and return the center on the line. total is px of the pattern found on the other line.
+func crossCheckFinder(m *bitMatrix, x, y, dx, dy, total int) (float64, bool) {
+	if !m.at(x, y) {
+		return 0, false
+	}
+	var counts [5]int
+	// count the runs from the center to the backward or the forward, and return the length of the center run
+	count := func(sign int, runs [3]int) int {
+		i, center := 0, 0
+		for n, isDark := range []bool{true, false, true} {
+			for counts[runs[n]] <= total {
+				px, py := x+sign*i*dx, y+sign*i*dy
+				if px < 0 || py < 0 || px >= m.width || py >= m.height || m.at(px, py) != isDark {
+					break
+				}
+				counts[runs[n]]++
+				i++
+			}
+			if n == 0 {
+				center = i
+			}
+		}
+		return center
+	}
+	backward := count(-1, [3]int{2, 1, 0})
+	forward := count(1, [3]int{2, 3, 4})
+	// the center pixel is counted twice
+	counts[2]--
+	sum := 0
+	for _, c := range counts {
+		sum += c
+	}
+	if 5*int(math.Abs(float64(sum-total))) >= 2*total || !isFinderRatio(counts[:]) {
+		return 0, false
+	}
+	center := float64(forward-backward)/2 + 0.5
+	if dx != 0 {
+		return float64(x) + center, true
+	}
+	return float64(y) + center, true
+}
+
+// addFinderPattern add the pattern, or merge it into the pattern found near
+func addFinderPattern(patterns []finderPattern, p finderPattern) []finderPattern {
+	for i, q := range patterns {
+		if math.Abs(q.center.x-p.center.x) <= q.module && math.Abs(q.center.y-p.center.y) <= q.module && math.Abs(q.module-p.module) <= math.Max(1, q.module) {
+			n := float64(q.count)
+			patterns[i] = finderPattern{
+				center: q.center.mul(n).add(p.center).mul(1 / (n + 1)),
+				module: (q.module*n + p.module) / (n + 1),
+				count:  q.count + 1,
+			}
+			return patterns
+		}
+	}
+	return append(patterns, p)
+}
+
+// finderTriples return the combinations of the patterns like the corners of the square in the order of the likelihood,
+// the patterns are ordered as the top left, the top right and the bottom left.
+func finderTriples(patterns []finderPattern) [][3]int {
+	type triple struct {
+		indexes [3]int
+		score   float64
+	}
+	var triples []triple
+	for i := range patterns {
+		for j := i + 1; j < len(patterns); j++ {
+			for k := j + 1; k < len(patterns); k++ {
+				if t, score, ok := orderFinders(patterns, [3]int{i, j, k}); ok {
+					triples = append(triples, triple{indexes: t, score: score})
+				}
+			}
+		}
+	}
+	sort.SliceStable(triples, func(i, j int) bool {
+		return triples[i].score < triples[j].score
+	})
+	result := make([][3]int, len(triples))
+	for i, t := range triples {
+		result[i] = t.indexes
+	}
+	return result
+}
+
+// orderFinders order the patterns as the top left, the top right and the bottom left,
+// and return the difference from the right isosceles triangle.
+func orderFinders(patterns []finderPattern, indexes [3]int) ([3]int, float64, bool) {
+	minModule, maxModule := math.Inf(1), 0.0
+	for _, i := range indexes {
+		minModule, maxModule = math.Min(minModule, patterns[i].module), math.Max(maxModule, patterns[i].module)
+	}
+	if maxModule > minModule*1.6 {
+		return indexes, 0, false
+	}
+	// the top left is at the right angle, opposite to the longest side
+	for n := 0; n < 3; n++ {
+		a, b, c := patterns[indexes[n]].center, patterns[indexes[(n+1)%3]].center, patterns[indexes[(n+2)%3]].center
+		ab, ac, bc := b.sub(a), c.sub(a), c.sub(b)
+		if bc.dot(bc) < ab.dot(ab) || bc.dot(bc) < ac.dot(ac) {
+			continue
+		}
+		legA, legB := ab.length(), ac.length()
+		module := (minModule + maxModule) / 2
+		if math.Min(legA, legB) < 10*module {
+			return indexes, 0, false
+		}
+		legRatio := math.Abs(legA-legB) / math.Max(legA, legB)
+		angle := math.Abs(ab.dot(ac)) / (legA * legB)
+		if legRatio > 0.3 || angle > 0.3 {
+			return indexes, 0, false
+		}
+		// the top right is on the right of the top left seen to the bottom left in the y axis downward
+		tr, bl := indexes[(n+1)%3], indexes[(n+2)%3]
+		if ab.x*ac.y-ab.y*ac.x < 0 {
+			tr, bl = bl, tr
+		}
+		return [3]int{indexes[n], tr, bl}, legRatio + angle, true
+	}
+	return indexes, 0, false
+}
+
+// decodeQRAt decode the QR code of the finder patterns at the top left, the top right and the bottom left
+func decodeQRAt(m *bitMatrix, tl, tr, bl finderPattern) (ScannedCode, bool) {
+	module := (tl.module + tr.module + bl.module) / 3
+	estimated := (tr.center.sub(tl.center).length()+bl.center.sub(tl.center).length())/2/module + 7
+	// try the sizes of the versions near the estimated size
+	var sizes []int
+	for version := 1; version <= 40; version++ {
+		if size := version*4 + 17; math.Abs(float64(size)-estimated) <= 6 {
+			sizes = append(sizes, size)
+		}
+	}
+	sort.SliceStable(sizes, func(i, j int) bool {
+		return math.Abs(float64(sizes[i])-estimated) < math.Abs(float64(sizes[j])-estimated)
+	})
+	for _, size := range sizes {
+		transform := qrTransform(m, tl.center, tr.center, bl.center, size, module)
+		modules := make([][]bool, size)
+		for y := range modules {
+			modules[y] = make([]bool, size)
+			for x := range modules[y] {
+				p := transform(float64(x)+0.5, float64(y)+0.5)
+				modules[y][x] = m.at(int(math.Floor(p.x)), int(math.Floor(p.y)))
+			}
+		}
+		text, err := decodeQRModules(modules)
+		if err != nil {
+			continue
+		}
+		// the bounds of the corners of the code
+		min, max := vec{math.Inf(1), math.Inf(1)}, vec{math.Inf(-1), math.Inf(-1)}
+		for _, corner := range []vec{{0, 0}, {float64(size), 0}, {0, float64(size)}, {float64(size), float64(size)}} {
+			p := transform(corner.x, corner.y)
+			min, max = vec{math.Min(min.x, p.x), math.Min(min.y, p.y)}, vec{math.Max(max.x, p.x), math.Max(max.y, p.y)}
+		}
+		bounds := image.Rect(int(math.Round(min.x)), int(math.Round(min.y)), int(math.Round(max.x)), int(math.Round(max.y)))
+		return ScannedCode{Type: CodeQR, Text: text, Bounds: bounds.Intersect(image.Rect(0, 0, m.width, m.height))}, true
+	}
+	return ScannedCode{}, false
+}
+
+// qrTransform return the function mapping the module coordinates to the image,
+// the right bottom corner is fixed by the alignment pattern if it is found.
+func qrTransform(m *bitMatrix, tl, tr, bl vec, size int, module float64) func(x, y float64) vec {
+	n := float64(size)
+	// the affine transform by the finder patterns
+	affine := func(x, y float64) vec {
+		return tl.add(tr.sub(tl).mul((x - 3.5) / (n - 7))).add(bl.sub(tl).mul((y - 3.5) / (n - 7)))
+	}
+	src := [4]vec{{3.5, 3.5}, {n - 3.5, 3.5}, {3.5, n - 3.5}, {n - 3.5, n - 3.5}}
+	dst := [4]vec{tl, tr, bl, affine(n-3.5, n-3.5)}
+	if size > 21 {
+		predicted := affine(n-6.5, n-6.5)
+		if alignment, ok := findAlignmentPattern(m, predicted, module, module*(4+n/20)); ok {
+			src[3], dst[3] = vec{n - 6.5, n - 6.5}, alignment
+		}
+	}
+	h, ok := homography(src, dst)
+	if !ok {
+		return affine
+	}
+	return func(x, y float64) vec {
+		d := h[6]*x + h[7]*y + 1
+		return vec{(h[0]*x + h[1]*y + h[2]) / d, (h[3]*x + h[4]*y + h[5]) / d}
+	}
+}
+
+// findAlignmentPattern return the center of the alignment pattern nearest to the predicted point in the radius
+func findAlignmentPattern(m *bitMatrix, predicted vec, module, radius float64) (vec, bool) {
+	best, bestDistance := vec{}, radius
+	for y := int(predicted.y - radius); y <= int(predicted.y+radius); y++ {
+		for x := int(predicted.x - radius); x <= int(predicted.x+radius); x++ {
+			// the dark center module is surrounded by the light modules
+			if !m.at(x, y) || m.at(x-1, y) {
+				continue
+			}
+			cx, ok := crossCheckAlignment(m, x, y, 1, 0, module)
+			if !ok {
+				continue
+			}
+			cy, ok := crossCheckAlignment(m, int(cx), y, 0, 1, module)
+			if !ok {
+				continue
+			}
+			cx, ok = crossCheckAlignment(m, int(cx), int(cy), 1, 0, module)
+			if !ok {
+				continue
+			}
+			if d := (vec{cx, cy}).sub(predicted).length(); d < bestDistance {
+				best, bestDistance = vec{cx, cy}, d
+			}
+		}
+	}
+	return best, bestDistance < radius
+}
+
+// crossCheckAlignment check the runs of the ratio 1:1:1 of the light, dark and light on the line through (x, y),
+// and return the center of the dark run on the line.
+func crossCheckAlignment(m *bitMatrix, x, y, dx, dy int, module float64) (float64, bool) {
+	if !m.at(x, y) {
+		return 0, false
+	}
+	limit := int(module*2) + 2
+	run := func(sign int, from int, isDark bool) int {
+		i := from
+		for ; i-from <= limit && m.at(x+sign*i*dx, y+sign*i*dy) == isDark; i++ {
+		}
+		return i
+	}
+	backward, forward := run(-1, 0, true), run(1, 0, true)
+	dark := backward + forward - 1
+	lightBackward, lightForward := run(-1, backward, false)-backward, run(1, forward, false)-forward
+	for _, w := range []int{dark, lightBackward, lightForward} {
+		if math.Abs(float64(w)-module) > module*0.7+0.5 {
+			return 0, false
+		}
+	}
+	center := float64(forward-backward)/2 + 0.5
+	if dx != 0 {
+		return float64(x) + center, true
+	}
+	return float64(y) + center, true
+}
+
+// homography return the projective transform mapping the 4 points of src to dst
+func homography(src, dst [4]vec) ([8]float64, bool) {
+	// solve the 8 linear equations by the gaussian elimination
+	var a [8][9]float64
+	for i := 0; i < 4; i++ {
+		u, v, x, y := src[i].x, src[i].y, dst[i].x, dst[i].y
+		a[i*2] = [9]float64{u, v, 1, 0, 0, 0, -u * x, -v * x, x}
+		a[i*2+1] = [9]float64{0, 0, 0, u, v, 1, -u * y, -v * y, y}
+	}
+	for col := 0; col < 8; col++ {
+		pivot := col
+		for row := col + 1; row < 8; row++ {
+			if math.Abs(a[row][col]) > math.Abs(a[pivot][col]) {
+				pivot = row
+			}
+		}
+		if math.Abs(a[pivot][col]) < 1e-12 {
+			return [8]float64{}, false
+		}
+		a[col], a[pivot] = a[pivot], a[col]
+		for row := 0; row < 8; row++ {
+			if row == col {
+				continue
+			}
+			f := a[row][col] / a[col][col]
+			for k := col; k < 9; k++ {
+				a[row][k] -= f * a[col][k]
+			}
+		}
+	}
+	var h [8]float64
+	for i := range h {
+		h[i] = a[i][8] / a[i][i]
+	}
+	return h, true
+}
+
+// decodeQRModules return the text of the modules of the QR code, true is dark
+func decodeQRModules(modules [][]bool) (string, error) {
+	size := len(modules)
+	version := (size - 17) / 4
+	at := func(x, y int) bool {
+		return modules[y][x]
+	}
+
+	level, mask, err := readQRFormat(at, size)
+	if err != nil {
+		return "", err
+	}
+	if version >= 7 {
+		if v, err := readQRVersion(at, size); err != nil || v != version {
+			return "", errors.New("version information does not match the size")
+		}
+	}
+
+	// read the codewords in the zigzag, skipping the function patterns
+	q := newQRCode(version)
+	codewords := make([]byte, qrRawModules(version)/8)
+	i := 0
+	for right := size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5
+		}
+		for vert := 0; vert < size; vert++ {
+			for j := 0; j < 2; j++ {
+				x, y := right-j, vert
+				if (right+1)&2 == 0 {
+					y = size - 1 - vert
+				}
+				if !q.function[y][x] && i < len(codewords)*8 {
+					if at(x, y) != qrMask(mask, x, y) {
+						codewords[i/8] |= 0x80 >> (i % 8)
+					}
+					i++
+				}
+			}
+		}
+	}
+
+	data, err := qrCorrect(codewords, version, level)
+	if err != nil {
+		return "", err
+	}
+	return parseQRData(data, version)
+}
+
+// readQRFormat return the level and the mask of the format information nearest to the valid one
+func readQRFormat(at func(x, y int) bool, size int) (QRLevel, int, error) {
+	read := func(positions [15][2]int) int {
+		bits := 0
+		for i, p := range positions {
+			if at(p[0], p[1]) {
+				bits |= 1 << i
+			}
+		}
+		return bits
+	}
+	// the positions of the bits in the same order as drawFormat
+	var first, second [15][2]int
+	for i := 0; i < 15; i++ {
+		switch {
+		case i <= 5:
+			first[i] = [2]int{8, i}
+		case i <= 7:
+			first[i] = [2]int{8, i + 1}
+		case i == 8:
+			first[i] = [2]int{7, 8}
+		default:
+			first[i] = [2]int{14 - i, 8}
+		}
+		if i < 8 {
+			second[i] = [2]int{size - 1 - i, 8}
+		} else {
+			second[i] = [2]int{8, size - 15 + i}
+		}
+	}
+	bestLevel, bestMask, bestDistance := QRLevelM, 0, 4
+	for _, bits := range []int{read(first), read(second)} {
+		for level := QRLevelM; level <= QRLevelH; level++ {
+			for mask := 0; mask < 8; mask++ {
+				if d := bitDistance(bits, qrFormatBits(level, mask)); d < bestDistance {
+					bestLevel, bestMask, bestDistance = level, mask, d
+				}
+			}
+		}
+	}
+	if bestDistance > 3 {
+		return 0, 0, errors.New("format information is broken")
+	}
+	return bestLevel, bestMask, nil
+}
+
+// readQRVersion return the version of the version information nearest to the valid one
+func readQRVersion(at func(x, y int) bool, size int) (int, error) {
+	best, bestDistance := 0, 4
+	for _, transposed := range []bool{false, true} {
+		bits := 0
+		for i := 0; i < 18; i++ {
+			a, b := size-11+i%3, i/3
+			if transposed {
+				a, b = b, a
+			}
+			if at(a, b) {
+				bits |= 1 << i
+			}
+		}
+		for version := 7; version <= 40; version++ {
+			if d := bitDistance(bits, qrVersionBits(version)); d < bestDistance {
+				best, bestDistance = version, d
+			}
+		}
+	}
+	if bestDistance > 3 {
+		return 0, errors.New("version information is broken")
+	}
+	return best, nil
+}
+
+// bitDistance return the number of the different bits
+func bitDistance(a, b int) int {
+	d := 0
+	for x := a ^ b; x != 0; x &= x - 1 {
+		d++
+	}
+	return d
+}
+
+// qrCorrect deinterleave the codewords into the blocks, correct the errors and return the data codewords
+func qrCorrect(codewords []byte, version int, level QRLevel) ([]byte, error) {
+	numBlocks, eccLength := qrEccBlocks[level][version], qrEccCodewords[level][version]
+	numShortBlocks := numBlocks - len(codewords)%numBlocks
+	shortDataLength := len(codewords)/numBlocks - eccLength
+
+	blocks := make([][]byte, numBlocks)
+	i := 0
+	for n := 0; n <= shortDataLength; n++ {
+		for j := range blocks {
+			if n < shortDataLength || j >= numShortBlocks {
+				blocks[j] = append(blocks[j], codewords[i])
+				i++
+			}
+		}
+	}
+	for n := 0; n < eccLength; n++ {
+		for j := range blocks {
+			blocks[j] = append(blocks[j], codewords[i])
+			i++
+		}
+	}
+
+	var data []byte
+	for _, block := range blocks {
+		if err := rsCorrect(block, eccLength); err != nil {
+			return nil, err
+		}
+		data = append(data, block[:len(block)-eccLength]...)
+	}
+	return data, nil
+}
+
+// gfExp and gfLog the tables of the powers and the logarithms of 2 in GF(2^8)
+var gfExp, gfLog = func() ([512]byte, [256]int) {
+	var exp [512]byte
+	var log [256]int
+	x := 1
+	for i := 0; i < 255; i++ {
+		exp[i], exp[i+255] = byte(x), byte(x)
+		log[x] = i
+		x <<= 1
+		if x >= 256 {
+			x ^= 0x11D
+		}
+	}
+	return exp, log
+}()
+
+// gfDivide return x / y in GF(2^8), y is not 0
+func gfDivide(x, y byte) byte {
+	if x == 0 {
+		return 0
+	}
+	return gfExp[gfLog[x]+255-gfLog[y]]
+}
+
+// gfEvaluate return the value of the polynomial of the coefficients from the lowest degree at x
+func gfEvaluate(poly []byte, x byte) byte {
+	var y byte
+	for i := len(poly) - 1; i >= 0; i-- {
+		y = gfMultiply(y, x) ^ poly[i]
+	}
+	return y
+}
+
+// rsCorrect correct the errors of the codeword of the data and the ecc codewords of eccLength in place
+func rsCorrect(codeword []byte, eccLength int) error {
+	syndromes, ok := rsSyndromes(codeword, eccLength)
+	if ok {
+		return nil
+	}
+
+	// the error locator by the Berlekamp-Massey algorithm
+	locator, prev := []byte{1}, []byte{1}
+	numErrors, shift, discrepancy := 0, 1, byte(1)
+	for n := 0; n < eccLength; n++ {
+		d := syndromes[n]
+		for i := 1; i <= numErrors && i < len(locator); i++ {
+			d ^= gfMultiply(locator[i], syndromes[n-i])
+		}
+		if d == 0 {
+			shift++
+			continue
+		}
+		next := append([]byte{}, locator...)
+		for len(next) < len(prev)+shift {
+			next = append(next, 0)
+		}
+		f := gfDivide(d, discrepancy)
+		for i, p := range prev {
+			next[i+shift] ^= gfMultiply(f, p)
+		}
+		if 2*numErrors <= n {
+			numErrors, prev, discrepancy, shift = n+1-numErrors, locator, d, 1
+		} else {
+			shift++
+		}
+		locator = next
+	}
+	if numErrors*2 > eccLength {
+		return errors.New("too many errors to correct")
+	}
+
+	// the positions of the errors are the inverse of the roots of the locator
+	var positions []int
+	for p := 0; p < len(codeword); p++ {
+		if gfEvaluate(locator, gfExp[(255-p%255)%255]) == 0 {
+			positions = append(positions, p)
+		}
+	}
+	if len(positions) != numErrors {
+		return errors.New("errors cannot be located")
+	}
+
+	// the magnitudes of the errors by the Forney algorithm
+	evaluator := make([]byte, eccLength)
+	for k := range evaluator {
+		for i := 0; i <= k && i < len(locator); i++ {
+			evaluator[k] ^= gfMultiply(syndromes[k-i], locator[i])
+		}
+	}
+	for _, p := range positions {
+		xInverse := gfExp[(255-p%255)%255]
+		var derivative byte
+		for k := 1; k < len(locator); k += 2 {
+			derivative ^= gfMultiply(locator[k], gfExp[(gfLog[xInverse]*(k-1))%255])
+		}
+		if derivative == 0 {
+			return errors.New("errors cannot be corrected")
+		}
+		magnitude := gfMultiply(gfExp[p%255], gfDivide(gfEvaluate(evaluator, xInverse), derivative))
+		codeword[len(codeword)-1-p] ^= magnitude
+	}
+	if _, ok := rsSyndromes(codeword, eccLength); !ok {
+		return errors.New("errors cannot be corrected")
+	}
+	return nil
+}
+
+// rsSyndromes return the values of the codeword at the roots of the generator, ok is true if they are all 0
+func rsSyndromes(codeword []byte, eccLength int) ([]byte, bool) {
+	syndromes := make([]byte, eccLength)
+	ok := true
+	for j := range syndromes {
+		for _, b := range codeword {
+			syndromes[j] = gfMultiply(syndromes[j], gfExp[j]) ^ b
+		}
+		ok = ok && syndromes[j] == 0
+	}
+	return syndromes, ok
+}
+
+// parseQRData return the text of the segments in the data codewords
+func parseQRData(data []byte, version int) (string, error) {
+	r := &bitReader{data: data}
+	var text []byte
+	for r.remaining() >= 4 {
+		mode := r.read(4)
+		switch mode {
+		case 0x0:
+			return string(text), r.err
+		case 0x1:
+			for count := r.read(qrCountBits(mode, version)); count > 0 && r.err == nil; count -= 3 {
+				digits := int(math.Min(3, float64(count)))
+				v := r.read(digits*3 + 1)
+				if v >= int(math.Pow10(digits)) {
+					return "", errors.New("numeric segment is broken")
+				}
+				s := strconv.Itoa(v)
+				for len(s) < digits {
+					s = "0" + s
+				}
+				text = append(text, s...)
+			}
+		case 0x2:
+			for count := r.read(qrCountBits(mode, version)); count > 0 && r.err == nil; count -= 2 {
+				if count == 1 {
+					text = append(text, qrAlphanumeric[r.read(6)%45])
+					break
+				}
+				v := r.read(11)
+				if v >= 45*45 {
+					return "", errors.New("alphanumeric segment is broken")
+				}
+				text = append(text, qrAlphanumeric[v/45], qrAlphanumeric[v%45])
+			}
+		case 0x4:
+			for count := r.read(qrCountBits(mode, version)); count > 0 && r.err == nil; count-- {
+				text = append(text, byte(r.read(8)))
+			}
+		case 0x7:
+			// the ECI designator is ignored and the bytes are read as UTF-8
+			switch {
+			case r.read(1) == 0:
+				r.read(7)
+			case r.read(1) == 0:
+				r.read(14)
+			default:
+				r.read(22)
+			}
+		default:
+			return "", errors.New("mode is not supported: " + strconv.Itoa(mode))
+		}
+		if r.err != nil {
+			return "", r.err
+		}
+	}
+	return string(text), nil
+}
+
+// bitReader read the bits from the most significant bit
+type bitReader struct {
+	data     []byte
+	position int
+	err      error
+}
+
+func (r *bitReader) remaining() int {
+	return len(r.data)*8 - r.position
+}
+
+// read return the next length bits, err is set if the data is short
+func (r *bitReader) read(length int) int {
+	if length > r.remaining() {
+		r.err = errors.New("data is short")
+		r.position = len(r.data) * 8
+		return 0
+	}
+	v := 0
+	for i := 0; i < length; i++ {
+		v = v<<1 | int(r.data[r.position/8]>>(7-r.position%8))&1
+		r.position++
+	}
+	return v
+}
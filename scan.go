@@ -0,0 +1,560 @@
+package imgedit
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"sort"
+)
+
+// CodeType the type of the scanned code
+type CodeType int
+
+const (
+	// CodeQR QR code
+	CodeQR CodeType = iota
+	// CodeCode128 Code 128 barcode
+	CodeCode128
+	// CodeEAN13 EAN-13 barcode, UPC-A is read as EAN-13 with the leading 0
+	CodeEAN13
+	// CodeEAN8 EAN-8 barcode
+	CodeEAN8
+)
+
+// String return the name of the code type
+func (t CodeType) String() string {
+	switch t {
+	case CodeQR:
+		return "qr"
+	case CodeCode128:
+		return "code128"
+	case CodeEAN13:
+		return "ean13"
+	case CodeEAN8:
+		return "ean8"
+	default:
+		return "unknown"
+	}
+}
+
+// ScannedCode the code found in the image
+type ScannedCode struct {
+	Type CodeType
+	// Text the payload of the code
+	Text string
+	// Bounds of the code in the image without the quiet zone
+	Bounds image.Rectangle
+}
+
+const (
+	// minDynamicRange the luminance range of the block considered to be the uniform color
+	minDynamicRange = 24
+	// binarizeBlockSize px of the blocks to calculate the local threshold
+	binarizeBlockSize = 8
+)
+
+// ScanCodes find the QR codes and the barcodes in the image and decode them,
+// the codes are sorted from the top left.
+func (c *converter) ScanCodes() []ScannedCode {
+	m := binarize(c.Image)
+	codes := append(scanQRCodes(m), scanBarcodes(m)...)
+	for i := range codes {
+		codes[i].Bounds = codes[i].Bounds.Add(c.Bounds().Min)
+	}
+	sort.SliceStable(codes, func(i, j int) bool {
+		if codes[i].Bounds.Min.Y != codes[j].Bounds.Min.Y {
+			return codes[i].Bounds.Min.Y < codes[j].Bounds.Min.Y
+		}
+		return codes[i].Bounds.Min.X < codes[j].Bounds.Min.X
+	})
+	return codes
+}
+
+// bitMatrix is the binarized image from (0, 0), true is dark
+type bitMatrix struct {
+	width, height int
+	bits          []bool
+}
+
+// at return true if the pixel is dark, the outside of the image is light
+func (m *bitMatrix) at(x, y int) bool {
+	if x < 0 || y < 0 || x >= m.width || y >= m.height {
+		return false
+	}
+	return m.bits[y*m.width+x]
+}
+
+// runs return the widths of the runs on the row or the column alternately from the light run,
+// the first light run is 0 if the line starts with the dark pixel.
+func (m *bitMatrix) runs(line int, vertical bool) []int {
+	length := m.width
+	if vertical {
+		length = m.height
+	}
+	widths := []int{0}
+	dark := false
+	for i := 0; i < length; i++ {
+		isDark := m.at(i, line)
+		if vertical {
+			isDark = m.at(line, i)
+		}
+		if isDark != dark {
+			widths = append(widths, 0)
+			dark = isDark
+		}
+		widths[len(widths)-1]++
+	}
+	return widths
+}
+
+// binarize return the dark pixels of the image by the threshold of the neighboring blocks,
+// the global threshold is used for the small image.
+func binarize(img image.Image) *bitMatrix {
+	bounds := img.Bounds()
+	w, h := bounds.Dx(), bounds.Dy()
+	luminance := make([]int, w*h)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			// the transparent pixels are light
+			r, g, b, a := img.At(bounds.Min.X+x, bounds.Min.Y+y).RGBA()
+			gray := color.GrayModel.Convert(color.RGBA64{R: uint16(r), G: uint16(g), B: uint16(b), A: math.MaxUint16}).(color.Gray).Y
+			luminance[y*w+x] = (int(gray)*int(a) + 255*(math.MaxUint16-int(a))) / math.MaxUint16
+		}
+	}
+	m := &bitMatrix{width: w, height: h, bits: make([]bool, w*h)}
+	if w < binarizeBlockSize*5 || h < binarizeBlockSize*5 {
+		threshold := otsuThreshold(luminance)
+		for i, l := range luminance {
+			m.bits[i] = l <= threshold
+		}
+		return m
+	}
+
+	subW, subH := (w+binarizeBlockSize-1)/binarizeBlockSize, (h+binarizeBlockSize-1)/binarizeBlockSize
+	blackPoints := make([][]int, subH)
+	for by := 0; by < subH; by++ {
+		blackPoints[by] = make([]int, subW)
+		for bx := 0; bx < subW; bx++ {
+			sum, count, min, max := 0, 0, 255, 0
+			for y := by * binarizeBlockSize; y < (by+1)*binarizeBlockSize && y < h; y++ {
+				for x := bx * binarizeBlockSize; x < (bx+1)*binarizeBlockSize && x < w; x++ {
+					l := luminance[y*w+x]
+					sum, count = sum+l, count+1
+					min, max = int(math.Min(float64(min), float64(l))), int(math.Max(float64(max), float64(l)))
+				}
+			}
+			average := sum / count
+			if max-min <= minDynamicRange {
+				// the uniform block is light, or dark if it is darker than the neighboring blocks
+				average = min / 2
+				if by > 0 && bx > 0 {
+					neighbor := (blackPoints[by-1][bx] + 2*blackPoints[by][bx-1] + blackPoints[by-1][bx-1]) / 4
+					if min < neighbor {
+						average = neighbor
+					}
+				}
+			}
+			blackPoints[by][bx] = average
+		}
+	}
+	clamp := func(v, max int) int {
+		return int(math.Max(2, math.Min(float64(v), float64(max-3))))
+	}
+	for by := 0; by < subH; by++ {
+		for bx := 0; bx < subW; bx++ {
+			// the threshold is the average of the 5x5 blocks around
+			cy, cx, sum := clamp(by, subH), clamp(bx, subW), 0
+			for dy := -2; dy <= 2; dy++ {
+				for dx := -2; dx <= 2; dx++ {
+					sum += blackPoints[cy+dy][cx+dx]
+				}
+			}
+			threshold := sum / 25
+			for y := by * binarizeBlockSize; y < (by+1)*binarizeBlockSize && y < h; y++ {
+				for x := bx * binarizeBlockSize; x < (bx+1)*binarizeBlockSize && x < w; x++ {
+					m.bits[y*w+x] = luminance[y*w+x] <= threshold
+				}
+			}
+		}
+	}
+	return m
+}
+
+// otsuThreshold return the threshold dividing the luminance into the two classes best
+func otsuThreshold(luminance []int) int {
+	var histogram [256]int
+	total := 0
+	for _, l := range luminance {
+		histogram[l]++
+		total += l
+	}
+	best, bestVariance := 127, -1.0
+	count, sum := 0, 0
+	for t := 0; t < 255; t++ {
+		count += histogram[t]
+		sum += t * histogram[t]
+		if count == 0 || count == len(luminance) {
+			continue
+		}
+		darkMean := float64(sum) / float64(count)
+		lightMean := float64(total-sum) / float64(len(luminance)-count)
+		variance := float64(count) * float64(len(luminance)-count) * (darkMean - lightMean) * (darkMean - lightMean)
+		if variance > bestVariance {
+			best, bestVariance = t, variance
+		}
+	}
+	return best
+}
+
+// lineCode the barcode found on the line, start and end are px along the line
+type lineCode struct {
+	codeType   CodeType
+	text       string
+	start, end int
+}
+
+// minBarcodeLines the number of the lines to find the barcode to avoid the accidental match
+const minBarcodeLines = 2
+
+// scanBarcodes find the barcodes on the rows and the columns
+func scanBarcodes(m *bitMatrix) []ScannedCode {
+	var codes []ScannedCode
+	var lines []int
+	for _, vertical := range []bool{false, true} {
+		numLines, length := m.height, m.width
+		if vertical {
+			numLines, length = m.width, m.height
+		}
+		for line := 0; line < numLines; line++ {
+			widths := m.runs(line, vertical)
+			found := decodeLine(widths)
+			// the barcode upside down
+			for _, code := range decodeLine(reverseRuns(widths)) {
+				code.start, code.end = length-code.end, length-code.start
+				found = append(found, code)
+			}
+			for _, code := range found {
+				rect := image.Rect(code.start, line, code.end, line+1)
+				if vertical {
+					rect = image.Rect(line, code.start, line+1, code.end)
+				}
+				codes, lines = mergeLineCode(codes, lines, ScannedCode{Type: code.codeType, Text: code.text, Bounds: rect})
+			}
+		}
+	}
+	var result []ScannedCode
+	for i, code := range codes {
+		if lines[i] >= minBarcodeLines {
+			result = append(result, code)
+		}
+	}
+	return result
+}
+
+// mergeLineCode add the code found on the line to the same code found on the lines near,
+// the lines failed to decode between them are in the code.
+func mergeLineCode(codes []ScannedCode, lines []int, code ScannedCode) ([]ScannedCode, []int) {
+	for i, c := range codes {
+		margin := int(math.Max(float64(c.Bounds.Dx()), float64(c.Bounds.Dy()))) / 2
+		if c.Type == code.Type && c.Text == code.Text && c.Bounds.Inset(-margin).Overlaps(code.Bounds) {
+			codes[i].Bounds = c.Bounds.Union(code.Bounds)
+			lines[i]++
+			return codes, lines
+		}
+	}
+	return append(codes, code), append(lines, 1)
+}
+
+// reverseRuns return the runs of the line read from the end
+func reverseRuns(widths []int) []int {
+	var reversed []int
+	if len(widths)%2 == 0 {
+		// the line ends with the dark run
+		reversed = []int{0}
+	}
+	for i := len(widths) - 1; i >= 0; i-- {
+		reversed = append(reversed, widths[i])
+	}
+	return reversed
+}
+
+// decodeLine return the barcodes on the line of the runs
+func decodeLine(widths []int) []lineCode {
+	positions := make([]int, len(widths)+1)
+	for i, w := range widths {
+		positions[i+1] = positions[i] + w
+	}
+	var codes []lineCode
+	// the dark runs are at the odd indexes
+	for i := 1; i < len(widths); i += 2 {
+		if code, runs, ok := decodeBarcodeAt(widths, i); ok {
+			code.start, code.end = positions[i], positions[i+runs]
+			codes = append(codes, code)
+			i += runs - 1
+		}
+	}
+	return codes
+}
+
+// decodeBarcodeAt decode the barcode starting from the dark run at i, and return the number of the runs
+func decodeBarcodeAt(widths []int, i int) (lineCode, int, bool) {
+	if text, runs, ok := decodeCode128(widths, i); ok {
+		return lineCode{codeType: CodeCode128, text: text}, runs, true
+	}
+	if text, runs, ok := decodeEAN(widths, i, 13); ok {
+		return lineCode{codeType: CodeEAN13, text: text}, runs, true
+	}
+	if text, runs, ok := decodeEAN(widths, i, 8); ok {
+		return lineCode{codeType: CodeEAN8, text: text}, runs, true
+	}
+	return lineCode{}, 0, false
+}
+
+const (
+	// code128MaxVariance the max difference of the runs from the Code 128 pattern per px
+	code128MaxVariance = 0.25
+	// eanMaxVariance the max difference of the runs from the EAN pattern per px, the EAN patterns are more distinct
+	eanMaxVariance = 0.48
+	// maxIndividualVariance the max difference of each run from the pattern per module
+	maxIndividualVariance = 0.7
+)
+
+// patternVariance return the difference of the runs from the widths of the pattern, or +Inf if they do not match
+func patternVariance(runs, pattern []int) float64 {
+	total, patternTotal := 0, 0
+	for i := range pattern {
+		total += runs[i]
+		patternTotal += pattern[i]
+	}
+	if total < patternTotal {
+		return math.Inf(1)
+	}
+	unit := float64(total) / float64(patternTotal)
+	variance := 0.0
+	for i, p := range pattern {
+		d := math.Abs(float64(runs[i]) - float64(p)*unit)
+		if d > maxIndividualVariance*unit {
+			return math.Inf(1)
+		}
+		variance += d
+	}
+	return variance / float64(total)
+}
+
+// bestPattern return the index of the pattern matching the runs best, -1 if none of them matches under maxVariance
+func bestPattern(runs []int, patterns [][]int, maxVariance float64) int {
+	best, bestVariance := -1, maxVariance
+	for i, pattern := range patterns {
+		if v := patternVariance(runs, pattern); v < bestVariance {
+			best, bestVariance = i, v
+		}
+	}
+	return best
+}
+
+// widthsOf return the widths of the digits string like "212222"
+func widthsOf(s string) []int {
+	widths := make([]int, len(s))
+	for i, r := range s {
+		widths[i] = int(r - '0')
+	}
+	return widths
+}
+
+// runsOf return the widths of the runs of the bits string like "0001101"
+func runsOf(bits string) []int {
+	var widths []int
+	for i := range bits {
+		if i == 0 || bits[i] != bits[i-1] {
+			widths = append(widths, 0)
+		}
+		widths[len(widths)-1]++
+	}
+	return widths
+}
+
+// hasQuietZone return true if the light run before the dark run at i is wider than the modules
+func hasQuietZone(widths []int, i int, unit float64, modules int) bool {
+	return i == 1 && widths[0] == 0 || float64(widths[i-1]) >= unit*float64(modules)
+}
+
+// code128Widths the widths of code128Patterns
+var code128Widths = func() [][]int {
+	widths := make([][]int, len(code128Patterns))
+	for i, p := range code128Patterns {
+		widths[i] = widthsOf(p)
+	}
+	return widths
+}()
+
+// decodeCode128 decode the Code 128 barcode starting from the dark run at i
+func decodeCode128(widths []int, i int) (string, int, bool) {
+	if i+6 > len(widths) {
+		return "", 0, false
+	}
+	start := bestPattern(widths[i:i+6], code128Widths[:code128Stop], code128MaxVariance)
+	if start < code128StartA || start > code128StartC {
+		return "", 0, false
+	}
+	unit := 0.0
+	for _, w := range widths[i : i+6] {
+		unit += float64(w) / 11
+	}
+	if !hasQuietZone(widths, i, unit, 5) {
+		return "", 0, false
+	}
+	values := []int{start}
+	for j := i + 6; j+7 <= len(widths); j += 6 {
+		v := bestPattern(widths[j:j+6], code128Widths[:code128Stop], code128MaxVariance)
+		if patternVariance(widths[j:j+7], code128Widths[code128Stop]) < code128MaxVariance {
+			v = code128Stop
+		}
+		switch {
+		case v < 0:
+			return "", 0, false
+		case v == code128Stop:
+			if len(values) < 3 {
+				return "", 0, false
+			}
+			check := values[0]
+			for k, value := range values[1 : len(values)-1] {
+				check += (k + 1) * value
+			}
+			if check%103 != values[len(values)-1] {
+				return "", 0, false
+			}
+			text, ok := code128Text(values[:len(values)-1])
+			return text, j + 7 - i, ok
+		}
+		values = append(values, v)
+	}
+	return "", 0, false
+}
+
+// code128Text return the text of the values from the start, the function codes are ignored
+func code128Text(values []int) (string, bool) {
+	const codeA, codeB, codeC = 0, 1, 2
+	set := values[0] - code128StartA
+	var text []byte
+	shift := false
+	for _, v := range values[1:] {
+		current := set
+		if shift {
+			current = codeA + codeB - set
+			shift = false
+		}
+		switch current {
+		case codeC:
+			switch {
+			case v < 100:
+				text = append(text, byte('0'+v/10), byte('0'+v%10))
+			case v == code128CodeB:
+				set = codeB
+			case v == 101:
+				set = codeA
+			}
+		default:
+			switch {
+			case v < 64 || (current == codeB && v < 96):
+				text = append(text, byte(' '+v))
+			case current == codeA && v < 96:
+				text = append(text, byte(v-64))
+			case v == 98:
+				shift = true
+			case v == code128CodeC:
+				set = codeC
+			case current == codeA && v == code128CodeB, current == codeB && v == 101:
+				set = codeA + codeB - current
+			}
+		}
+	}
+	return string(text), len(text) > 0
+}
+
+// eanWidths the widths of the L codes of the digits, the G codes are reversed
+var eanWidths = func() [][]int {
+	widths := make([][]int, len(ean13Patterns))
+	for i, p := range ean13Patterns {
+		widths[i] = runsOf(p)
+	}
+	return widths
+}()
+
+// eanGWidths the widths of the G codes of the digits
+var eanGWidths = func() [][]int {
+	widths := make([][]int, len(eanWidths))
+	for i, w := range eanWidths {
+		for j := len(w) - 1; j >= 0; j-- {
+			widths[i] = append(widths[i], w[j])
+		}
+	}
+	return widths
+}()
+
+// decodeEAN decode the EAN-13 or EAN-8 barcode of the length starting from the dark run at i
+func decodeEAN(widths []int, i, length int) (string, int, bool) {
+	// the digits at each side, the first digit of EAN-13 is the parity of the left side
+	half := length / 2
+	// the guards and 4 runs of each digit
+	runs := 3 + half*4 + 5 + half*4 + 3
+	if i+runs > len(widths) || patternVariance(widths[i:i+3], []int{1, 1, 1}) >= eanMaxVariance {
+		return "", 0, false
+	}
+	unit := float64(widths[i]+widths[i+1]+widths[i+2]) / 3
+	if !hasQuietZone(widths, i, unit, 3) {
+		return "", 0, false
+	}
+	leftWidths := eanWidths
+	if length == 13 {
+		leftWidths = append(append([][]int{}, eanWidths...), eanGWidths...)
+	}
+	var digits []byte
+	parity := ""
+	j := i + 3
+	for n := 0; n < half; n++ {
+		// the left digits of EAN-13 are the L or G codes
+		d := bestPattern(widths[j:j+4], leftWidths, eanMaxVariance)
+		if d < 0 {
+			return "", 0, false
+		}
+		if d >= 10 {
+			d -= 10
+			parity += "G"
+		} else {
+			parity += "L"
+		}
+		digits = append(digits, byte('0'+d))
+		j += 4
+	}
+	if patternVariance(widths[j:j+5], []int{1, 1, 1, 1, 1}) >= eanMaxVariance {
+		return "", 0, false
+	}
+	j += 5
+	for n := 0; n < half; n++ {
+		d := bestPattern(widths[j:j+4], eanWidths, eanMaxVariance)
+		if d < 0 {
+			return "", 0, false
+		}
+		digits = append(digits, byte('0'+d))
+		j += 4
+	}
+	if patternVariance(widths[j:j+3], []int{1, 1, 1}) >= eanMaxVariance {
+		return "", 0, false
+	}
+	if length == 13 {
+		first := -1
+		for k, p := range ean13Parities {
+			if p == parity {
+				first = k
+			}
+		}
+		if first < 0 {
+			return "", 0, false
+		}
+		digits = append([]byte{byte('0' + first)}, digits...)
+	}
+	if gtinCheckDigit(string(digits[:length-1])) != digits[length-1] {
+		return "", 0, false
+	}
+	return string(digits), runs, true
+}
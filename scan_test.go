@@ -0,0 +1,174 @@
+package imgedit
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"testing"
+
+	"github.com/magiconair/properties/assert"
+	xdraw "golang.org/x/image/draw"
+	"golang.org/x/image/math/f64"
+)
+
+// rotateImage return the image rotated clockwise and scaled around the center on the white background
+func rotateImage(img image.Image, degrees, scale float64) image.Image {
+	b := img.Bounds()
+	size := int(float64(b.Dx()+b.Dy()) * scale)
+	dst := image.NewRGBA(image.Rect(0, 0, size, size))
+	xdraw.Draw(dst, dst.Bounds(), image.NewUniform(color.White), image.Point{}, xdraw.Src)
+	r := degrees * math.Pi / 180
+	cos, sin := math.Cos(r)*scale, math.Sin(r)*scale
+	cx, cy := float64(b.Dx())/2, float64(b.Dy())/2
+	tx, ty := float64(size)/2-(cos*cx-sin*cy), float64(size)/2-(sin*cx+cos*cy)
+	xdraw.BiLinear.Transform(dst, f64.Aff3{cos, -sin, tx, sin, cos, ty}, img, b, xdraw.Over, nil)
+	return dst
+}
+
+func Test_converter_ScanCodes(t *testing.T) {
+	qrCode, _ := NewQRCode("https://github.com/icemint0828/imgedit", &QROptions{Level: QRLevelQ})
+	code128, _ := NewCode128("ABC-123456", &BarcodeOptions{ModuleWidth: 2})
+	ean13, _ := NewEAN13("400638133393", nil)
+	tests := []struct {
+		name  string
+		image func() image.Image
+		want  []ScannedCode
+		// ignoreBounds is true if the bounds are not exact
+		ignoreBounds bool
+	}{
+		{
+			name:  "qr code",
+			image: func() image.Image { return qrCode },
+			want:  []ScannedCode{{Type: CodeQR, Text: "https://github.com/icemint0828/imgedit", Bounds: image.Rect(16, 16, 148, 148)}},
+		},
+		{
+			name: "damaged qr code",
+			image: func() image.Image {
+				img, _ := NewQRCode("rabbit and turtle", &QROptions{Level: QRLevelH})
+				c := NewConverter(img)
+				c.DrawRectangle(image.Rect(50, 50, 70, 76), 0, &ShapeOptions{Fill: color.White})
+				return c.Convert()
+			},
+			want: []ScannedCode{{Type: CodeQR, Text: "rabbit and turtle", Bounds: image.Rect(16, 16, 132, 132)}},
+		},
+		{
+			name:         "rotated qr code",
+			image:        func() image.Image { return rotateImage(qrCode, 30, 0.7) },
+			want:         []ScannedCode{{Type: CodeQR, Text: "https://github.com/icemint0828/imgedit"}},
+			ignoreBounds: true,
+		},
+		{
+			name:  "code128",
+			image: func() image.Image { return code128 },
+			want:  []ScannedCode{{Type: CodeCode128, Text: "ABC-123456", Bounds: image.Rect(20, 0, 266, 100)}},
+		},
+		{
+			name: "upside down ean13",
+			image: func() image.Image {
+				c := NewConverter(ean13)
+				c.Reverse(true)
+				c.Reverse(false)
+				return c.Convert()
+			},
+			want: []ScannedCode{{Type: CodeEAN13, Text: "4006381333931", Bounds: image.Rect(40, 0, 420, 100)}},
+		},
+		{
+			name:         "vertical code128",
+			image:        func() image.Image { return rotateImage(code128, 90, 0.8) },
+			want:         []ScannedCode{{Type: CodeCode128, Text: "ABC-123456"}},
+			ignoreBounds: true,
+		},
+		{
+			name: "codes on the photo",
+			image: func() image.Image {
+				c := NewConverter(GetPngImage())
+				small, _ := NewQRCode("rabbit", &QROptions{Size: 120})
+				c.AddImage(qrCode, image.Point{X: 100, Y: 100})
+				c.AddImage(small, image.Point{X: 900, Y: 200})
+				c.AddImage(ean13, image.Point{X: 300, Y: 1200})
+				return c.Convert()
+			},
+			want: []ScannedCode{
+				{Type: CodeQR, Text: "https://github.com/icemint0828/imgedit", Bounds: image.Rect(116, 116, 248, 248)},
+				{Type: CodeQR, Text: "rabbit", Bounds: image.Rect(918, 218, 1002, 302)},
+				{Type: CodeEAN13, Text: "4006381333931", Bounds: image.Rect(340, 1200, 720, 1300)},
+			},
+		},
+		{
+			name:  "photo",
+			image: GetPngImage,
+			want:  nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			img := tt.image()
+			SaveTestImageAsPng(img)
+			got := NewConverter(img).ScanCodes()
+			if tt.ignoreBounds {
+				for i := range got {
+					got[i].Bounds = image.Rectangle{}
+				}
+			}
+			assert.Equal(t, got, tt.want)
+		})
+	}
+}
+
+func Test_rsCorrect(t *testing.T) {
+	data := []byte("rabbit and turtle")
+	ecc := rsRemainder(data, rsDivisor(10))
+	tests := []struct {
+		name    string
+		errors  []int
+		wantErr bool
+	}{
+		{name: "no error"},
+		{name: "1 error", errors: []int{3}},
+		{name: "5 errors", errors: []int{0, 4, 10, 20, 26}},
+		{name: "6 errors", errors: []int{0, 4, 10, 20, 25, 26}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			codeword := append(append([]byte{}, data...), ecc...)
+			for _, i := range tt.errors {
+				codeword[i] ^= 0x5A
+			}
+			err := rsCorrect(codeword, len(ecc))
+			assert.Equal(t, err != nil, tt.wantErr)
+			if !tt.wantErr {
+				assert.Equal(t, string(codeword[:len(data)]), string(data))
+			}
+		})
+	}
+}
+
+func Test_parseQRData(t *testing.T) {
+	for _, text := range []string{"0123456789", "AC-42 $%*+./:", "rabbit", "うさぎ", "1"} {
+		t.Run(text, func(t *testing.T) {
+			version, data, err := qrData(text, QRLevelM)
+			assert.Equal(t, err, nil)
+			got, err := parseQRData(data, version)
+			assert.Equal(t, err, nil)
+			assert.Equal(t, got, text)
+		})
+	}
+}
+
+func Test_decodeLine(t *testing.T) {
+	// EAN-8 of 96385074 with the quiet zone
+	bits := "101"
+	for _, d := range "9638" {
+		bits += ean13Patterns[d-'0']
+	}
+	bits += "01010"
+	for _, d := range "5074" {
+		bits += invertBits(ean13Patterns[d-'0'])
+	}
+	bits += "101"
+	widths := runsOf("0000000" + bits + "0000000")
+	for i := range widths {
+		widths[i] *= 3
+	}
+	assert.Equal(t, decodeLine(widths), []lineCode{{codeType: CodeEAN8, text: "96385074", start: 21, end: 21 + 67*3}})
+}
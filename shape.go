@@ -0,0 +1,210 @@
+package imgedit
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+
+	"golang.org/x/image/vector"
+)
+
+// DefaultStrokeWidth used when stroke width is not specified in ShapeOptions
+const DefaultStrokeWidth = 1
+
+// ShapeOptions options for the drawing methods
+type ShapeOptions struct {
+	// Fill color inside of the shape, nil is not filled
+	Fill color.Color
+	// FillPaint image like the gradient or the pattern to fill inside of the shape instead of Fill
+	FillPaint image.Image
+	// Stroke color of the outline, nil is not stroked. default color.Black if the others are nil
+	Stroke color.Color
+	// StrokePaint image like the gradient or the pattern to stroke the outline instead of Stroke
+	StrokePaint image.Image
+	// StrokeWidth px, default 1
+	StrokeWidth float64
+	// Join default JoinRound, and JoinMiter for DrawRectangle
+	Join LineJoin
+	// Cap of the ends of the open lines and the dashes, default CapButt
+	Cap LineCap
+	// Dash lengths px of the dashes and the gaps in turn, nil is the solid line
+	Dash []float64
+	// DashOffset px to start the dash pattern
+	DashOffset float64
+}
+
+func (o *ShapeOptions) setDefault() {
+	if o.Fill == nil && o.FillPaint == nil && o.Stroke == nil && o.StrokePaint == nil {
+		o.Stroke = color.Black
+	}
+	if o.StrokeWidth == 0 {
+		o.StrokeWidth = DefaultStrokeWidth
+	}
+}
+
+// paintOf return the image of the paint, or the uniform image of the color, nil if both are nil
+func paintOf(p image.Image, c color.Color) image.Image {
+	if p != nil {
+		return p
+	}
+	if c != nil {
+		return image.NewUniform(c)
+	}
+	return nil
+}
+
+// Path the lines and the curves for DrawPath.
+// the coordinates are px from the left top corner of the image, the center of the pixel (x, y) is (x+0.5, y+0.5).
+type Path struct {
+	contours path
+	start    vec
+	// closed is true if the last contour is closed, the next segment starts the new contour
+	closed bool
+}
+
+// NewPath create empty path
+func NewPath() *Path {
+	return &Path{}
+}
+
+// MoveTo start the new contour at (x, y)
+func (p *Path) MoveTo(x, y float64) {
+	p.start, p.closed = vec{x, y}, false
+	p.contours = append(p.contours, contour{points: []vec{p.start}})
+}
+
+// LineTo add the straight line to (x, y)
+func (p *Path) LineTo(x, y float64) {
+	c := p.current()
+	c.points = append(c.points, vec{x, y})
+}
+
+// QuadTo add the quadratic bezier curve to (x, y) with the control point (cx, cy)
+func (p *Path) QuadTo(cx, cy, x, y float64) {
+	c := p.current()
+	c.points = flattenQuad(c.points, c.points[len(c.points)-1], vec{cx, cy}, vec{x, y})
+}
+
+// CubeTo add the cubic bezier curve to (x, y) with the control points (c1x, c1y) and (c2x, c2y)
+func (p *Path) CubeTo(c1x, c1y, c2x, c2y, x, y float64) {
+	c := p.current()
+	c.points = flattenCube(c.points, c.points[len(c.points)-1], vec{c1x, c1y}, vec{c2x, c2y}, vec{x, y})
+}
+
+// Close add the straight line to the start of the contour
+func (p *Path) Close() {
+	if len(p.contours) == 0 || p.closed {
+		return
+	}
+	p.contours[len(p.contours)-1].closed = true
+	p.closed = true
+}
+
+// current return the contour to add the segment, the segment after Close starts from the start of the closed contour
+func (p *Path) current() *contour {
+	if len(p.contours) == 0 || p.closed {
+		p.MoveTo(p.start.x, p.start.y)
+	}
+	return &p.contours[len(p.contours)-1]
+}
+
+// DrawLine draw the line from the center of the pixel to the center of the pixel
+func (c *converter) DrawLine(from, to image.Point, options *ShapeOptions) {
+	line := path{{points: []vec{pixelCenter(from), pixelCenter(to)}}}
+	c.drawShape(nil, line, options)
+}
+
+// DrawRectangle draw the rectangle with the corners rounded by radius px,
+// the stroke is drawn inside of the rectangle, and its corners are sharp if radius is 0 and Join is not set.
+func (c *converter) DrawRectangle(rect image.Rectangle, radius int, options *ShapeOptions) {
+	if options == nil {
+		options = &ShapeOptions{}
+	}
+	options.setDefault()
+	min, max := vec{float64(rect.Min.X), float64(rect.Min.Y)}, vec{float64(rect.Max.X), float64(rect.Max.Y)}
+	fill := path{roundedRect(min, max, float64(radius))}
+	// the center of the stroke is inset by the half width
+	inset := vec{options.StrokeWidth / 2, options.StrokeWidth / 2}
+	stroke := path{roundedRect(min.add(inset), max.sub(inset), float64(radius)-inset.x)}
+	sharp := *options
+	if sharp.Join == JoinDefault {
+		sharp.Join = JoinMiter
+	}
+	c.drawShape(fill, stroke, &sharp)
+}
+
+// DrawCircle draw the circle around the center of the pixel
+func (c *converter) DrawCircle(center image.Point, radius int, options *ShapeOptions) {
+	c.DrawEllipse(center, radius, radius, options)
+}
+
+// DrawEllipse draw the ellipse around the center of the pixel with the horizontal and vertical radius px
+func (c *converter) DrawEllipse(center image.Point, radiusX, radiusY int, options *ShapeOptions) {
+	e := path{ellipse(pixelCenter(center), float64(radiusX), float64(radiusY))}
+	c.drawShape(e, e, options)
+}
+
+// DrawPolygon draw the polygon through the centers of the pixels
+func (c *converter) DrawPolygon(points []image.Point, options *ShapeOptions) {
+	polygon := contour{closed: true}
+	for _, point := range points {
+		polygon.points = append(polygon.points, pixelCenter(point))
+	}
+	c.drawShape(path{polygon}, path{polygon}, options)
+}
+
+// DrawPath draw the path, the open contours are closed to be filled
+func (c *converter) DrawPath(p *Path, options *ShapeOptions) {
+	if p == nil {
+		return
+	}
+	c.drawShape(p.contours, p.contours, options)
+}
+
+// drawShape fill the fill path and stroke the stroke path on the image
+func (c *converter) drawShape(fill, stroke path, options *ShapeOptions) {
+	if options == nil {
+		options = &ShapeOptions{}
+	}
+	options.setDefault()
+
+	// copy base image
+	dst := image.NewRGBA(image.Rect(0, 0, c.Bounds().Dx(), c.Bounds().Dy()))
+	draw.Draw(dst, dst.Bounds(), c.Image, c.Bounds().Min, draw.Src)
+
+	if src := paintOf(options.FillPaint, options.Fill); src != nil && len(fill) > 0 {
+		rasterizer := vector.NewRasterizer(dst.Bounds().Dx(), dst.Bounds().Dy())
+		fill.fill(rasterizer)
+		rasterizer.Draw(dst, dst.Bounds(), src, image.Point{})
+	}
+	if src := paintOf(options.StrokePaint, options.Stroke); src != nil && len(stroke) > 0 {
+		if len(options.Dash) > 0 {
+			stroke = stroke.dash(options.Dash, options.DashOffset)
+			if options.Cap != CapRound {
+				// the dashes of length zero are drawn only as the round dots
+				stroke = removeDots(stroke)
+			}
+		}
+		rasterizer := vector.NewRasterizer(dst.Bounds().Dx(), dst.Bounds().Dy())
+		stroke.stroke(rasterizer, options.StrokeWidth, options.Join)
+		stroke.addCaps(rasterizer, options.StrokeWidth, options.Cap)
+		rasterizer.Draw(dst, dst.Bounds(), src, image.Point{})
+	}
+	c.Image = dst
+}
+
+// pixelCenter return the center of the pixel
+func pixelCenter(p image.Point) vec {
+	return vec{float64(p.X) + 0.5, float64(p.Y) + 0.5}
+}
+
+// removeDots remove the contours which are the single points
+func removeDots(p path) path {
+	var dst path
+	for _, c := range p {
+		if len(removeDuplicates(c.points, c.closed)) > 1 {
+			dst = append(dst, c)
+		}
+	}
+	return dst
+}
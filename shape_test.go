@@ -0,0 +1,141 @@
+package imgedit
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"testing"
+
+	"github.com/magiconair/properties/assert"
+)
+
+func Test_converter_DrawShapes(t *testing.T) {
+	red := color.RGBA{R: 255, A: 255}
+	white := color.RGBA{R: 255, G: 255, B: 255, A: 255}
+	black := color.RGBA{A: 255}
+	tests := []struct {
+		name string
+		draw func(c Converter)
+		// want is the color of the pixel
+		want map[image.Point]color.Color
+	}{
+		{
+			name: "line",
+			draw: func(c Converter) {
+				c.DrawLine(image.Point{X: 10, Y: 50}, image.Point{X: 89, Y: 50}, nil)
+			},
+			want: map[image.Point]color.Color{{X: 50, Y: 50}: black, {X: 50, Y: 49}: white, {X: 50, Y: 51}: white},
+		},
+		{
+			name: "dashed line",
+			draw: func(c Converter) {
+				c.DrawLine(image.Point{X: 10, Y: 50}, image.Point{X: 89, Y: 50}, &ShapeOptions{Stroke: red, StrokeWidth: 4, Dash: []float64{10, 10}})
+			},
+			want: map[image.Point]color.Color{{X: 15, Y: 50}: red, {X: 25, Y: 50}: white, {X: 35, Y: 51}: red},
+		},
+		{
+			name: "dotted line",
+			draw: func(c Converter) {
+				c.DrawLine(image.Point{X: 10, Y: 50}, image.Point{X: 89, Y: 50}, &ShapeOptions{StrokeWidth: 6, Cap: CapRound, Dash: []float64{0, 10}})
+			},
+			want: map[image.Point]color.Color{{X: 10, Y: 50}: black, {X: 15, Y: 50}: white, {X: 20, Y: 50}: black},
+		},
+		{
+			name: "filled rectangle",
+			draw: func(c Converter) {
+				c.DrawRectangle(image.Rect(20, 20, 80, 60), 0, &ShapeOptions{Fill: red})
+			},
+			want: map[image.Point]color.Color{{X: 20, Y: 20}: red, {X: 79, Y: 59}: red, {X: 19, Y: 20}: white, {X: 80, Y: 59}: white},
+		},
+		{
+			name: "stroke inside of the rectangle",
+			draw: func(c Converter) {
+				c.DrawRectangle(image.Rect(20, 20, 80, 60), 0, &ShapeOptions{StrokeWidth: 2})
+			},
+			want: map[image.Point]color.Color{{X: 20, Y: 20}: black, {X: 21, Y: 40}: black, {X: 22, Y: 40}: white, {X: 19, Y: 40}: white},
+		},
+		{
+			name: "rectangle with round join",
+			draw: func(c Converter) {
+				c.DrawRectangle(image.Rect(20, 20, 80, 60), 0, &ShapeOptions{StrokeWidth: 20, Join: JoinRound})
+			},
+			want: map[image.Point]color.Color{{X: 20, Y: 20}: white, {X: 20, Y: 40}: black, {X: 30, Y: 20}: black},
+		},
+		{
+			name: "rounded rectangle",
+			draw: func(c Converter) {
+				c.DrawRectangle(image.Rect(20, 20, 80, 60), 10, &ShapeOptions{Fill: red, Stroke: black})
+			},
+			want: map[image.Point]color.Color{{X: 20, Y: 20}: white, {X: 50, Y: 40}: red, {X: 50, Y: 20}: black},
+		},
+		{
+			name: "circle",
+			draw: func(c Converter) {
+				c.DrawCircle(image.Point{X: 50, Y: 50}, 30, &ShapeOptions{Fill: red})
+			},
+			want: map[image.Point]color.Color{{X: 50, Y: 50}: red, {X: 50, Y: 22}: red, {X: 50, Y: 18}: white, {X: 25, Y: 25}: white},
+		},
+		{
+			name: "ellipse",
+			draw: func(c Converter) {
+				c.DrawEllipse(image.Point{X: 50, Y: 50}, 40, 10, &ShapeOptions{Fill: red})
+			},
+			want: map[image.Point]color.Color{{X: 15, Y: 50}: red, {X: 50, Y: 35}: white},
+		},
+		{
+			name: "polygon",
+			draw: func(c Converter) {
+				c.DrawPolygon([]image.Point{{X: 10, Y: 90}, {X: 50, Y: 10}, {X: 90, Y: 90}}, &ShapeOptions{Fill: red, Stroke: black, StrokeWidth: 4, Join: JoinMiter})
+			},
+			want: map[image.Point]color.Color{{X: 50, Y: 60}: red, {X: 50, Y: 90}: black, {X: 15, Y: 20}: white},
+		},
+		{
+			name: "gradient fill",
+			draw: func(c Converter) {
+				c.DrawRectangle(image.Rect(0, 0, 100, 100), 0, &ShapeOptions{FillPaint: NewLinearGradient(image.Point{}, image.Point{X: 99}, EvenStops(red, black)), StrokePaint: image.White, StrokeWidth: 10})
+			},
+			want: map[image.Point]color.Color{{X: 5, Y: 5}: white, {X: 10, Y: 50}: color.RGBA{R: 230, A: 255}, {X: 89, Y: 50}: color.RGBA{R: 25, A: 255}},
+		},
+		{
+			name: "bezier path",
+			draw: func(c Converter) {
+				p := NewPath()
+				p.MoveTo(10, 90)
+				p.QuadTo(50, -10, 90, 90)
+				p.Close()
+				p.MoveTo(10, 5)
+				p.CubeTo(30, 5, 70, 5, 90, 5)
+				c.DrawPath(p, &ShapeOptions{Fill: red, Stroke: black, StrokeWidth: 2})
+			},
+			want: map[image.Point]color.Color{{X: 50, Y: 60}: red, {X: 50, Y: 4}: black, {X: 50, Y: 30}: white},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			img := image.NewRGBA(image.Rect(0, 0, 100, 100))
+			draw.Draw(img, img.Bounds(), image.White, image.Point{}, draw.Src)
+			c := NewConverter(img)
+			tt.draw(c)
+			dst := c.Convert()
+			for p, want := range tt.want {
+				assert.Equal(t, dst.At(p.X, p.Y), color.RGBAModel.Convert(want), p.String())
+			}
+			SaveTestImageAsPng(dst)
+		})
+	}
+}
+
+func TestPath(t *testing.T) {
+	p := NewPath()
+	p.LineTo(10, 0)
+	p.LineTo(10, 10)
+	p.Close()
+	p.Close()
+	p.LineTo(0, 10)
+	p.MoveTo(20, 20)
+	assert.Equal(t, p.contours, path{
+		{points: []vec{{0, 0}, {10, 0}, {10, 10}}, closed: true},
+		{points: []vec{{0, 0}, {0, 10}}},
+		{points: []vec{{20, 20}}},
+	})
+}
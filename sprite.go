@@ -0,0 +1,131 @@
+package imgedit
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"time"
+)
+
+// SpriteSheetOptions options for NewSpriteSheet and NewAnimationFromSpriteSheet
+type SpriteSheetOptions struct {
+	// Cols number of the frames in a row, default all frames in a row
+	Cols int
+	// Rows number of the frames in a column, default calculated from Cols
+	Rows int
+	// Layout of the frames same as TileWithOptions, default TileGrid
+	Layout TileLayout
+	// Gap px between the frames
+	Gap int
+	// GapColor default transparent
+	GapColor color.Color
+	// Count of the frames, default Cols * Rows
+	Count int
+	// Delay of the frames split from the sprite sheet, default 100ms
+	Delay time.Duration
+}
+
+func (o *SpriteSheetOptions) setDefault(count int) {
+	if o.Cols <= 0 && o.Rows <= 0 {
+		o.Cols = count
+	}
+	if o.Cols <= 0 {
+		o.Cols = (count + o.Rows - 1) / o.Rows
+	}
+	if o.Rows <= 0 {
+		o.Rows = (count + o.Cols - 1) / o.Cols
+	}
+	if o.Count <= 0 || o.Count > o.Cols*o.Rows {
+		o.Count = o.Cols * o.Rows
+	}
+	if o.GapColor == nil {
+		o.GapColor = color.Transparent
+	}
+	if o.Delay <= 0 {
+		o.Delay = DefaultFrameDelay
+	}
+}
+
+// shift return px of the shifted rows or columns by the layout
+func (o *SpriteSheetOptions) shift(pitch image.Point) image.Point {
+	switch {
+	case o.Layout == TileBrick && o.Rows > 1:
+		return image.Point{X: pitch.X / 2}
+	case o.Layout == TileHalfDrop && o.Cols > 1:
+		return image.Point{Y: pitch.Y / 2}
+	}
+	return image.Point{}
+}
+
+// NewSpriteSheet create converter with the frames laid out from the left top in the order of the frames,
+// the frames are placed same as the tiles of TileWithOptions.
+func NewSpriteSheet(frames []Frame, options *SpriteSheetOptions) Converter {
+	if options == nil {
+		options = &SpriteSheetOptions{}
+	}
+	if len(frames) == 0 {
+		return &converter{image.NewRGBA(image.Rect(0, 0, 0, 0))}
+	}
+	options.setDefault(len(frames))
+
+	frameSize := frames[0].Image.Bounds().Size()
+	pitch := frameSize.Add(image.Point{X: options.Gap, Y: options.Gap})
+	size := image.Point{X: options.Cols*pitch.X - options.Gap, Y: options.Rows*pitch.Y - options.Gap}.Add(options.shift(pitch))
+	dst := image.NewRGBA(image.Rectangle{Max: size})
+	draw.Draw(dst, dst.Bounds(), image.NewUniform(options.GapColor), image.Point{}, draw.Src)
+	for i, frame := range frames {
+		if i >= options.Count {
+			break
+		}
+		point := tilePoint(i%options.Cols, i/options.Cols, pitch, options.Layout)
+		draw.Draw(dst, image.Rectangle{Min: point, Max: point.Add(frameSize)}, frame.Image, frame.Image.Bounds().Min, draw.Src)
+	}
+	return &converter{dst}
+}
+
+// NewAnimationFromSpriteSheet create AnimationConverter of the frames split from the sprite sheet laid out same as NewSpriteSheet,
+// the frames are square in a row if both Cols and Rows are not set, and 1 is used for the other if one is not set.
+func NewAnimationFromSpriteSheet(sheet image.Image, options *SpriteSheetOptions) AnimationConverter {
+	if options == nil {
+		options = &SpriteSheetOptions{}
+	}
+	size := sheet.Bounds().Size()
+	if options.Cols <= 0 && options.Rows <= 0 && size.Y > 0 {
+		options.Rows = 1
+		options.Cols = (size.X + options.Gap) / (size.Y + options.Gap)
+	}
+	if options.Cols <= 0 {
+		options.Cols = 1
+	}
+	if options.Rows <= 0 {
+		options.Rows = 1
+	}
+	options.setDefault(options.Cols * options.Rows)
+
+	pitch := image.Point{
+		X: spritePitch(size.X+options.Gap, options.Cols, options.Layout == TileBrick && options.Rows > 1),
+		Y: spritePitch(size.Y+options.Gap, options.Rows, options.Layout == TileHalfDrop && options.Cols > 1),
+	}
+	frameSize := pitch.Sub(image.Point{X: options.Gap, Y: options.Gap})
+	var frames []Frame
+	for i := 0; i < options.Count; i++ {
+		point := tilePoint(i%options.Cols, i/options.Cols, pitch, options.Layout).Add(sheet.Bounds().Min)
+		dst := image.NewRGBA(image.Rectangle{Max: frameSize})
+		draw.Draw(dst, dst.Bounds(), sheet, point, draw.Src)
+		frames = append(frames, Frame{Image: dst, Delay: options.Delay})
+	}
+	return NewAnimationConverter(frames, 0)
+}
+
+// spritePitch return the pitch px of n frames in the length, the length is n * pitch, and plus pitch / 2 if shifted
+func spritePitch(length, n int, shifted bool) int {
+	if !shifted {
+		return length / n
+	}
+	for pitch := length / n; pitch > 0; pitch-- {
+		if n*pitch+pitch/2 <= length {
+			return pitch
+		}
+	}
+	return 0
+}
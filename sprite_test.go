@@ -0,0 +1,73 @@
+package imgedit
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/magiconair/properties/assert"
+)
+
+func TestNewSpriteSheet(t *testing.T) {
+	type args struct {
+		options *SpriteSheetOptions
+	}
+	tests := []struct {
+		name     string
+		args     args
+		wantSize image.Point
+	}{
+		{
+			name:     "in a row",
+			args:     args{options: nil},
+			wantSize: image.Point{X: 120, Y: 30},
+		},
+		{
+			name:     "grid with gap",
+			args:     args{options: &SpriteSheetOptions{Cols: 2, Gap: 2}},
+			wantSize: image.Point{X: 82, Y: 62},
+		},
+		{
+			name:     "brick",
+			args:     args{options: &SpriteSheetOptions{Rows: 2, Layout: TileBrick}},
+			wantSize: image.Point{X: 100, Y: 60},
+		},
+		{
+			name:     "half drop",
+			args:     args{options: &SpriteSheetOptions{Cols: 2, Layout: TileHalfDrop, Gap: 3}},
+			wantSize: image.Point{X: 83, Y: 79},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			frames := getTestFrames()
+			sheet := NewSpriteSheet(frames, tt.args.options).Convert()
+			SaveTestImageAsPng(sheet)
+			assert.Equal(t, sheet.Bounds().Size(), tt.wantSize)
+
+			// split the sprite sheet back into the frames
+			options := &SpriteSheetOptions{Cols: len(frames)}
+			if tt.args.options != nil {
+				options = tt.args.options
+				options.Count = len(frames)
+			}
+			a := NewAnimationFromSpriteSheet(sheet, options)
+			assert.Equal(t, len(a.Frames()), len(frames))
+			for i, frame := range a.Frames() {
+				assert.Equal(t, frame.Image.(*image.RGBA).Pix, frames[i].Image.(*image.RGBA).Pix)
+				assert.Equal(t, frame.Delay, DefaultFrameDelay)
+			}
+		})
+	}
+}
+
+func TestNewAnimationFromSpriteSheet(t *testing.T) {
+	sheet := image.NewRGBA(image.Rect(0, 0, 64, 16))
+	sheet.Set(20, 3, color.White)
+	a := NewAnimationFromSpriteSheet(sheet, nil)
+	frames := a.Frames()
+	// the frames are square in a row by default
+	assert.Equal(t, len(frames), 4)
+	assert.Equal(t, frames[1].Image.Bounds(), image.Rect(0, 0, 16, 16))
+	assert.Equal(t, color.RGBAModel.Convert(frames[1].Image.At(4, 3)), color.RGBA{R: 255, G: 255, B: 255, A: 255})
+}
@@ -0,0 +1,408 @@
+package imgedit
+
+import (
+	"image"
+	"math"
+
+	"golang.org/x/image/vector"
+)
+
+// LineJoin how to join the segments of the stroke
+type LineJoin int
+
+const (
+	// JoinDefault is JoinRound, or JoinMiter for the rectangle
+	JoinDefault LineJoin = iota
+	// JoinRound join the segments with the arc
+	JoinRound
+	// JoinMiter join the segments with the sharp corner, long corners are beveled
+	JoinMiter
+	// JoinBevel join the segments with the cut off corner
+	JoinBevel
+)
+
+// LineCap how to end the open lines of the stroke
+type LineCap int
+
+const (
+	// CapButt end the lines at the end points
+	CapButt LineCap = iota
+	// CapRound end the lines with the half circles
+	CapRound
+	// CapSquare end the lines with the half squares beyond the end points
+	CapSquare
+)
+
+// miterLimit the ratio of the miter length to the stroke width to switch to JoinBevel
+const miterLimit = 4
+
+// vec is the point in float
+type vec struct {
+	x, y float64
+}
+
+func (v vec) add(u vec) vec {
+	return vec{v.x + u.x, v.y + u.y}
+}
+
+func (v vec) sub(u vec) vec {
+	return vec{v.x - u.x, v.y - u.y}
+}
+
+func (v vec) mul(k float64) vec {
+	return vec{v.x * k, v.y * k}
+}
+
+func (v vec) dot(u vec) float64 {
+	return v.x*u.x + v.y*u.y
+}
+
+func (v vec) cross(u vec) float64 {
+	return v.x*u.y - v.y*u.x
+}
+
+func (v vec) length() float64 {
+	return math.Hypot(v.x, v.y)
+}
+
+// rotate turn v clockwise around center
+func (v vec) rotate(center vec, angle float64) vec {
+	sin, cos := math.Sincos(angle)
+	d := v.sub(center)
+	return vec{center.x + d.x*cos - d.y*sin, center.y + d.x*sin + d.y*cos}
+}
+
+// normal return the left side unit normal of v
+func (v vec) normal() vec {
+	l := v.length()
+	if l == 0 {
+		return vec{}
+	}
+	return vec{-v.y / l, v.x / l}
+}
+
+// contour is the flattened polyline
+type contour struct {
+	points []vec
+	closed bool
+}
+
+// path is the set of contours
+type path []contour
+
+// flattenQuad append the quadratic bezier curve from p0 to p2 as the polyline
+func flattenQuad(points []vec, p0, p1, p2 vec) []vec {
+	n := curveSegments(p0.sub(p1).length() + p1.sub(p2).length())
+	for i := 1; i <= n; i++ {
+		t := float64(i) / float64(n)
+		u := 1 - t
+		points = append(points, p0.mul(u*u).add(p1.mul(2*u*t)).add(p2.mul(t*t)))
+	}
+	return points
+}
+
+// flattenCube append the cubic bezier curve from p0 to p3 as the polyline
+func flattenCube(points []vec, p0, p1, p2, p3 vec) []vec {
+	n := curveSegments(p0.sub(p1).length() + p1.sub(p2).length() + p2.sub(p3).length())
+	for i := 1; i <= n; i++ {
+		t := float64(i) / float64(n)
+		u := 1 - t
+		points = append(points, p0.mul(u*u*u).add(p1.mul(3*u*u*t)).add(p2.mul(3*u*t*t)).add(p3.mul(t*t*t)))
+	}
+	return points
+}
+
+// curveSegments return the number of segments for the curve of the length
+func curveSegments(length float64) int {
+	n := int(math.Ceil(math.Sqrt(length) * 2))
+	if n < 1 {
+		return 1
+	}
+	if n > 100 {
+		return 100
+	}
+	return n
+}
+
+// rotate return the path turned clockwise around center
+func (p path) rotate(center vec, angle float64) path {
+	dst := make(path, len(p))
+	for i, c := range p {
+		points := make([]vec, len(c.points))
+		for j, point := range c.points {
+			points[j] = point.rotate(center, angle)
+		}
+		dst[i] = contour{points: points, closed: c.closed}
+	}
+	return dst
+}
+
+// translate return the path moved by d
+func (p path) translate(d vec) path {
+	dst := make(path, len(p))
+	for i, c := range p {
+		points := make([]vec, len(c.points))
+		for j, point := range c.points {
+			points[j] = point.add(d)
+		}
+		dst[i] = contour{points: points, closed: c.closed}
+	}
+	return dst
+}
+
+// bounds return the smallest rectangle containing the path
+func (p path) bounds() image.Rectangle {
+	var rect image.Rectangle
+	for _, c := range p {
+		for _, point := range c.points {
+			r := image.Rect(int(math.Floor(point.x)), int(math.Floor(point.y)), int(math.Ceil(point.x))+1, int(math.Ceil(point.y))+1)
+			rect = rect.Union(r)
+		}
+	}
+	return rect
+}
+
+// fill add the closed contours of the path to the rasterizer as they are,
+// the holes are made by the contours in the opposite direction.
+func (p path) fill(r *vector.Rasterizer) {
+	for _, c := range p {
+		if len(c.points) < 3 {
+			continue
+		}
+		r.MoveTo(float32(c.points[0].x), float32(c.points[0].y))
+		for _, point := range c.points[1:] {
+			r.LineTo(float32(point.x), float32(point.y))
+		}
+		r.ClosePath()
+	}
+}
+
+// stroke add the outline of the path with the width to the rasterizer.
+// the stroke is the union of polygons for each segment and join,
+// they are all added in the same direction not to cancel each other.
+func (p path) stroke(r *vector.Rasterizer, width float64, join LineJoin) {
+	halfWidth := width / 2
+	if halfWidth <= 0 {
+		return
+	}
+	for _, c := range p {
+		points := removeDuplicates(c.points, c.closed)
+		if len(points) == 1 {
+			addPolygon(r, circlePolygon(points[0], halfWidth))
+			continue
+		}
+		segments := len(points) - 1
+		if c.closed {
+			segments++
+		}
+		for i := 0; i < segments; i++ {
+			a, b := points[i], points[(i+1)%len(points)]
+			n := b.sub(a).normal().mul(halfWidth)
+			addPolygon(r, []vec{a.add(n), b.add(n), b.sub(n), a.sub(n)})
+		}
+		for i := 0; i < len(points); i++ {
+			if !c.closed && (i == 0 || i == len(points)-1) {
+				continue
+			}
+			prev, next := points[(i-1+len(points))%len(points)], points[(i+1)%len(points)]
+			addJoin(r, prev, points[i], next, halfWidth, join)
+		}
+	}
+}
+
+// addCaps add the caps at the ends of the open contours
+func (p path) addCaps(r *vector.Rasterizer, width float64, lineCap LineCap) {
+	halfWidth := width / 2
+	if halfWidth <= 0 || lineCap == CapButt {
+		return
+	}
+	for _, c := range p {
+		points := removeDuplicates(c.points, c.closed)
+		if c.closed || len(points) < 2 {
+			continue
+		}
+		ends := [][2]vec{{points[0], points[1]}, {points[len(points)-1], points[len(points)-2]}}
+		for _, end := range ends {
+			v, inner := end[0], end[1]
+			if lineCap == CapRound {
+				addPolygon(r, circlePolygon(v, halfWidth))
+				continue
+			}
+			out := v.sub(inner).mul(halfWidth / v.sub(inner).length())
+			n := out.normal().mul(halfWidth)
+			addPolygon(r, []vec{v.add(n), v.add(n).add(out), v.sub(n).add(out), v.sub(n)})
+		}
+	}
+}
+
+// dash split the contours into the dashes, pattern is the lengths of the dashes and the gaps in turn.
+// the pattern starts from offset at each contour, the pattern of the odd length is repeated twice.
+func (p path) dash(pattern []float64, offset float64) path {
+	var total float64
+	for _, length := range pattern {
+		if length < 0 {
+			return p
+		}
+		total += length
+	}
+	if total <= 0 {
+		return p
+	}
+	if len(pattern)%2 == 1 {
+		pattern = append(pattern[:len(pattern):len(pattern)], pattern...)
+		total *= 2
+	}
+
+	var dashes path
+	for _, c := range p {
+		points := c.points
+		if c.closed && len(points) > 1 {
+			points = append(points[:len(points):len(points)], points[0])
+		}
+		if len(points) == 0 {
+			continue
+		}
+		// find the position in the pattern at the start of the contour
+		i, remaining := 0, math.Mod(offset, total)
+		if remaining < 0 {
+			remaining += total
+		}
+		// the dash of length zero at the start is kept to be drawn as the dot
+		for remaining > pattern[i] || (remaining == pattern[i] && pattern[i] > 0) {
+			remaining -= pattern[i]
+			i = (i + 1) % len(pattern)
+		}
+		remaining = pattern[i] - remaining
+
+		var current []vec
+		if i%2 == 0 {
+			current = []vec{points[0]}
+		}
+		for j := 1; j < len(points); j++ {
+			a, b := points[j-1], points[j]
+			length, t := b.sub(a).length(), 0.0
+			for length-t > remaining {
+				t += remaining
+				v := a.add(b.sub(a).mul(t / length))
+				if i%2 == 0 {
+					dashes = append(dashes, contour{points: append(current, v)})
+					current = nil
+				} else {
+					current = []vec{v}
+				}
+				i = (i + 1) % len(pattern)
+				remaining = pattern[i]
+			}
+			remaining -= length - t
+			if i%2 == 0 {
+				current = append(current, b)
+			}
+		}
+		if len(current) > 0 {
+			dashes = append(dashes, contour{points: current})
+		}
+	}
+	return dashes
+}
+
+// addJoin add the polygon to fill the gap of the segments at v
+func addJoin(r *vector.Rasterizer, prev, v, next vec, halfWidth float64, join LineJoin) {
+	d1, d2 := v.sub(prev), next.sub(v)
+	if join == JoinDefault || join == JoinRound {
+		addPolygon(r, circlePolygon(v, halfWidth))
+		return
+	}
+	// the outer side is opposite to the turning direction
+	side := 1.0
+	if d1.cross(d2) > 0 {
+		side = -1
+	}
+	p1, p2 := v.add(d1.normal().mul(halfWidth*side)), v.add(d2.normal().mul(halfWidth*side))
+	if join == JoinMiter {
+		// the tip is on the bisector of the offset points
+		mid := p1.add(p2).mul(0.5)
+		toMid := mid.sub(v)
+		if l := toMid.length(); l > 0 {
+			miterLength := halfWidth * halfWidth / l
+			if miterLength <= halfWidth*miterLimit {
+				addPolygon(r, []vec{v, p1, v.add(toMid.mul(miterLength / l)), p2})
+				return
+			}
+		}
+	}
+	addPolygon(r, []vec{v, p1, p2})
+}
+
+// roundedRect return the rectangle from min to max with the rounded corners
+func roundedRect(min, max vec, radius float64) contour {
+	radius = math.Max(0, math.Min(radius, math.Min(max.x-min.x, max.y-min.y)/2))
+	if radius == 0 {
+		return contour{points: []vec{min, {max.x, min.y}, max, {min.x, max.y}}, closed: true}
+	}
+	// the center of the corner arcs from the right top in the clockwise order
+	centers := []vec{{max.x - radius, min.y + radius}, {max.x - radius, max.y - radius}, {min.x + radius, max.y - radius}, {min.x + radius, min.y + radius}}
+	n := curveSegments(radius * math.Pi / 2)
+	var points []vec
+	for i, center := range centers {
+		for j := 0; j <= n; j++ {
+			theta := math.Pi/2*float64(i-1) + math.Pi/2*float64(j)/float64(n)
+			points = append(points, vec{center.x + radius*math.Cos(theta), center.y + radius*math.Sin(theta)})
+		}
+	}
+	return contour{points: points, closed: true}
+}
+
+// ellipse return the ellipse with the horizontal and vertical radius
+func ellipse(center vec, radiusX, radiusY float64) contour {
+	n := curveSegments(math.Max(radiusX, radiusY)*2*math.Pi) * 2
+	points := make([]vec, n)
+	for i := range points {
+		theta := 2 * math.Pi * float64(i) / float64(n)
+		points[i] = vec{center.x + radiusX*math.Cos(theta), center.y + radiusY*math.Sin(theta)}
+	}
+	return contour{points: points, closed: true}
+}
+
+// circlePolygon return the polygon approximating the circle
+func circlePolygon(center vec, radius float64) []vec {
+	return ellipse(center, radius, radius).points
+}
+
+// addPolygon add the polygon to the rasterizer in the clockwise direction
+func addPolygon(r *vector.Rasterizer, points []vec) {
+	var area float64
+	for i := range points {
+		area += points[i].cross(points[(i+1)%len(points)])
+	}
+	if area == 0 {
+		return
+	}
+	if area < 0 {
+		reversed := make([]vec, len(points))
+		for i, point := range points {
+			reversed[len(points)-1-i] = point
+		}
+		points = reversed
+	}
+	r.MoveTo(float32(points[0].x), float32(points[0].y))
+	for _, point := range points[1:] {
+		r.LineTo(float32(point.x), float32(point.y))
+	}
+	r.ClosePath()
+}
+
+// removeDuplicates remove the consecutive same points
+func removeDuplicates(points []vec, closed bool) []vec {
+	var dst []vec
+	for i, point := range points {
+		if i > 0 && point == dst[len(dst)-1] {
+			continue
+		}
+		dst = append(dst, point)
+	}
+	// the closed contour may end at the start point
+	if closed && len(dst) > 1 && dst[0] == dst[len(dst)-1] {
+		dst = dst[:len(dst)-1]
+	}
+	return dst
+}
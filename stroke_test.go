@@ -0,0 +1,249 @@
+package imgedit
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"testing"
+
+	"github.com/magiconair/properties/assert"
+	"golang.org/x/image/vector"
+)
+
+func Test_path_stroke(t *testing.T) {
+	type args struct {
+		width float64
+		join  LineJoin
+	}
+	tests := []struct {
+		name string
+		p    path
+		args args
+		// inside and outside points of the stroke
+		inside  []image.Point
+		outside []image.Point
+	}{
+		{
+			name:    "line",
+			p:       path{{points: []vec{{10, 50}, {90, 50}}}},
+			args:    args{width: 10},
+			inside:  []image.Point{{10, 50}, {50, 46}, {89, 54}},
+			outside: []image.Point{{50, 40}, {50, 60}, {3, 50}},
+		},
+		{
+			name:    "closed square with miter",
+			p:       path{{points: []vec{{20, 20}, {80, 20}, {80, 80}, {20, 80}}, closed: true}},
+			args:    args{width: 10, join: JoinMiter},
+			inside:  []image.Point{{16, 16}, {83, 83}, {50, 20}},
+			outside: []image.Point{{50, 50}, {10, 10}},
+		},
+		{
+			name:    "closed square with bevel",
+			p:       path{{points: []vec{{20, 20}, {80, 20}, {80, 80}, {20, 80}}, closed: true}},
+			args:    args{width: 10, join: JoinBevel},
+			inside:  []image.Point{{18, 18}, {50, 80}},
+			outside: []image.Point{{50, 50}, {15, 15}},
+		},
+		{
+			name:    "closed square with round",
+			p:       path{{points: []vec{{20, 20}, {80, 20}, {80, 80}, {20, 80}}, closed: true}},
+			args:    args{width: 10, join: JoinRound},
+			inside:  []image.Point{{17, 17}, {20, 50}},
+			outside: []image.Point{{50, 50}, {15, 15}},
+		},
+		{
+			name:    "point",
+			p:       path{{points: []vec{{50, 50}}}},
+			args:    args{width: 10},
+			inside:  []image.Point{{50, 50}},
+			outside: []image.Point{{50, 40}},
+		},
+		{
+			name:    "zero width",
+			p:       path{{points: []vec{{10, 50}, {90, 50}}}},
+			args:    args{width: 0},
+			outside: []image.Point{{50, 50}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dst := image.NewAlpha(image.Rect(0, 0, 100, 100))
+			r := vector.NewRasterizer(100, 100)
+			tt.p.stroke(r, tt.args.width, tt.args.join)
+			r.Draw(dst, dst.Bounds(), image.Opaque, image.Point{})
+			for _, p := range tt.inside {
+				assert.Equal(t, dst.At(p.X, p.Y), color.Alpha{A: 0xff}, p.String())
+			}
+			for _, p := range tt.outside {
+				assert.Equal(t, dst.At(p.X, p.Y), color.Alpha{}, p.String())
+			}
+		})
+	}
+}
+
+func Test_path_fill(t *testing.T) {
+	tests := []struct {
+		name string
+		p    path
+		// inside and outside points of the fill
+		inside  []image.Point
+		outside []image.Point
+	}{
+		{
+			name:    "square",
+			p:       path{{points: []vec{{20, 20}, {80, 20}, {80, 80}, {20, 80}}, closed: true}},
+			inside:  []image.Point{{20, 20}, {50, 50}, {79, 79}},
+			outside: []image.Point{{10, 10}, {80, 80}},
+		},
+		{
+			name: "square with hole",
+			p: path{
+				{points: []vec{{20, 20}, {80, 20}, {80, 80}, {20, 80}}, closed: true},
+				{points: []vec{{40, 40}, {40, 60}, {60, 60}, {60, 40}}, closed: true},
+			},
+			inside:  []image.Point{{30, 30}, {70, 70}},
+			outside: []image.Point{{50, 50}},
+		},
+		{
+			name:    "rounded rect",
+			p:       path{roundedRect(vec{20, 20}, vec{80, 80}, 20)},
+			inside:  []image.Point{{50, 20}, {20, 50}, {30, 30}},
+			outside: []image.Point{{21, 21}, {78, 78}},
+		},
+		{
+			name:    "rotated square",
+			p:       path{{points: []vec{{30, 30}, {70, 30}, {70, 70}, {30, 70}}, closed: true}}.rotate(vec{50, 50}, math.Pi/4),
+			inside:  []image.Point{{50, 25}, {75, 50}},
+			outside: []image.Point{{31, 31}, {69, 69}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dst := image.NewAlpha(image.Rect(0, 0, 100, 100))
+			r := vector.NewRasterizer(100, 100)
+			tt.p.fill(r)
+			r.Draw(dst, dst.Bounds(), image.Opaque, image.Point{})
+			for _, p := range tt.inside {
+				assert.Equal(t, dst.At(p.X, p.Y), color.Alpha{A: 0xff}, p.String())
+			}
+			for _, p := range tt.outside {
+				assert.Equal(t, dst.At(p.X, p.Y), color.Alpha{}, p.String())
+			}
+		})
+	}
+}
+
+func Test_path_bounds(t *testing.T) {
+	p := path{roundedRect(vec{20.5, 10}, vec{80, 90.5}, 10)}
+	assert.Equal(t, p.bounds(), image.Rect(20, 10, 81, 92))
+	assert.Equal(t, path{}.bounds(), image.Rectangle{})
+}
+
+func Test_flattenCube(t *testing.T) {
+	points := flattenCube([]vec{{0, 0}}, vec{0, 0}, vec{0, 100}, vec{100, 100}, vec{100, 0})
+	assert.Equal(t, points[len(points)-1], vec{100, 0})
+	for _, p := range points {
+		// the curve is in the convex hull of the control points, and the peak is y = 75
+		if p.x < 0 || p.x > 100 || p.y < 0 || p.y > 75.0001 {
+			t.Errorf("flattenCube() point %v is out of the curve", p)
+		}
+	}
+}
+
+func Test_path_dash(t *testing.T) {
+	line := path{{points: []vec{{0, 0}, {10, 0}}}}
+	type args struct {
+		pattern []float64
+		offset  float64
+	}
+	tests := []struct {
+		name string
+		p    path
+		args args
+		// want is the x of the start and the end of each dash
+		want [][2]float64
+	}{
+		{
+			name: "dash and gap",
+			p:    line,
+			args: args{pattern: []float64{3, 2}},
+			want: [][2]float64{{0, 3}, {5, 8}},
+		},
+		{
+			name: "offset",
+			p:    line,
+			args: args{pattern: []float64{3, 2}, offset: 1},
+			want: [][2]float64{{0, 2}, {4, 7}, {9, 10}},
+		},
+		{
+			name: "odd pattern is repeated",
+			p:    line,
+			args: args{pattern: []float64{2}},
+			want: [][2]float64{{0, 2}, {4, 6}, {8, 10}},
+		},
+		{
+			name: "across the corner",
+			p:    path{{points: []vec{{0, 0}, {4, 0}, {4, 10}}}},
+			args: args{pattern: []float64{6, 10}},
+			want: [][2]float64{{0, 4}},
+		},
+		{
+			name: "invalid pattern is solid",
+			p:    line,
+			args: args{pattern: []float64{0, 0}},
+			want: [][2]float64{{0, 10}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got [][2]float64
+			for _, c := range tt.p.dash(tt.args.pattern, tt.args.offset) {
+				got = append(got, [2]float64{c.points[0].x, c.points[len(c.points)-1].x})
+			}
+			assert.Equal(t, got, tt.want)
+		})
+	}
+}
+
+func Test_path_addCaps(t *testing.T) {
+	line := path{{points: []vec{{20, 50}, {80, 50}}}}
+	tests := []struct {
+		name    string
+		cap     LineCap
+		inside  []image.Point
+		outside []image.Point
+	}{
+		{
+			name:    "butt",
+			cap:     CapButt,
+			outside: []image.Point{{17, 50}, {82, 50}},
+		},
+		{
+			name:    "round",
+			cap:     CapRound,
+			inside:  []image.Point{{17, 50}, {82, 50}},
+			outside: []image.Point{{15, 45}},
+		},
+		{
+			name:    "square",
+			cap:     CapSquare,
+			inside:  []image.Point{{16, 46}, {83, 53}},
+			outside: []image.Point{{14, 50}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dst := image.NewAlpha(image.Rect(0, 0, 100, 100))
+			r := vector.NewRasterizer(100, 100)
+			line.stroke(r, 10, JoinRound)
+			line.addCaps(r, 10, tt.cap)
+			r.Draw(dst, dst.Bounds(), image.Opaque, image.Point{})
+			for _, p := range tt.inside {
+				assert.Equal(t, dst.At(p.X, p.Y), color.Alpha{A: 0xff}, p.String())
+			}
+			for _, p := range tt.outside {
+				assert.Equal(t, dst.At(p.X, p.Y), color.Alpha{}, p.String())
+			}
+		})
+	}
+}
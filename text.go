@@ -0,0 +1,1071 @@
+package imgedit
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+	"strings"
+	"unicode"
+
+	"github.com/golang/freetype/truetype"
+	"golang.org/x/image/font"
+	"golang.org/x/image/math/fixed"
+	"golang.org/x/image/vector"
+)
+
+// minShrinkFontSize is the lower limit of the font size for ShrinkToFit
+const minShrinkFontSize = 1
+
+const (
+	// verticalRotated letters turned sideways in the vertical writing
+	verticalRotated = "ー－―‐-–—~〜～…‥()（）[]［］{}｛｝<>＜＞〈〉《》「」『』【】〔〕=＝"
+	// verticalShifted punctuations moved to the right top in the vertical writing
+	verticalShifted = "、。，．"
+)
+
+// Align horizontal alignment of the lines
+type Align int
+
+const (
+	// AlignCenter align the lines to the center
+	AlignCenter Align = iota
+	// AlignLeft align the lines to the left
+	AlignLeft
+	// AlignRight align the lines to the right
+	AlignRight
+)
+
+// Anchor the point of the text placed at StringOptions.Point
+type Anchor int
+
+const (
+	// AnchorCenter the center of the text
+	AnchorCenter Anchor = iota
+	// AnchorTopLeft the left top of the text
+	AnchorTopLeft
+	// AnchorTop the center top of the text
+	AnchorTop
+	// AnchorTopRight the right top of the text
+	AnchorTopRight
+	// AnchorLeft the left center of the text
+	AnchorLeft
+	// AnchorRight the right center of the text
+	AnchorRight
+	// AnchorBottomLeft the left bottom of the text
+	AnchorBottomLeft
+	// AnchorBottom the center bottom of the text
+	AnchorBottom
+	// AnchorBottomRight the right bottom of the text
+	AnchorBottomRight
+	// AnchorBaselineLeft the left end of the first baseline
+	AnchorBaselineLeft
+	// AnchorBaseline the center of the first baseline
+	AnchorBaseline
+	// AnchorBaselineRight the right end of the first baseline
+	AnchorBaselineRight
+)
+
+// StringOptions options for AddString
+type StringOptions struct {
+	// Point left top = (0px, 0px), default center
+	Point *image.Point
+	// Font
+	Font *Font
+	// Outline
+	Outline *Outline
+	// Align default AlignCenter
+	Align Align
+	// Anchor default AnchorCenter
+	Anchor Anchor
+	// LineSpacing ratio to the line height, default 1
+	LineSpacing float64
+	// LetterSpacing px added between the letters
+	LetterSpacing float64
+	// MaxWidth px to wrap the lines, 0 is not wrapped
+	MaxWidth int
+	// Box lay out the text inside of the box with Anchor, the text is wrapped at the box width.
+	// if Box is set, Point and MaxWidth are ignored.
+	Box *image.Rectangle
+	// ShrinkToFit reduce the font size until the text fits in Box,
+	// or until each line fits in MaxWidth without wrapping if Box is nil.
+	ShrinkToFit bool
+	// Rotation degrees clockwise around the anchor point
+	Rotation float64
+	// Vertical write the lines from top to bottom, and the lines are placed from right to left.
+	// MaxWidth is the max height of the lines, AlignLeft and AlignRight align the lines to the top and the bottom.
+	Vertical bool
+	// Arc lay out the lines along the circle, Align, Anchor, Box and Vertical are ignored
+	Arc *TextArc
+	// Shadow of the letters and the outline
+	Shadow *TextShadow
+	// Background rectangle behind the text
+	Background *TextBackground
+	// Gradient fill the letters with the colors instead of Font.Color
+	Gradient *TextGradient
+	// Pattern fill the letters with the image repeated from the left top of the text instead of Font.Color
+	Pattern image.Image
+	// Paint fill the letters with the image like NewRadialGradient in the coordinates of the image instead of Font.Color,
+	// Paint is used before Pattern and Gradient
+	Paint image.Image
+	// Opacity of the whole text with the effects, 0 < Opacity <= 1, default 1
+	Opacity float64
+	// Markup style the spans of the text with the tags <b>, <color=#FF0000>, <size=40> and <font=name>,
+	// use &lt;, &gt; and &amp; to write <, > and &.
+	Markup bool
+}
+
+// TextArc the circle for the text
+type TextArc struct {
+	// Radius px from the center at Point to the baseline
+	Radius float64
+	// Angle degrees clockwise from the top of the circle to the center of the text,
+	// from the bottom if Reverse is set.
+	Angle float64
+	// Reverse write the text counterclockwise with the letters upright at the bottom of the circle
+	Reverse bool
+}
+
+// Font used in the options
+type Font struct {
+	// TrueTypeFont use ReadTtf to get font
+	TrueTypeFont *truetype.Font
+	// Typeface TrueType or OpenType font, use ReadTypeface or FontRegistry to get font. if Typeface is set, TrueTypeFont is ignored
+	Typeface *Typeface
+	// Name of the font in DefaultFontRegistry used if Typeface and TrueTypeFont are nil, default FontLogoTypeGothic
+	Name string
+	// Fallbacks used in order for the letters missing from the font, before the fallbacks registered in DefaultFontRegistry
+	Fallbacks []*Typeface
+	// Size default 100
+	Size float64
+	// Color default color.Black
+	Color color.Color
+}
+
+// Outline used in the options
+type Outline struct {
+	// Color default color.White
+	Color color.Color
+	// Width from font. 0 <= Width <= 200 recommended
+	Width int
+	// Join default JoinRound
+	Join LineJoin
+}
+
+func (o *StringOptions) setDefault() {
+	// font
+	if o.Font == nil {
+		o.Font = &Font{}
+	}
+	if o.Font.Typeface == nil {
+		o.Font.Typeface = o.Font.typeface()
+	}
+	if o.Font.Size == 0 {
+		o.Font.Size = DefaultFontSize
+	}
+	if o.Font.Color == nil {
+		o.Font.Color = color.Black
+	}
+
+	// outLine
+	if o.Outline != nil {
+		if o.Outline.Color == nil {
+			o.Outline.Color = color.White
+		}
+		if o.Outline.Width == 0 {
+			o.Outline.Width = DefaultOutlineWidth
+		}
+	}
+
+	// layout
+	if o.LineSpacing == 0 {
+		o.LineSpacing = 1
+	}
+
+	// effect
+	if o.Shadow != nil && o.Shadow.Color == nil {
+		o.Shadow.Color = color.Black
+	}
+	if o.Background != nil && o.Background.Color == nil {
+		o.Background.Color = color.White
+	}
+	if o.Opacity <= 0 || o.Opacity > 1 {
+		o.Opacity = 1
+	}
+}
+
+func (o *StringOptions) face() font.Face {
+	return o.Font.Typeface.face(o.Font.Size)
+}
+
+// typeface return the font from TrueTypeFont or Name, or the default font
+func (f *Font) typeface() *Typeface {
+	if f.TrueTypeFont != nil {
+		return NewTypeface(f.TrueTypeFont)
+	}
+	if f.Name != "" {
+		if typeface, err := DefaultFontRegistry.Lookup(f.Name); err == nil {
+			return typeface
+		}
+	}
+	typeface, _ := DefaultFontRegistry.Lookup(FontLogoTypeGothic)
+	return typeface
+}
+
+// chain return the typeface and the fallbacks in order
+func (f *Font) chain(typeface *Typeface) []*Typeface {
+	registered := DefaultFontRegistry.chain(typeface)
+	return append(append(registered[:1:1], f.Fallbacks...), registered[1:]...)
+}
+
+// fill return the image to fill the letters in the bounds of the text
+func (o *StringOptions) fill(bounds image.Rectangle) image.Image {
+	if o.Paint != nil {
+		return o.Paint
+	}
+	if o.Pattern != nil {
+		return &repeatedImage{Image: o.Pattern, origin: bounds.Min}
+	}
+	if o.Gradient != nil && len(o.Gradient.Colors) > 0 {
+		return o.Gradient.image(bounds)
+	}
+	return image.NewUniform(o.Font.Color)
+}
+
+func (o *StringOptions) colorOutLine() *image.Uniform {
+	return image.NewUniform(o.Outline.Color)
+}
+
+// TextMetrics the size and position of the text measured by MeasureString
+type TextMetrics struct {
+	// Bounds logical area of the lines from the ascent of the first line to the descent of the last line
+	Bounds image.Rectangle
+	// InkBounds area actually painted by the letters and the outline
+	InkBounds image.Rectangle
+	// Advance px of the widest line
+	Advance float64
+	// FontSize used to draw, it is smaller than Font.Size if the text is shrunk by ShrinkToFit
+	FontSize float64
+	// Lines metrics of each line
+	Lines []LineMetrics
+}
+
+// LineMetrics the size and position of the line
+type LineMetrics struct {
+	// Text of the line after wrapping
+	Text string
+	// Bounds logical area of the line from the ascent to the descent
+	Bounds image.Rectangle
+	// Baseline y px of the line
+	Baseline int
+	// Advance px of the line including kerning and letter spacing
+	Advance float64
+	// Ascent px above the baseline
+	Ascent float64
+	// Descent px below the baseline
+	Descent float64
+	// Height px from the baseline to the next baseline before LineSpacing is applied
+	Height float64
+}
+
+// MeasureString return the metrics of the text without drawing.
+// the text is placed as on the image of size zero, so the text is centered at (0px, 0px) if Point and Box are nil.
+func MeasureString(text string, options *StringOptions) TextMetrics {
+	if options == nil {
+		options = &StringOptions{}
+	}
+	options.setDefault()
+	return newTextLayout(text, options, image.Rectangle{}).metrics(options)
+}
+
+// AddString add string on current Image, return the rectangle drawn into
+func (c *converter) AddString(text string, options *StringOptions) image.Rectangle {
+	if text == "" {
+		return image.Rectangle{}
+	}
+	if options == nil {
+		options = &StringOptions{}
+	}
+	options.setDefault()
+
+	// copy base image
+	dst := image.NewRGBA(image.Rect(0, 0, c.Bounds().Dx(), c.Bounds().Dy()))
+	draw.Draw(dst, image.Rect(0, 0, c.Bounds().Dx(), c.Bounds().Dy()), c.Image, image.Point{}, draw.Over)
+
+	rect := drawText(dst, text, options)
+	c.Image = dst
+	return rect
+}
+
+// drawText draw text on dst with the options already set default, return the rectangle drawn into
+func drawText(dst draw.Image, text string, options *StringOptions) image.Rectangle {
+	layout := newTextLayout(text, options, dst.Bounds())
+	rect := layout.inkBounds(options).Intersect(dst.Bounds())
+	if options.Shadow == nil && options.Background == nil && options.Opacity >= 1 {
+		layout.draw(dst, options)
+		return rect
+	}
+
+	// the text is drawn on the layer to apply the opacity to the whole text at once
+	layer := image.NewRGBA(dst.Bounds())
+	if options.Background != nil {
+		layout.drawBackground(layer, options.Background)
+	}
+	if options.Shadow != nil {
+		layout.drawShadow(layer, options)
+	}
+	layout.draw(layer, options)
+	mask := image.NewUniform(color.Alpha{A: uint8(math.Round(options.Opacity * math.MaxUint8))})
+	draw.DrawMask(dst, rect, layer, rect.Min, mask, image.Point{}, draw.Over)
+	return rect
+}
+
+// textStyle is the font of the glyphs
+type textStyle struct {
+	typeface *Typeface
+	size     float64
+	face     font.Face
+}
+
+func newTextStyle(typeface *Typeface, size float64) *textStyle {
+	return &textStyle{typeface: typeface, size: size, face: typeface.face(size)}
+}
+
+// textStyles is the styles of the spans with the fallbacks
+type textStyles struct {
+	font *Font
+	// scale is the ratio of the font size shrunk by ShrinkToFit
+	scale  float64
+	chains map[textStyleKey][]*textStyle
+}
+
+type textStyleKey struct {
+	typeface *Typeface
+	size     float64
+}
+
+func newTextStyles(f *Font, size float64) *textStyles {
+	return &textStyles{font: f, scale: size / f.Size, chains: map[textStyleKey][]*textStyle{}}
+}
+
+// of return the style of the span which has the glyph of r
+func (s *textStyles) of(span *textSpan, r rune) *textStyle {
+	key := textStyleKey{typeface: s.font.Typeface, size: s.font.Size * s.scale}
+	if span.typeface != nil {
+		key.typeface = span.typeface
+	}
+	if span.size > 0 {
+		key.size = span.size * s.scale
+	}
+	chain, ok := s.chains[key]
+	if !ok {
+		for _, typeface := range s.font.chain(key.typeface) {
+			chain = append(chain, newTextStyle(typeface, key.size))
+		}
+		s.chains[key] = chain
+	}
+	return styleOf(chain, r)
+}
+
+// styleOf return the first style which has the glyph of r, or the first style if no style has it
+func styleOf(styles []*textStyle, r rune) *textStyle {
+	for _, style := range styles {
+		if style.typeface.hasGlyph(r) {
+			return style
+		}
+	}
+	return styles[0]
+}
+
+// em return the font size as the advance in the vertical writing
+func (s *textStyle) em() fixed.Int26_6 {
+	return fixed.Int26_6(s.size * 64)
+}
+
+// textGlyph is the letter in the layout
+type textGlyph struct {
+	r     rune
+	style *textStyle
+	span  *textSpan
+	// kern is the kerning with the previous glyph, ignored at the start of the line
+	kern    fixed.Int26_6
+	advance fixed.Int26_6
+	// dot is the origin of the glyph, set by textLayout.place
+	dot fixed.Point26_6
+	// angle radians clockwise to turn the glyph around dot
+	angle float64
+}
+
+// textLine is the line in the layout
+type textLine struct {
+	glyphs  []textGlyph
+	width   fixed.Int26_6
+	ascent  fixed.Int26_6
+	descent fixed.Int26_6
+	height  fixed.Int26_6
+	// dot is the left end of the baseline, or the top of the column in the vertical writing, set by textLayout.place
+	dot fixed.Point26_6
+	// bounds is the logical area of the line, set by textLayout.place
+	bounds fixed.Rectangle26_6
+}
+
+// textLayout is the text placed on the image
+type textLayout struct {
+	lines []*textLine
+	// bounds is the logical area of the lines from ascent to descent
+	bounds fixed.Rectangle26_6
+	// block is bounds before the rotation
+	block fixed.Rectangle26_6
+	// pivot and rotation is the center and radians of the rotation
+	pivot    vec
+	rotation float64
+	// size is the font size shrunk by ShrinkToFit
+	size float64
+}
+
+// newTextLayout lay out the text on canvas with the options already set default
+func newTextLayout(text string, options *StringOptions, canvas image.Rectangle) *textLayout {
+	runs := plainRuns(text)
+	if options.Markup {
+		runs = parseMarkup(text)
+	}
+	size := options.Font.Size
+	for {
+		styles := newTextStyles(options.Font, size)
+		layout := &textLayout{lines: breakLines(runs, styles, options), size: size}
+		layout.place(options, canvas)
+		if !options.ShrinkToFit || size <= minShrinkFontSize || layout.fits(options) {
+			return layout
+		}
+		size *= 0.95
+		if size < minShrinkFontSize {
+			size = minShrinkFontSize
+		}
+	}
+}
+
+// wrapWidth return the width to wrap the lines, 0 is not wrapped
+func (o *StringOptions) wrapWidth() fixed.Int26_6 {
+	if o.Arc != nil {
+		return 0
+	}
+	if o.Box != nil {
+		if o.Vertical {
+			return fixed.I(o.Box.Dy())
+		}
+		return fixed.I(o.Box.Dx())
+	}
+	if o.ShrinkToFit {
+		// shrink instead of wrapping
+		return 0
+	}
+	return fixed.I(o.MaxWidth)
+}
+
+// fits return true, if the layout is in the Box or MaxWidth
+func (l *textLayout) fits(options *StringOptions) bool {
+	if options.Box != nil {
+		return l.bounds.Max.X-l.bounds.Min.X <= fixed.I(options.Box.Dx()) && l.bounds.Max.Y-l.bounds.Min.Y <= fixed.I(options.Box.Dy())
+	}
+	if options.MaxWidth > 0 && options.Vertical {
+		return l.bounds.Max.Y-l.bounds.Min.Y <= fixed.I(options.MaxWidth)
+	}
+	if options.MaxWidth > 0 {
+		return l.bounds.Max.X-l.bounds.Min.X <= fixed.I(options.MaxWidth)
+	}
+	return true
+}
+
+// breakLines split the letters at the line breaks and wrap them
+func breakLines(runs []textRun, styles *textStyles, options *StringOptions) []*textLine {
+	var lines []*textLine
+	letterSpacing := fixed.Int26_6(options.LetterSpacing * 64)
+	for _, paragraph := range splitParagraphs(runs) {
+		var glyphs []textGlyph
+		prev, prevStyle := rune(-1), (*textStyle)(nil)
+		for _, run := range paragraph {
+			r := run.r
+			style := styles.of(run.span, r)
+			advance, ok := style.face.GlyphAdvance(r)
+			if !ok {
+				continue
+			}
+			g := textGlyph{r: r, style: style, span: run.span, advance: advance + letterSpacing}
+			// the bold letters are widened by the stroke
+			g.advance += g.boldWidth()
+			if options.Vertical && options.Arc == nil {
+				// the letters are not kerned in the vertical writing
+				if !strings.ContainsRune(verticalRotated, r) {
+					g.advance = style.em() + letterSpacing
+				}
+				glyphs = append(glyphs, g)
+				continue
+			}
+			// the letters in the different fonts are not kerned
+			if prev >= 0 && style == prevStyle {
+				g.kern = style.face.Kern(prev, r)
+			}
+			glyphs = append(glyphs, g)
+			prev, prevStyle = r, style
+		}
+		for _, wrapped := range wrapGlyphs(glyphs, options.wrapWidth()) {
+			lines = append(lines, newTextLine(wrapped, letterSpacing))
+		}
+	}
+	return lines
+}
+
+// splitParagraphs split the letters at the line breaks \r\n, \n\r, \n and \r
+func splitParagraphs(runs []textRun) [][]textRun {
+	var paragraphs [][]textRun
+	start := 0
+	for i := 0; i < len(runs); i++ {
+		r := runs[i].r
+		if r != '\n' && r != '\r' {
+			continue
+		}
+		paragraphs = append(paragraphs, runs[start:i])
+		if i+1 < len(runs) && runs[i+1].r != r && (runs[i+1].r == '\n' || runs[i+1].r == '\r') {
+			i++
+		}
+		start = i + 1
+	}
+	return append(paragraphs, runs[start:])
+}
+
+// wrapGlyphs split the glyphs into the lines within maxWidth.
+// the lines are broken at the spaces, or at any letter if a word is too long.
+func wrapGlyphs(glyphs []textGlyph, maxWidth fixed.Int26_6) [][]textGlyph {
+	if maxWidth <= 0 || len(glyphs) == 0 {
+		// the empty paragraph is kept as the blank line
+		return [][]textGlyph{glyphs}
+	}
+	var lines [][]textGlyph
+	for len(glyphs) > 0 {
+		var width fixed.Int26_6
+		end, spaceEnd := len(glyphs), -1
+		for i, g := range glyphs {
+			if i > 0 {
+				width += g.kern
+			}
+			if unicode.IsSpace(g.r) {
+				spaceEnd = i
+			} else if width+g.advance > maxWidth && i > 0 {
+				end = i
+				if spaceEnd > 0 {
+					end = spaceEnd
+				}
+				break
+			}
+			width += g.advance
+		}
+		lines = append(lines, trimSpaces(glyphs[:end]))
+		glyphs = glyphs[end:]
+		// spaces at the line break are not drawn
+		for len(glyphs) > 0 && unicode.IsSpace(glyphs[0].r) {
+			glyphs = glyphs[1:]
+		}
+	}
+	return lines
+}
+
+// trimSpaces remove the spaces at the end of the line
+func trimSpaces(glyphs []textGlyph) []textGlyph {
+	for len(glyphs) > 0 && unicode.IsSpace(glyphs[len(glyphs)-1].r) {
+		glyphs = glyphs[:len(glyphs)-1]
+	}
+	return glyphs
+}
+
+// newTextLine measure the glyphs, the letter spacing after the last glyph is not counted
+func newTextLine(glyphs []textGlyph, letterSpacing fixed.Int26_6) *textLine {
+	line := &textLine{glyphs: glyphs}
+	for i, g := range glyphs {
+		if i > 0 {
+			line.width += g.kern
+		}
+		line.width += g.advance
+		metrics := g.style.face.Metrics()
+		line.ascent = maxInt26_6(line.ascent, metrics.Ascent)
+		line.descent = maxInt26_6(line.descent, metrics.Descent)
+		line.height = maxInt26_6(line.height, metrics.Height)
+	}
+	if len(glyphs) > 0 {
+		line.width -= letterSpacing
+	}
+	return line
+}
+
+// place set the position of the lines and glyphs
+func (l *textLayout) place(options *StringOptions, canvas image.Rectangle) {
+	if len(l.lines) == 0 {
+		return
+	}
+	// empty lines have the height of the default style in the size shrunk by ShrinkToFit
+	style := newTextStyle(options.Font.Typeface, l.size)
+	for _, line := range l.lines {
+		if len(line.glyphs) == 0 {
+			metrics := style.face.Metrics()
+			line.ascent, line.descent, line.height = metrics.Ascent, metrics.Descent, metrics.Height
+		}
+	}
+
+	switch {
+	case options.Arc != nil:
+		l.placeArc(options, canvas)
+	case options.Vertical:
+		l.placeVertical(options, canvas)
+	default:
+		l.placeHorizontal(options, canvas)
+	}
+	l.block = l.bounds
+	if options.Rotation != 0 {
+		pivot := anchorPoint(options.anchor(), options, canvas)
+		l.rotate(vec{fixedFloat(pivot.X), fixedFloat(pivot.Y)}, options.Rotation*math.Pi/180)
+	}
+}
+
+// anchor return the anchor used to place the block of the lines
+func (o *StringOptions) anchor() Anchor {
+	if o.Arc != nil {
+		// the arc is placed around the center
+		return AnchorCenter
+	}
+	if o.Box == nil && !o.Vertical {
+		return o.Anchor
+	}
+	// the text in the box or in the vertical writing is aligned to the top instead of the baseline
+	switch o.Anchor {
+	case AnchorBaselineLeft:
+		return AnchorTopLeft
+	case AnchorBaseline:
+		return AnchorTop
+	case AnchorBaselineRight:
+		return AnchorTopRight
+	}
+	return o.Anchor
+}
+
+// placeHorizontal lay out the lines from top to bottom
+func (l *textLayout) placeHorizontal(options *StringOptions, canvas image.Rectangle) {
+	// lay out in the block whose left top is (0, 0)
+	var width, baseline fixed.Int26_6
+	for i, line := range l.lines {
+		width = maxInt26_6(width, line.width)
+		if i == 0 {
+			baseline = line.ascent
+		} else {
+			baseline += fixed.Int26_6(float64(line.height) * options.LineSpacing)
+		}
+		line.dot.Y = baseline
+	}
+	last := l.lines[len(l.lines)-1]
+	block := fixed.Rectangle26_6{Max: fixed.Point26_6{X: width, Y: baseline + last.descent}}
+	for _, line := range l.lines {
+		switch options.Align {
+		case AlignLeft:
+			line.dot.X = 0
+		case AlignRight:
+			line.dot.X = width - line.width
+		default:
+			line.dot.X = (width - line.width) / 2
+		}
+	}
+
+	// move the block to the anchor point
+	anchor := options.anchor()
+	offset := anchorPoint(anchor, options, canvas).Sub(anchorOffset(anchor, block, l.lines[0].ascent))
+	l.bounds = block.Add(offset)
+	for _, line := range l.lines {
+		line.dot = line.dot.Add(offset)
+		line.bounds = fixed.Rectangle26_6{
+			Min: fixed.Point26_6{X: line.dot.X, Y: line.dot.Y - line.ascent},
+			Max: fixed.Point26_6{X: line.dot.X + line.width, Y: line.dot.Y + line.descent},
+		}
+		dot := line.dot
+		for i := range line.glyphs {
+			if i > 0 {
+				dot.X += line.glyphs[i].kern
+			}
+			line.glyphs[i].dot = dot
+			dot.X += line.glyphs[i].advance
+		}
+	}
+}
+
+// placeVertical lay out the lines as the columns from right to left,
+// the letters in the column are placed from top to bottom.
+func (l *textLayout) placeVertical(options *StringOptions, canvas image.Rectangle) {
+	// the center of the columns relative to the first column
+	centers := make([]fixed.Int26_6, len(l.lines))
+	var length fixed.Int26_6
+	for i, line := range l.lines {
+		length = maxInt26_6(length, line.width)
+		if i > 0 {
+			centers[i] = centers[i-1] - fixed.Int26_6(float64(line.height)*options.LineSpacing)
+		}
+	}
+	first, last := l.lines[0], l.lines[len(l.lines)-1]
+	left := centers[len(centers)-1] - last.height/2
+	block := fixed.Rectangle26_6{Max: fixed.Point26_6{X: first.height/2 - left, Y: length}}
+
+	// move the block to the anchor point
+	anchor := options.anchor()
+	offset := anchorPoint(anchor, options, canvas).Sub(anchorOffset(anchor, block, 0))
+	l.bounds = block.Add(offset)
+	for i, line := range l.lines {
+		var top fixed.Int26_6
+		switch options.Align {
+		case AlignLeft:
+			top = 0
+		case AlignRight:
+			top = length - line.width
+		default:
+			top = (length - line.width) / 2
+		}
+		center := centers[i] - left + offset.X
+		line.dot = fixed.Point26_6{X: center, Y: top + offset.Y}
+		line.bounds = fixed.Rectangle26_6{
+			Min: fixed.Point26_6{X: center - line.height/2, Y: line.dot.Y},
+			Max: fixed.Point26_6{X: center + line.height/2, Y: line.dot.Y + line.width},
+		}
+		y := line.dot.Y
+		for j := range line.glyphs {
+			g := &line.glyphs[j]
+			metrics := g.style.face.Metrics()
+			em := g.style.em()
+			switch {
+			case strings.ContainsRune(verticalRotated, g.r):
+				// the sideways letter is rotated clockwise around the left end of the baseline
+				g.dot = fixed.Point26_6{X: center - (metrics.Ascent-metrics.Descent)/2, Y: y}
+				g.angle = math.Pi / 2
+			default:
+				advance, _ := g.style.face.GlyphAdvance(g.r)
+				// the baseline divides the em box in the ratio of the ascent and the descent
+				baseline := fixed.Int26_6(int64(em) * int64(metrics.Ascent) / int64(metrics.Ascent+metrics.Descent))
+				g.dot = fixed.Point26_6{X: center - advance/2, Y: y + baseline}
+				if strings.ContainsRune(verticalShifted, g.r) {
+					g.dot = g.dot.Add(fixed.Point26_6{X: em / 2, Y: -em / 2})
+				}
+			}
+			y += g.advance
+		}
+	}
+}
+
+// placeArc lay out the lines along the circles around the anchor point
+func (l *textLayout) placeArc(options *StringOptions, canvas image.Rectangle) {
+	point := anchorPoint(AnchorCenter, options, canvas)
+	center := vec{fixedFloat(point.X), fixedFloat(point.Y)}
+	radius := options.Arc.Radius
+	start := options.Arc.Angle * math.Pi / 180
+	if options.Arc.Reverse {
+		start += math.Pi
+	}
+	l.bounds = fixed.Rectangle26_6{}
+	for i, line := range l.lines {
+		if i > 0 {
+			// the next line is placed under the baseline, it is inside of the circle unless reversed
+			pitch := float64(line.height) / 64 * options.LineSpacing
+			if options.Arc.Reverse {
+				radius += pitch
+			} else {
+				radius -= pitch
+			}
+		}
+		if radius < 1 {
+			radius = 1
+		}
+		line.bounds = fixed.Rectangle26_6{}
+		// s is the arc length from the center of the line
+		s := -fixedFloat(line.width) / 2
+		for j := range line.glyphs {
+			g := &line.glyphs[j]
+			if j > 0 {
+				s += fixedFloat(g.kern)
+			}
+			advance := fixedFloat(g.advance)
+			// theta is the clockwise angle from the top of the circle, angle is the direction of the baseline
+			theta := start + (s+advance/2)/radius
+			angle := theta
+			if options.Arc.Reverse {
+				// the letters are upright at the bottom and go counterclockwise
+				theta = start - (s+advance/2)/radius
+				angle = theta - math.Pi
+			}
+			// the glyph is placed on the tangent at the middle of the advance
+			mid := center.add(vec{math.Sin(theta), -math.Cos(theta)}.mul(radius))
+			dot := mid.sub(vec{math.Cos(angle), math.Sin(angle)}.mul(advance / 2))
+			g.dot = fixed.Point26_6{X: fixed.Int26_6(dot.x * 64), Y: fixed.Int26_6(dot.y * 64)}
+			g.angle = angle
+			box := fixed.Rectangle26_6{
+				Min: fixed.Point26_6{X: g.dot.X, Y: g.dot.Y - line.ascent},
+				Max: fixed.Point26_6{X: g.dot.X + g.advance, Y: g.dot.Y + line.descent},
+			}
+			line.bounds = unionRect(line.bounds, rotateRect(box, dot, angle))
+			s += advance
+		}
+		line.dot = line.bounds.Min
+		l.bounds = unionRect(l.bounds, line.bounds)
+	}
+}
+
+// rotate turn all glyphs clockwise around the pivot
+func (l *textLayout) rotate(pivot vec, angle float64) {
+	l.pivot, l.rotation = pivot, angle
+	l.bounds = rotateRect(l.bounds, pivot, angle)
+	for _, line := range l.lines {
+		line.bounds = rotateRect(line.bounds, pivot, angle)
+		for i := range line.glyphs {
+			g := &line.glyphs[i]
+			dot := vec{fixedFloat(g.dot.X), fixedFloat(g.dot.Y)}.rotate(pivot, angle)
+			g.dot = fixed.Point26_6{X: fixed.Int26_6(dot.x * 64), Y: fixed.Int26_6(dot.y * 64)}
+			g.angle += angle
+		}
+	}
+}
+
+// anchorPoint return the point on the canvas where the anchor is placed
+func anchorPoint(anchor Anchor, options *StringOptions, canvas image.Rectangle) fixed.Point26_6 {
+	if options.Box != nil {
+		box := fixed.R(options.Box.Min.X, options.Box.Min.Y, options.Box.Max.X, options.Box.Max.Y)
+		return anchorOffset(anchor, box.Sub(box.Min), 0).Add(box.Min)
+	}
+	if options.Point != nil {
+		return fixed.P(options.Point.X, options.Point.Y)
+	}
+	return fixed.P(canvas.Min.X+canvas.Dx()/2, canvas.Min.Y+canvas.Dy()/2)
+}
+
+// anchorOffset return the anchor position in the rect whose left top is (0, 0)
+func anchorOffset(anchor Anchor, rect fixed.Rectangle26_6, baseline fixed.Int26_6) fixed.Point26_6 {
+	width, height := rect.Max.X, rect.Max.Y
+	switch anchor {
+	case AnchorTopLeft:
+		return fixed.Point26_6{}
+	case AnchorTop:
+		return fixed.Point26_6{X: width / 2}
+	case AnchorTopRight:
+		return fixed.Point26_6{X: width}
+	case AnchorLeft:
+		return fixed.Point26_6{Y: height / 2}
+	case AnchorRight:
+		return fixed.Point26_6{X: width, Y: height / 2}
+	case AnchorBottomLeft:
+		return fixed.Point26_6{Y: height}
+	case AnchorBottom:
+		return fixed.Point26_6{X: width / 2, Y: height}
+	case AnchorBottomRight:
+		return fixed.Point26_6{X: width, Y: height}
+	case AnchorBaselineLeft:
+		return fixed.Point26_6{Y: baseline}
+	case AnchorBaseline:
+		return fixed.Point26_6{X: width / 2, Y: baseline}
+	case AnchorBaselineRight:
+		return fixed.Point26_6{X: width, Y: baseline}
+	default:
+		return fixed.Point26_6{X: width / 2, Y: height / 2}
+	}
+}
+
+// metrics return the measured size of the layout
+func (l *textLayout) metrics(options *StringOptions) TextMetrics {
+	m := TextMetrics{
+		Bounds:    fixedRect(l.bounds),
+		InkBounds: l.inkBounds(options),
+		FontSize:  l.size,
+	}
+	for _, line := range l.lines {
+		text := make([]rune, 0, len(line.glyphs))
+		for _, g := range line.glyphs {
+			text = append(text, g.r)
+		}
+		m.Advance = math.Max(m.Advance, fixedFloat(line.width))
+		m.Lines = append(m.Lines, LineMetrics{
+			Text:     string(text),
+			Bounds:   fixedRect(line.bounds),
+			Baseline: line.dot.Y.Round(),
+			Advance:  fixedFloat(line.width),
+			Ascent:   fixedFloat(line.ascent),
+			Descent:  fixedFloat(line.descent),
+			Height:   fixedFloat(line.height),
+		})
+	}
+	return m
+}
+
+// inkBounds return the area painted by the glyphs, the outline and the effects
+func (l *textLayout) inkBounds(options *StringOptions) image.Rectangle {
+	ink := l.glyphBounds(options)
+	if options.Shadow != nil && !ink.Empty() {
+		ink = ink.Union(options.Shadow.bounds(ink))
+	}
+	if options.Background != nil && len(l.lines) > 0 {
+		ink = ink.Union(l.backgroundPath(options.Background).bounds())
+	}
+	return ink
+}
+
+// glyphBounds return the area painted by the glyphs and the outline
+func (l *textLayout) glyphBounds(options *StringOptions) image.Rectangle {
+	var ink image.Rectangle
+	for _, line := range l.lines {
+		for _, g := range line.glyphs {
+			bounds, _, ok := g.style.face.GlyphBounds(g.r)
+			if !ok || bounds.Empty() {
+				continue
+			}
+			rect := fixedRect(rotateRect(bounds.Add(g.dot).Add(fixed.Point26_6{X: g.boldWidth() / 2}), g.origin(), g.angle))
+			halfWidth := fixedFloat(g.boldWidth()) / 2
+			if options.Outline != nil {
+				halfWidth += g.outlineWidth(options.Outline)
+			}
+			rect = rect.Inset(-int(math.Ceil(halfWidth)))
+			ink = ink.Union(rect)
+		}
+	}
+	return ink
+}
+
+// outlineWidth return the half width px of the outline
+func (g textGlyph) outlineWidth(outline *Outline) float64 {
+	// notice : width values are determined by feeling
+	return float64(g.style.face.Metrics().Height) / 64 * float64(outline.Width) / 12800
+}
+
+// boldWidth return the width px of the stroke to thicken the bold glyph
+func (g textGlyph) boldWidth() fixed.Int26_6 {
+	if g.span == nil || !g.span.bold {
+		return 0
+	}
+	return fixed.Int26_6(g.style.size * boldWidth * 64)
+}
+
+// src return the image to fill the glyph, the color of the span is used instead of fill
+func (g textGlyph) src(fill image.Image) image.Image {
+	if g.span == nil || g.span.color == nil {
+		return fill
+	}
+	return image.NewUniform(g.span.color)
+}
+
+// origin return the dot in float
+func (g textGlyph) origin() vec {
+	return vec{fixedFloat(g.dot.X), fixedFloat(g.dot.Y)}
+}
+
+// path return the contours of the glyph turned around dot,
+// the bold glyph is moved by the half of the stroke not to overlap the previous glyph.
+func (g textGlyph) path() (path, error) {
+	glyph, err := g.style.typeface.glyphPath(g.style.size, g.r, g.dot.Add(fixed.Point26_6{X: g.boldWidth() / 2}))
+	if err != nil || g.angle == 0 {
+		return glyph, err
+	}
+	return glyph.rotate(g.origin(), g.angle), nil
+}
+
+// draw the outline of all lines first, then draw the letters over it
+func (l *textLayout) draw(dst draw.Image, options *StringOptions) {
+	if options.Outline != nil {
+		rasterizer := vector.NewRasterizer(dst.Bounds().Dx(), dst.Bounds().Dy())
+		for _, line := range l.lines {
+			for _, g := range line.glyphs {
+				glyph, err := g.path()
+				if err == nil {
+					glyph.stroke(rasterizer, g.outlineWidth(options.Outline)*2+fixedFloat(g.boldWidth()), options.Outline.Join)
+				}
+			}
+		}
+		rasterizer.Draw(dst, dst.Bounds(), options.colorOutLine(), image.Point{})
+	}
+
+	fill := options.fill(fixedRect(l.bounds))
+	for _, line := range l.lines {
+		for _, g := range line.glyphs {
+			// the turned and the bold glyphs are filled with the contours instead of the face
+			if g.angle != 0 || g.boldWidth() > 0 {
+				glyph, err := g.path()
+				if err == nil {
+					drawPath(dst, glyph, g.src(fill), fixedFloat(g.boldWidth()))
+				}
+				continue
+			}
+			dr, mask, maskp, _, ok := g.style.face.Glyph(g.dot, g.r)
+			if !ok {
+				continue
+			}
+			draw.DrawMask(dst, dr, g.src(fill), dr.Min, mask, maskp, draw.Over)
+		}
+	}
+}
+
+// drawPath fill the path with src, and thicken it with the stroke of the width if width > 0
+func drawPath(dst draw.Image, p path, src image.Image, width float64) {
+	rect := p.bounds().Inset(-int(math.Ceil(width / 2))).Intersect(dst.Bounds())
+	if rect.Empty() {
+		return
+	}
+	// the fill and the stroke are drawn on the mask separately,
+	// the contours of the glyph and the stroke may be in the opposite direction.
+	mask := image.NewAlpha(rect)
+	rasterizer := vector.NewRasterizer(rect.Dx(), rect.Dy())
+	offset := vec{-float64(rect.Min.X), -float64(rect.Min.Y)}
+	p.translate(offset).fill(rasterizer)
+	rasterizer.Draw(mask, rect, image.Opaque, image.Point{})
+	if width > 0 {
+		rasterizer.Reset(rect.Dx(), rect.Dy())
+		p.translate(offset).stroke(rasterizer, width, JoinRound)
+		rasterizer.Draw(mask, rect, image.Opaque, image.Point{})
+	}
+	draw.DrawMask(dst, rect, src, rect.Min, mask, rect.Min, draw.Over)
+}
+
+// fixedRect return the smallest integer rectangle containing r
+func fixedRect(r fixed.Rectangle26_6) image.Rectangle {
+	return image.Rect(r.Min.X.Floor(), r.Min.Y.Floor(), r.Max.X.Ceil(), r.Max.Y.Ceil())
+}
+
+// rotateRect return the bounding rectangle of r turned clockwise around center
+func rotateRect(r fixed.Rectangle26_6, center vec, angle float64) fixed.Rectangle26_6 {
+	if angle == 0 {
+		return r
+	}
+	corners := []vec{
+		{fixedFloat(r.Min.X), fixedFloat(r.Min.Y)},
+		{fixedFloat(r.Max.X), fixedFloat(r.Min.Y)},
+		{fixedFloat(r.Max.X), fixedFloat(r.Max.Y)},
+		{fixedFloat(r.Min.X), fixedFloat(r.Max.Y)},
+	}
+	min, max := corners[0].rotate(center, angle), corners[0].rotate(center, angle)
+	for _, corner := range corners[1:] {
+		v := corner.rotate(center, angle)
+		min = vec{math.Min(min.x, v.x), math.Min(min.y, v.y)}
+		max = vec{math.Max(max.x, v.x), math.Max(max.y, v.y)}
+	}
+	return fixed.Rectangle26_6{
+		Min: fixed.Point26_6{X: fixed.Int26_6(math.Floor(min.x * 64)), Y: fixed.Int26_6(math.Floor(min.y * 64))},
+		Max: fixed.Point26_6{X: fixed.Int26_6(math.Ceil(max.x * 64)), Y: fixed.Int26_6(math.Ceil(max.y * 64))},
+	}
+}
+
+// unionRect return the smallest rectangle containing a and b, the empty rectangle is ignored
+func unionRect(a, b fixed.Rectangle26_6) fixed.Rectangle26_6 {
+	if a.Empty() {
+		return b
+	}
+	if b.Empty() {
+		return a
+	}
+	return a.Union(b)
+}
+
+func fixedFloat(x fixed.Int26_6) float64 {
+	return float64(x) / 64
+}
+
+func maxInt26_6(a, b fixed.Int26_6) fixed.Int26_6 {
+	if a > b {
+		return a
+	}
+	return b
+}
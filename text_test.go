@@ -0,0 +1,495 @@
+package imgedit
+
+import (
+	"image"
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/magiconair/properties/assert"
+	"golang.org/x/image/font"
+	"golang.org/x/image/math/fixed"
+)
+
+func Test_newTextLayout_lines(t *testing.T) {
+	type args struct {
+		text    string
+		options *StringOptions
+	}
+	tests := []struct {
+		name string
+		args args
+		want []string
+	}{
+		{
+			name: "single line",
+			args: args{text: "rabbit and turtle", options: &StringOptions{}},
+			want: []string{"rabbit and turtle"},
+		},
+		{
+			name: "line breaks",
+			args: args{text: "rabbit\nand\r\nturtle", options: &StringOptions{}},
+			want: []string{"rabbit", "and", "turtle"},
+		},
+		{
+			name: "wrap at spaces",
+			args: args{text: "rabbit and turtle", options: &StringOptions{Font: &Font{Size: 50}, MaxWidth: 300}},
+			want: []string{"rabbit and", "turtle"},
+		},
+		{
+			name: "wrap long word",
+			args: args{text: "うさぎとかめ", options: &StringOptions{Font: &Font{Size: 50}, MaxWidth: 120}},
+			want: []string{"うさ", "ぎと", "かめ"},
+		},
+		{
+			name: "wrap in box",
+			args: args{text: "rabbit and turtle", options: &StringOptions{Font: &Font{Size: 50}, Box: &image.Rectangle{Max: image.Point{X: 200, Y: 500}}}},
+			want: []string{"rabbit", "and", "turtle"},
+		},
+		{
+			name: "blank lines in wrap",
+			args: args{text: "rabbit\n\nturtle", options: &StringOptions{Font: &Font{Size: 50}, MaxWidth: 300}},
+			want: []string{"rabbit", "", "turtle"},
+		},
+		{
+			name: "shrink instead of wrap",
+			args: args{text: "rabbit and turtle", options: &StringOptions{Font: &Font{Size: 50}, MaxWidth: 300, ShrinkToFit: true}},
+			want: []string{"rabbit and turtle"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.args.options.setDefault()
+			layout := newTextLayout(tt.args.text, tt.args.options, image.Rect(0, 0, 1000, 1000))
+			var got []string
+			for _, line := range layout.lines {
+				got = append(got, lineText(line))
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("newTextLayout() lines = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_newTextLayout_anchor(t *testing.T) {
+	point := &image.Point{X: 300, Y: 200}
+	tests := []struct {
+		name    string
+		options *StringOptions
+		// want returns the expected point from the layout
+		got  func(l *textLayout) fixed.Point26_6
+		want fixed.Point26_6
+	}{
+		{
+			name:    "default is the center of the canvas",
+			options: &StringOptions{},
+			got: func(l *textLayout) fixed.Point26_6 {
+				return fixed.Point26_6{X: (l.bounds.Min.X + l.bounds.Max.X) / 2, Y: (l.bounds.Min.Y + l.bounds.Max.Y) / 2}
+			},
+			want: fixed.P(500, 500),
+		},
+		{
+			name:    "top left",
+			options: &StringOptions{Point: point, Anchor: AnchorTopLeft},
+			got:     func(l *textLayout) fixed.Point26_6 { return l.bounds.Min },
+			want:    fixed.P(300, 200),
+		},
+		{
+			name:    "bottom right",
+			options: &StringOptions{Point: point, Anchor: AnchorBottomRight},
+			got:     func(l *textLayout) fixed.Point26_6 { return l.bounds.Max },
+			want:    fixed.P(300, 200),
+		},
+		{
+			name:    "baseline left",
+			options: &StringOptions{Point: point, Anchor: AnchorBaselineLeft, Align: AlignLeft},
+			got:     func(l *textLayout) fixed.Point26_6 { return l.lines[0].dot },
+			want:    fixed.P(300, 200),
+		},
+		{
+			name:    "box bottom right",
+			options: &StringOptions{Box: &image.Rectangle{Min: image.Point{X: 100, Y: 100}, Max: image.Point{X: 900, Y: 800}}, Anchor: AnchorBottomRight},
+			got:     func(l *textLayout) fixed.Point26_6 { return l.bounds.Max },
+			want:    fixed.P(900, 800),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.options.setDefault()
+			layout := newTextLayout("rabbit\nand turtle", tt.options, image.Rect(0, 0, 1000, 1000))
+			got := tt.got(layout)
+			// allow the rounding error
+			if d := got.Sub(tt.want); d.X < -2 || 2 < d.X || d.Y < -2 || 2 < d.Y {
+				t.Errorf("newTextLayout() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_newTextLayout_align(t *testing.T) {
+	tests := []struct {
+		name  string
+		align Align
+		// the position of the line in the space of the block, 0 is left and 1 is right
+		want float64
+	}{
+		{name: "left", align: AlignLeft, want: 0},
+		{name: "center", align: AlignCenter, want: 0.5},
+		{name: "right", align: AlignRight, want: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			options := &StringOptions{Align: tt.align}
+			options.setDefault()
+			layout := newTextLayout("rabbit\nand turtle", options, image.Rect(0, 0, 1000, 1000))
+			line := layout.lines[0]
+			space := layout.bounds.Max.X - layout.bounds.Min.X - line.width
+			got := line.dot.X - layout.bounds.Min.X
+			want := fixed.Int26_6(float64(space) * tt.want)
+			if d := got - want; d < -1 || 1 < d {
+				t.Errorf("newTextLayout() line x = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func Test_newTextLayout_spacing(t *testing.T) {
+	options := &StringOptions{}
+	options.setDefault()
+	layout := newTextLayout("rabbit\nturtle", options, image.Rect(0, 0, 1000, 1000))
+
+	spaced := &StringOptions{LineSpacing: 2, LetterSpacing: 10}
+	spaced.setDefault()
+	spacedLayout := newTextLayout("rabbit\nturtle", spaced, image.Rect(0, 0, 1000, 1000))
+
+	// the letter spacing is added between 6 letters
+	assert.Equal(t, spacedLayout.lines[0].width, layout.lines[0].width+fixed.I(50))
+	// the line height is doubled
+	height := layout.lines[1].dot.Y - layout.lines[0].dot.Y
+	assert.Equal(t, spacedLayout.lines[1].dot.Y-spacedLayout.lines[0].dot.Y, height*2)
+}
+
+func Test_newTextLayout_blankLines(t *testing.T) {
+	canvas := image.Rect(0, 0, 1000, 1000)
+	for _, text := range []string{"a\n\nb", "\n", "a\n\n\n"} {
+		options := &StringOptions{}
+		options.setDefault()
+		wrapped := &StringOptions{MaxWidth: 500}
+		wrapped.setDefault()
+		assert.Equal(t, len(newTextLayout(text, wrapped, canvas).lines), len(newTextLayout(text, options, canvas).lines))
+	}
+
+	// the blank lines are shrunk with the text
+	box := image.Rect(100, 100, 400, 300)
+	options := &StringOptions{Box: &box, ShrinkToFit: true}
+	options.setDefault()
+	layout := newTextLayout("rabbit\n\n\nturtle", options, canvas)
+	if !layout.fits(options) {
+		t.Errorf("newTextLayout() bounds = %v, want in %v", layout.bounds, box)
+	}
+	assert.Equal(t, layout.lines[1].height, layout.lines[0].height)
+}
+
+func Test_newTextLayout_shrinkToFit(t *testing.T) {
+	box := image.Rect(100, 100, 400, 200)
+	options := &StringOptions{Box: &box, ShrinkToFit: true}
+	options.setDefault()
+	layout := newTextLayout("rabbit and turtle run a race", options, image.Rect(0, 0, 1000, 1000))
+	if !layout.fits(options) {
+		t.Errorf("newTextLayout() bounds = %v, want in %v", layout.bounds, box)
+	}
+	if layout.lines[0].glyphs[0].style.size >= DefaultFontSize {
+		t.Errorf("newTextLayout() size = %v, want less than %v", layout.lines[0].glyphs[0].style.size, DefaultFontSize)
+	}
+}
+
+func Test_newTextLayout_vertical(t *testing.T) {
+	options := &StringOptions{Point: &image.Point{X: 500, Y: 100}, Anchor: AnchorTop, Align: AlignLeft, Vertical: true}
+	options.setDefault()
+	layout := newTextLayout("うさぎ\n「かめ」", options, image.Rect(0, 0, 1000, 1000))
+	assert.Equal(t, len(layout.lines), 2)
+
+	em := layout.lines[0].glyphs[0].style.em()
+	first, second := layout.lines[0], layout.lines[1]
+	// the letters go down with the em advance
+	for i, g := range first.glyphs {
+		assert.Equal(t, g.angle, 0.0)
+		if i > 0 {
+			assert.Equal(t, g.dot.Y-first.glyphs[i-1].dot.Y, em)
+		}
+	}
+	// the next column is placed on the left
+	assert.Equal(t, second.bounds.Max.X <= first.bounds.Min.X, true)
+	assert.Equal(t, first.bounds.Min.Y, fixed.I(100))
+	assert.Equal(t, (layout.bounds.Min.X+layout.bounds.Max.X)/2, fixed.I(500))
+	// the brackets are turned sideways
+	assert.Equal(t, second.glyphs[0].angle, math.Pi/2)
+	assert.Equal(t, second.glyphs[1].angle, 0.0)
+
+	// MaxWidth wraps the column
+	options = &StringOptions{MaxWidth: 250, Vertical: true}
+	options.setDefault()
+	layout = newTextLayout("うさぎとかめ", options, image.Rect(0, 0, 1000, 1000))
+	assert.Equal(t, len(layout.lines), 3)
+}
+
+func Test_newTextLayout_arc(t *testing.T) {
+	type args struct {
+		arc *TextArc
+	}
+	tests := []struct {
+		name      string
+		args      args
+		wantAbove bool
+	}{
+		{
+			name:      "top",
+			args:      args{arc: &TextArc{Radius: 300}},
+			wantAbove: true,
+		},
+		{
+			name:      "bottom reversed",
+			args:      args{arc: &TextArc{Radius: 300, Reverse: true}},
+			wantAbove: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			options := &StringOptions{Point: &image.Point{X: 500, Y: 500}, Arc: tt.args.arc}
+			options.setDefault()
+			layout := newTextLayout("rabbit and turtle", options, image.Rect(0, 0, 1000, 1000))
+			center := vec{500, 500}
+			glyphs := layout.lines[0].glyphs
+			for i, g := range glyphs {
+				// the baseline is on the circle
+				assert.Equal(t, math.Abs(g.origin().sub(center).length()-300) < 50, true)
+				assert.Equal(t, g.origin().y < 500, tt.wantAbove)
+				// the letters go to the right
+				if i > 0 {
+					assert.Equal(t, g.dot.X > glyphs[i-1].dot.X, true)
+				}
+			}
+			// the text is centered at the top or the bottom of the circle
+			assert.Equal(t, glyphs[0].dot.X < fixed.I(500), true)
+			assert.Equal(t, glyphs[len(glyphs)-1].dot.X > fixed.I(500), true)
+		})
+	}
+}
+
+func Test_newTextLayout_rotation(t *testing.T) {
+	options := &StringOptions{Point: &image.Point{X: 500, Y: 500}, Anchor: AnchorBaselineLeft}
+	options.setDefault()
+	straight := newTextLayout("rabbit", options, image.Rect(0, 0, 1000, 1000))
+	options.Rotation = 90
+	rotated := newTextLayout("rabbit", options, image.Rect(0, 0, 1000, 1000))
+	for i, g := range rotated.lines[0].glyphs {
+		// the baseline goes down from the pivot
+		want := straight.lines[0].glyphs[i].origin().rotate(vec{500, 500}, math.Pi/2)
+		assert.Equal(t, g.origin().sub(want).length() < 0.1, true)
+		assert.Equal(t, g.angle, math.Pi/2)
+	}
+	rotatedSize, straightSize := fixedRect(rotated.bounds).Size(), fixedRect(straight.bounds).Size()
+	assert.Equal(t, rotatedSize, image.Point{X: straightSize.Y, Y: straightSize.X})
+}
+
+func Test_newTextLayout_fallback(t *testing.T) {
+	goRegular, _ := DefaultFontRegistry.Lookup(FontGoRegular)
+	pop, _ := DefaultFontRegistry.Lookup(FontLightNovelPOP)
+	ttf, _ := ReadTtfFromByte(TtfFile)
+	type args struct {
+		font *Font
+	}
+	tests := []struct {
+		name string
+		args args
+		// want is the font name of each letter in "aé"
+		want []string
+	}{
+		{
+			name: "registered fallback",
+			args: args{font: &Font{}},
+			want: []string{FontLogoTypeGothic, FontGoRegular},
+		},
+		{
+			name: "font name",
+			args: args{font: &Font{Name: FontLightNovelPOP}},
+			want: []string{FontLightNovelPOP, FontGoRegular},
+		},
+		{
+			name: "explicit fallback first",
+			args: args{font: &Font{Name: FontLightNovelPOP, Fallbacks: []*Typeface{goRegular, pop}}},
+			want: []string{FontLightNovelPOP, FontGoRegular},
+		},
+		{
+			name: "unregistered font has no fallback",
+			args: args{font: &Font{TrueTypeFont: ttf}},
+			want: []string{"", ""},
+		},
+		{
+			name: "unknown name",
+			args: args{font: &Font{Name: "unknown"}},
+			want: []string{FontLogoTypeGothic, FontGoRegular},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			options := &StringOptions{Font: tt.args.font}
+			options.setDefault()
+			layout := newTextLayout("aé", options, image.Rect(0, 0, 1000, 1000))
+			var got []string
+			for _, g := range layout.lines[0].glyphs {
+				got = append(got, g.style.typeface.Name())
+			}
+			assert.Equal(t, got, tt.want)
+		})
+	}
+}
+
+func Test_newTextLayout_markup(t *testing.T) {
+	options := &StringOptions{Font: &Font{Size: 50}, Markup: true}
+	options.setDefault()
+	layout := newTextLayout("a<size=100>b</size>\n<b>c</b>c", options, image.Rect(0, 0, 1000, 1000))
+	assert.Equal(t, len(layout.lines), 2)
+
+	// the line is as high as the largest letter
+	first := layout.lines[0]
+	assert.Equal(t, first.glyphs[0].style.size, 50.0)
+	assert.Equal(t, first.glyphs[1].style.size, 100.0)
+	assert.Equal(t, first.ascent, first.glyphs[1].style.face.Metrics().Ascent)
+
+	// the bold letter is wider than the plain letter
+	second := layout.lines[1]
+	assert.Equal(t, second.glyphs[0].advance-second.glyphs[1].advance, second.glyphs[0].boldWidth())
+	assert.Equal(t, second.glyphs[0].boldWidth() > 0, true)
+
+	// the spans are shrunk at the same ratio
+	options = &StringOptions{Font: &Font{Size: 50}, Markup: true, MaxWidth: 100, ShrinkToFit: true}
+	options.setDefault()
+	layout = newTextLayout("rabbit <size=100>turtle</size>", options, image.Rect(0, 0, 1000, 1000))
+	glyphs := layout.lines[0].glyphs
+	assert.Equal(t, math.Abs(glyphs[len(glyphs)-1].style.size/glyphs[0].style.size-2) < 1e-9, true)
+
+	// the tags are written as they are without Markup
+	options = &StringOptions{}
+	options.setDefault()
+	layout = newTextLayout("<b>c</b>", options, image.Rect(0, 0, 1000, 1000))
+	assert.Equal(t, len(layout.lines[0].glyphs), 8)
+}
+
+func Test_newTextLayout_glyphs(t *testing.T) {
+	options := &StringOptions{Anchor: AnchorBaselineLeft, Point: &image.Point{Y: 100}}
+	options.setDefault()
+	face := options.face()
+	drawer := &font.Drawer{Face: face}
+
+	tests := []struct {
+		name   string
+		prefix string
+	}{
+		{
+			name:   "kerning",
+			prefix: "AV",
+		},
+		{
+			name:   "cjk",
+			prefix: "うさぎ",
+		},
+		{
+			name:   "emoji missing in the font",
+			prefix: "😀",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// the glyphs are placed at the same position as font.Drawer
+			layout := newTextLayout(tt.prefix+"A", options, image.Rect(0, 0, 1000, 1000))
+			glyphs := layout.lines[0].glyphs
+			got := glyphs[len(glyphs)-1]
+			advance, _ := face.GlyphAdvance('A')
+			want := fixed.Point26_6{X: drawer.MeasureString(tt.prefix+"A") - advance, Y: fixed.I(100)}
+			assert.Equal(t, len(glyphs), len([]rune(tt.prefix))+1)
+			assert.Equal(t, got.dot, want)
+		})
+	}
+}
+
+func Test_MeasureString(t *testing.T) {
+	type args struct {
+		text    string
+		options *StringOptions
+	}
+	tests := []struct {
+		name      string
+		args      args
+		wantLines []string
+	}{
+		{
+			name:      "default",
+			args:      args{text: "rabbit"},
+			wantLines: []string{"rabbit"},
+		},
+		{
+			name:      "multi lines from left top",
+			args:      args{text: "rabbit\nand turtle", options: &StringOptions{Point: &image.Point{X: 10, Y: 20}, Anchor: AnchorTopLeft, Align: AlignLeft}},
+			wantLines: []string{"rabbit", "and turtle"},
+		},
+		{
+			name:      "with outline",
+			args:      args{text: "rabbit", options: &StringOptions{Outline: &Outline{Width: 200}}},
+			wantLines: []string{"rabbit"},
+		},
+		{
+			name:      "wrap in box",
+			args:      args{text: "rabbit and turtle", options: &StringOptions{Font: &Font{Size: 50}, Box: &image.Rectangle{Max: image.Point{X: 200, Y: 500}}}},
+			wantLines: []string{"rabbit", "and", "turtle"},
+		},
+		{
+			name:      "empty",
+			args:      args{text: ""},
+			wantLines: []string{""},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := MeasureString(tt.args.text, tt.args.options)
+			var lines []string
+			var bounds image.Rectangle
+			for _, line := range got.Lines {
+				lines = append(lines, line.Text)
+				bounds = bounds.Union(line.Bounds)
+				assert.Equal(t, line.Bounds.Min.Y <= line.Baseline && line.Baseline <= line.Bounds.Max.Y, true)
+				assert.Equal(t, line.Advance <= got.Advance, true)
+			}
+			assert.Equal(t, lines, tt.wantLines)
+			assert.Equal(t, bounds.In(got.Bounds), true)
+			assert.Equal(t, got.InkBounds.In(got.Bounds.Inset(-int(got.FontSize))), true)
+			if tt.args.text != "" {
+				assert.Equal(t, got.InkBounds.Empty(), false)
+			}
+		})
+	}
+
+	// the text is placed at Point
+	got := MeasureString("rabbit", &StringOptions{Point: &image.Point{X: 10, Y: 20}, Anchor: AnchorTopLeft})
+	assert.Equal(t, got.Bounds.Min, image.Point{X: 10, Y: 20})
+
+	// the outline is included in the ink bounds
+	plain := MeasureString("rabbit", nil)
+	outlined := MeasureString("rabbit", &StringOptions{Outline: &Outline{}})
+	assert.Equal(t, plain.InkBounds.In(outlined.InkBounds) && plain.InkBounds != outlined.InkBounds, true)
+	assert.Equal(t, plain.Bounds, outlined.Bounds)
+
+	// the shrunk font size is returned
+	shrunk := MeasureString("rabbit and turtle", &StringOptions{MaxWidth: 300, ShrinkToFit: true})
+	assert.Equal(t, shrunk.FontSize < DefaultFontSize, true)
+	assert.Equal(t, shrunk.Advance <= 300, true)
+}
+
+func lineText(line *textLine) string {
+	var runes []rune
+	for _, g := range line.glyphs {
+		runes = append(runes, g.r)
+	}
+	return string(runes)
+}
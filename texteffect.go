@@ -0,0 +1,92 @@
+package imgedit
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+
+	"golang.org/x/image/vector"
+)
+
+// TextShadow the shadow of the text
+type TextShadow struct {
+	// Offset the shadow position from the text
+	Offset image.Point
+	// Blur radius px
+	Blur int
+	// Color default color.Black, use the alpha to make it light
+	Color color.Color
+}
+
+// TextBackground the rectangle behind the text
+type TextBackground struct {
+	// Color default color.White
+	Color color.Color
+	// Padding px around the text
+	Padding int
+	// Radius px of the rounded corners
+	Radius int
+}
+
+// TextGradient the colors changing across the text
+type TextGradient struct {
+	// Colors placed at even intervals from the start to the end of the text
+	Colors []color.Color
+	// Angle degrees clockwise of the gradient direction, 0 is left to right
+	Angle float64
+}
+
+// image return the gradient over the bounds
+func (g *TextGradient) image(bounds image.Rectangle) image.Image {
+	sin, cos := math.Sincos(g.Angle * math.Pi / 180)
+	center := vec{float64(bounds.Min.X+bounds.Max.X) / 2, float64(bounds.Min.Y+bounds.Max.Y) / 2}
+	// the gradient line is long enough to reach the corners of the bounds
+	half := (float64(bounds.Dx())*math.Abs(cos) + float64(bounds.Dy())*math.Abs(sin)) / 2
+	direction := vec{cos, sin}.mul(half)
+	return &linearGradient{start: center.sub(direction), end: center.add(direction), stops: EvenStops(g.Colors...)}
+}
+
+// bounds return the area of the shadow cast by the ink
+func (s *TextShadow) bounds(ink image.Rectangle) image.Rectangle {
+	return ink.Add(s.Offset).Inset(-s.Blur)
+}
+
+// drawShadow draw the blurred shadow of the glyphs and the outline on dst
+func (l *textLayout) drawShadow(dst draw.Image, options *StringOptions) {
+	ink := l.glyphBounds(options).Intersect(dst.Bounds())
+	if ink.Empty() {
+		return
+	}
+	rect := options.Shadow.bounds(ink).Intersect(dst.Bounds())
+	if rect.Empty() {
+		return
+	}
+	letters := image.NewRGBA(dst.Bounds())
+	l.draw(letters, options)
+	shadow := newAlphaMask(letters.SubImage(ink), rect, ink.Min.Add(options.Shadow.Offset))
+	shadow.blur(float64(options.Shadow.Blur) / 2)
+	draw.DrawMask(dst, rect, image.NewUniform(options.Shadow.Color), image.Point{}, shadow.alpha(1), rect.Min, draw.Over)
+}
+
+// backgroundPath return the rounded rectangle around the lines turned with the text
+func (l *textLayout) backgroundPath(background *TextBackground) path {
+	padding := float64(background.Padding)
+	min := vec{fixedFloat(l.block.Min.X) - padding, fixedFloat(l.block.Min.Y) - padding}
+	max := vec{fixedFloat(l.block.Max.X) + padding, fixedFloat(l.block.Max.Y) + padding}
+	rect := path{roundedRect(min, max, float64(background.Radius))}
+	if l.rotation != 0 {
+		rect = rect.rotate(l.pivot, l.rotation)
+	}
+	return rect
+}
+
+// drawBackground draw the background rectangle on dst
+func (l *textLayout) drawBackground(dst draw.Image, background *TextBackground) {
+	if len(l.lines) == 0 {
+		return
+	}
+	rasterizer := vector.NewRasterizer(dst.Bounds().Dx(), dst.Bounds().Dy())
+	l.backgroundPath(background).fill(rasterizer)
+	rasterizer.Draw(dst, dst.Bounds(), image.NewUniform(background.Color), image.Point{})
+}
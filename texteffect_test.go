@@ -0,0 +1,145 @@
+package imgedit
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"testing"
+
+	"github.com/magiconair/properties/assert"
+)
+
+func Test_drawText_effects(t *testing.T) {
+	red := color.RGBA{R: 255, A: 255}
+	tests := []struct {
+		name    string
+		options *StringOptions
+		// wantAt is the color at the point relative to the ink bounds of the plain text
+		wantAt    image.Point
+		wantColor color.Color
+	}{
+		{
+			name:      "background padding",
+			options:   &StringOptions{Background: &TextBackground{Color: red, Padding: 20}},
+			wantAt:    image.Point{X: -10, Y: -10},
+			wantColor: red,
+		},
+		{
+			name:      "half opacity",
+			options:   &StringOptions{Background: &TextBackground{Color: color.Black, Padding: 20}, Opacity: 0.5},
+			wantAt:    image.Point{X: -10, Y: -10},
+			wantColor: color.RGBA{R: 127, G: 127, B: 127, A: 255},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dst := image.NewRGBA(image.Rect(0, 0, 1000, 2000))
+			draw.Draw(dst, dst.Bounds(), image.White, image.Point{}, draw.Src)
+			options := &StringOptions{Point: &image.Point{X: 500, Y: 200}, Font: tt.options.Font}
+			options.setDefault()
+			plain := newTextLayout("rabbit", options, dst.Bounds()).glyphBounds(options)
+
+			tt.options.Point = options.Point
+			tt.options.setDefault()
+			rect := drawText(dst, "rabbit", tt.options)
+			p := plain.Min.Add(tt.wantAt)
+			assert.Equal(t, p.In(rect), true)
+			assert.Equal(t, dst.At(p.X, p.Y), color.RGBAModel.Convert(tt.wantColor))
+		})
+	}
+}
+
+func Test_drawText_markup(t *testing.T) {
+	dst := image.NewRGBA(image.Rect(0, 0, 1000, 1000))
+	options := &StringOptions{Point: &image.Point{X: 500, Y: 500}, Font: &Font{Color: color.RGBA{B: 255, A: 255}}, Markup: true}
+	options.setDefault()
+	rect := drawText(dst, "<color=#ff0000>■</color>■", options)
+	assert.Equal(t, dominantColor(dst, rect, true), color.RGBA{R: 255, A: 255})
+	assert.Equal(t, dominantColor(dst, rect, false), color.RGBA{B: 255, A: 255})
+}
+
+func Test_drawText_shadow(t *testing.T) {
+	red := color.RGBA{R: 255, A: 255}
+	offset := image.Point{X: 0, Y: 300}
+	plain := image.NewRGBA(image.Rect(0, 0, 1000, 1000))
+	options := &StringOptions{Point: &image.Point{X: 500, Y: 200}}
+	options.setDefault()
+	plainRect := drawText(plain, "rabbit", options)
+
+	dst := image.NewRGBA(image.Rect(0, 0, 1000, 1000))
+	options.Shadow = &TextShadow{Offset: offset, Color: red}
+	rect := drawText(dst, "rabbit", options)
+	assert.Equal(t, rect, plainRect.Union(plainRect.Add(offset)))
+
+	// the shadow has the same shape as the letters
+	for y := plainRect.Min.Y; y < plainRect.Max.Y; y++ {
+		for x := plainRect.Min.X; x < plainRect.Max.X; x++ {
+			_, _, _, a := plain.At(x, y).RGBA()
+			_, _, _, shadowA := dst.At(x+offset.X, y+offset.Y).RGBA()
+			if a != shadowA {
+				t.Fatalf("shadow alpha at %v = %v, want %v", image.Point{X: x, Y: y}, shadowA, a)
+			}
+		}
+	}
+}
+
+func Test_drawText_fill(t *testing.T) {
+	red, blue := color.RGBA{R: 255, A: 255}, color.RGBA{B: 255, A: 255}
+	tests := []struct {
+		name    string
+		options *StringOptions
+		// wantLeft and wantRight is the color of the first and last letter
+		wantLeft  color.Color
+		wantRight color.Color
+	}{
+		{
+			name:      "gradient left to right",
+			options:   &StringOptions{Gradient: &TextGradient{Colors: []color.Color{red, blue}}},
+			wantLeft:  red,
+			wantRight: blue,
+		},
+		{
+			name:      "gradient right to left",
+			options:   &StringOptions{Gradient: &TextGradient{Colors: []color.Color{red, blue}, Angle: 180}},
+			wantLeft:  blue,
+			wantRight: red,
+		},
+		{
+			name:      "pattern",
+			options:   &StringOptions{Pattern: image.NewUniform(red)},
+			wantLeft:  red,
+			wantRight: red,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dst := image.NewRGBA(image.Rect(0, 0, 1000, 500))
+			tt.options.setDefault()
+			rect := drawText(dst, "IIIIIIIIII", tt.options)
+			left, right := dominantColor(dst, rect, true), dominantColor(dst, rect, false)
+			assert.Equal(t, left, tt.wantLeft)
+			assert.Equal(t, right, tt.wantRight)
+		})
+	}
+}
+
+// dominantColor return red or blue which is stronger at the first or last opaque column in rect
+func dominantColor(img image.Image, rect image.Rectangle, isLeft bool) color.Color {
+	for i := 0; i < rect.Dx(); i++ {
+		x := rect.Min.X + i
+		if !isLeft {
+			x = rect.Max.X - 1 - i
+		}
+		for y := rect.Min.Y; y < rect.Max.Y; y++ {
+			r, _, b, a := img.At(x, y).RGBA()
+			if a != 0xffff {
+				continue
+			}
+			if r > b {
+				return color.RGBA{R: 255, A: 255}
+			}
+			return color.RGBA{B: 255, A: 255}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,128 @@
+package imgedit
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+)
+
+// TileLayout how to shift the tiles
+type TileLayout int
+
+const (
+	// TileGrid lay down the tiles in a plain grid
+	TileGrid TileLayout = iota
+	// TileBrick shift every other row by half the tile width
+	TileBrick
+	// TileHalfDrop shift every other column by half the tile height
+	TileHalfDrop
+)
+
+// TileOptions options for TileWithOptions
+type TileOptions struct {
+	// Cols number of the tiles in a row
+	Cols int
+	// Rows number of the tiles in a column
+	Rows int
+	// Width px of the canvas, if Width is set, Cols is ignored
+	Width int
+	// Height px of the canvas, if Height is set, Rows is ignored
+	Height int
+	// Mirror flip every other tile to make the seamless pattern
+	Mirror bool
+	// Layout default TileGrid
+	Layout TileLayout
+	// Gap px between the tiles, the negative gap overlaps the tiles and the pitch is at least 1px
+	Gap int
+	// GapColor default transparent
+	GapColor color.Color
+}
+
+func (o *TileOptions) setDefault(tileSize image.Point) {
+	// the tiles of the negative gap must not be on the same point
+	side := tileSize.X
+	if tileSize.Y < side {
+		side = tileSize.Y
+	}
+	if side > 0 && o.Gap < 1-side {
+		o.Gap = 1 - side
+	}
+	if o.Width <= 0 {
+		o.Width = o.Cols*(tileSize.X+o.Gap) - o.Gap
+	}
+	if o.Height <= 0 {
+		o.Height = o.Rows*(tileSize.Y+o.Gap) - o.Gap
+	}
+	if o.Width < 0 {
+		o.Width = 0
+	}
+	if o.Height < 0 {
+		o.Height = 0
+	}
+	if o.GapColor == nil {
+		o.GapColor = color.Transparent
+	}
+}
+
+// TileWithOptions lay down the image with mirror, layout and gap
+func (c *converter) TileWithOptions(options *TileOptions) {
+	if options == nil {
+		options = &TileOptions{}
+	}
+	srcSize := c.Bounds().Size()
+	options.setDefault(srcSize)
+
+	dst := image.NewRGBA(image.Rect(0, 0, options.Width, options.Height))
+	if srcSize.X <= 0 || srcSize.Y <= 0 {
+		c.Image = dst
+		return
+	}
+	draw.Draw(dst, dst.Bounds(), image.NewUniform(options.GapColor), image.Point{}, draw.Src)
+
+	// tiles[flipY][flipX]
+	tiles := [2][2]image.Image{{c.Image, c.Image}, {c.Image, c.Image}}
+	if options.Mirror {
+		tiles[0][1] = reversedImage(c.Image, true)
+		tiles[1][0] = reversedImage(c.Image, false)
+		tiles[1][1] = reversedImage(tiles[0][1], false)
+	}
+
+	pitch := srcSize.Add(image.Point{X: options.Gap, Y: options.Gap})
+	// start from -1 to fill the area revealed by the shifted tiles
+	for col := -1; col*pitch.X < options.Width+pitch.X; col++ {
+		for row := -1; row*pitch.Y < options.Height+pitch.Y; row++ {
+			point := tilePoint(col, row, pitch, options.Layout)
+			rect := image.Rectangle{Min: point, Max: point.Add(srcSize)}
+			if !rect.Overlaps(dst.Bounds()) {
+				continue
+			}
+			tile := tiles[mod2(row)][mod2(col)]
+			draw.Draw(dst, rect, tile, tile.Bounds().Min, draw.Src)
+		}
+	}
+	c.Image = dst
+}
+
+// tilePoint return the left top point of the tile at col and row
+func tilePoint(col, row int, pitch image.Point, layout TileLayout) image.Point {
+	point := image.Point{X: col * pitch.X, Y: row * pitch.Y}
+	switch layout {
+	case TileBrick:
+		point.X += mod2(row) * pitch.X / 2
+	case TileHalfDrop:
+		point.Y += mod2(col) * pitch.Y / 2
+	}
+	return point
+}
+
+// reversedImage return the flipped copy of img
+func reversedImage(img image.Image, isHorizon bool) image.Image {
+	c := &converter{img}
+	c.Reverse(isHorizon)
+	return c.Image
+}
+
+// mod2 return 0 or 1 even if n is negative
+func mod2(n int) int {
+	return (n%2 + 2) % 2
+}
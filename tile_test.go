@@ -0,0 +1,103 @@
+package imgedit
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/magiconair/properties/assert"
+)
+
+func Test_converter_TileWithOptions(t *testing.T) {
+	type fields struct {
+		Image image.Image
+	}
+	type args struct {
+		options *TileOptions
+	}
+	tests := []struct {
+		name       string
+		fields     fields
+		args       args
+		wantWidth  int
+		wantHeight int
+	}{
+		{
+			name:       "grid",
+			fields:     fields{Image: GetAlphaPngImage()},
+			args:       args{options: &TileOptions{Cols: 2, Rows: 3}},
+			wantWidth:  GetAlphaPngImage().Bounds().Dx() * 2,
+			wantHeight: GetAlphaPngImage().Bounds().Dy() * 3,
+		},
+		{
+			name:       "gap",
+			fields:     fields{Image: image.NewRGBA(image.Rect(0, 0, 100, 50))},
+			args:       args{options: &TileOptions{Cols: 3, Rows: 2, Gap: 10, GapColor: color.White}},
+			wantWidth:  320,
+			wantHeight: 110,
+		},
+		{
+			name:       "negative gap overlaps",
+			fields:     fields{Image: image.NewRGBA(image.Rect(0, 0, 100, 50))},
+			args:       args{options: &TileOptions{Cols: 3, Rows: 2, Gap: -20}},
+			wantWidth:  260,
+			wantHeight: 80,
+		},
+		{
+			name:       "negative gap over the tile size",
+			fields:     fields{Image: image.NewRGBA(image.Rect(0, 0, 100, 50))},
+			args:       args{options: &TileOptions{Width: 300, Height: 200, Gap: -100}},
+			wantWidth:  300,
+			wantHeight: 200,
+		},
+		{
+			name:       "mirror brick to fixed size",
+			fields:     fields{Image: GetAlphaPngImage()},
+			args:       args{options: &TileOptions{Width: 1500, Height: 900, Mirror: true, Layout: TileBrick}},
+			wantWidth:  1500,
+			wantHeight: 900,
+		},
+		{
+			name:       "half drop",
+			fields:     fields{Image: GetAlphaPngImage()},
+			args:       args{options: &TileOptions{Cols: 3, Height: 1000, Layout: TileHalfDrop, Gap: 20}},
+			wantWidth:  GetAlphaPngImage().Bounds().Dx()*3 + 40,
+			wantHeight: 1000,
+		},
+		{
+			name:       "empty",
+			fields:     fields{Image: GetPngImage()},
+			args:       args{},
+			wantWidth:  0,
+			wantHeight: 0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &converter{
+				Image: tt.fields.Image,
+			}
+			c.TileWithOptions(tt.args.options)
+			img := c.Convert()
+			assert.Equal(t, img.Bounds().Dx(), tt.wantWidth)
+			assert.Equal(t, img.Bounds().Dy(), tt.wantHeight)
+			if !img.Bounds().Empty() {
+				SaveTestImageAsPng(img)
+			}
+		})
+	}
+}
+
+func Test_converter_TileWithOptions_mirror(t *testing.T) {
+	src := image.NewRGBA(image.Rect(0, 0, 2, 2))
+	src.Set(0, 0, color.White)
+	src.Set(1, 1, color.Black)
+	c := &converter{Image: src}
+	c.TileWithOptions(&TileOptions{Cols: 2, Rows: 2, Mirror: true})
+	img := c.Convert()
+
+	// the mirrored tiles meet at the same color
+	assert.Equal(t, img.At(1, 1), img.At(2, 1))
+	assert.Equal(t, img.At(1, 1), img.At(1, 2))
+	assert.Equal(t, img.At(0, 0), img.At(3, 3))
+}
@@ -0,0 +1,587 @@
+package imgedit
+
+import (
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/draw"
+	"io"
+	"math/bits"
+	"sort"
+)
+
+const (
+	// webpMaxSize px of the width and the height of the VP8L image
+	webpMaxSize = 1 << 14
+	// webpPredictorBits log2 of the tile size of the predictor transform
+	webpPredictorBits = 4
+	// webpMaxLength pixels of the longest backward reference
+	webpMaxLength = 4096
+	// webpMaxDistance the largest distance code of the backward reference
+	webpMaxDistance = 1 << 20
+	// webpMinLength pixels of the shortest backward reference worth to code
+	webpMinLength = 3
+	// webpHashBits log2 of the size of the hash table to find the backward references
+	webpHashBits = 16
+)
+
+// webpCodeLengthOrder the order of the code length code lengths in the bit stream
+var webpCodeLengthOrder = []int{17, 18, 0, 1, 2, 3, 4, 5, 16, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15}
+
+// webpDistanceMap the offsets of the short distance codes, the upper 4 bits are y and the lower 4 bits are 8-x
+var webpDistanceMap = [120]uint8{
+	0x18, 0x07, 0x17, 0x19, 0x28, 0x06, 0x27, 0x29, 0x16, 0x1a,
+	0x26, 0x2a, 0x38, 0x05, 0x37, 0x39, 0x15, 0x1b, 0x36, 0x3a,
+	0x25, 0x2b, 0x48, 0x04, 0x47, 0x49, 0x14, 0x1c, 0x35, 0x3b,
+	0x46, 0x4a, 0x24, 0x2c, 0x58, 0x45, 0x4b, 0x34, 0x3c, 0x03,
+	0x57, 0x59, 0x13, 0x1d, 0x56, 0x5a, 0x23, 0x2d, 0x44, 0x4c,
+	0x55, 0x5b, 0x33, 0x3d, 0x68, 0x02, 0x67, 0x69, 0x12, 0x1e,
+	0x66, 0x6a, 0x22, 0x2e, 0x54, 0x5c, 0x43, 0x4d, 0x65, 0x6b,
+	0x32, 0x3e, 0x78, 0x01, 0x77, 0x79, 0x53, 0x5d, 0x11, 0x1f,
+	0x64, 0x6c, 0x42, 0x4e, 0x76, 0x7a, 0x21, 0x2f, 0x75, 0x7b,
+	0x31, 0x3f, 0x63, 0x6d, 0x52, 0x5e, 0x00, 0x74, 0x7c, 0x41,
+	0x4f, 0x10, 0x20, 0x62, 0x6e, 0x30, 0x73, 0x7d, 0x51, 0x5f,
+	0x40, 0x72, 0x7e, 0x61, 0x6f, 0x50, 0x71, 0x7f, 0x60, 0x70,
+}
+
+// webpEncode write the image in the lossless WebP format.
+// the subtract green and the predictor transforms are applied, and the repeated pixels are coded as the backward references.
+func webpEncode(w io.Writer, m image.Image) error {
+	b := m.Bounds()
+	width, height := b.Dx(), b.Dy()
+	if width < 1 || height < 1 || width > webpMaxSize || height > webpMaxSize {
+		return errors.New("webp: image size is not supported")
+	}
+	nrgba := image.NewNRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(nrgba, nrgba.Bounds(), m, b.Min, draw.Src)
+	pix := make([]uint32, width*height)
+	hasAlpha := false
+	for i := range pix {
+		p := nrgba.Pix[i*4 : i*4+4]
+		pix[i] = uint32(p[3])<<24 | uint32(p[0])<<16 | uint32(p[1])<<8 | uint32(p[2])
+		hasAlpha = hasAlpha || p[3] != 0xff
+	}
+
+	bw := &webpBitWriter{}
+	bw.write(0x2f, 8)
+	bw.write(uint32(width-1), 14)
+	bw.write(uint32(height-1), 14)
+	if hasAlpha {
+		bw.write(1, 1)
+	} else {
+		bw.write(0, 1)
+	}
+	bw.write(0, 3)
+
+	// subtract green transform
+	bw.write(1, 1)
+	bw.write(2, 2)
+	for i, p := range pix {
+		green := (p >> 8) & 0xff
+		pix[i] = p&0xff00ff00 | ((p>>16-green)&0xff)<<16 | (p-green)&0xff
+	}
+
+	// predictor transform
+	bw.write(1, 1)
+	bw.write(0, 2)
+	bw.write(webpPredictorBits-2, 3)
+	modes := webpPredict(pix, width, height)
+	writeWebpImage(bw, modes, webpTiles(width), false)
+	bw.write(0, 1)
+
+	writeWebpImage(bw, pix, width, true)
+	bw.flush()
+
+	data := bw.buf
+	pad := len(data) & 1
+	header := make([]byte, 20)
+	copy(header[0:], "RIFF")
+	binary.LittleEndian.PutUint32(header[4:], uint32(12+len(data)+pad))
+	copy(header[8:], "WEBPVP8L")
+	binary.LittleEndian.PutUint32(header[16:], uint32(len(data)))
+	if _, err := w.Write(header); err != nil {
+		return err
+	}
+	if pad == 1 {
+		data = append(data, 0)
+	}
+	_, err := w.Write(data)
+	return err
+}
+
+// webpTiles return the number of the tiles of the predictor transform
+func webpTiles(size int) int {
+	return (size + 1<<webpPredictorBits - 1) >> webpPredictorBits
+}
+
+// webpPredict replace the pixels with the residuals of the best predictor of each tile, and return the modes of the tiles
+func webpPredict(pix []uint32, width, height int) []uint32 {
+	tilesX, tilesY := webpTiles(width), webpTiles(height)
+	modes := make([]uint32, tilesX*tilesY)
+	residuals := make([]uint32, len(pix))
+	for ty := 0; ty < tilesY; ty++ {
+		for tx := 0; tx < tilesX; tx++ {
+			bestMode, bestCost := 0, -1
+			for mode := 0; mode < 14; mode++ {
+				cost := 0
+				webpEachTilePixel(tx, ty, width, height, func(x, y int) {
+					r := webpSubPixels(pix[y*width+x], webpPredictor(pix, width, x, y, mode))
+					for shift := 0; shift < 32; shift += 8 {
+						c := int(int8(r >> shift))
+						if c < 0 {
+							c = -c
+						}
+						cost += c
+					}
+				})
+				if bestCost < 0 || cost < bestCost {
+					bestMode, bestCost = mode, cost
+				}
+			}
+			modes[ty*tilesX+tx] = 0xff000000 | uint32(bestMode)<<8
+			webpEachTilePixel(tx, ty, width, height, func(x, y int) {
+				residuals[y*width+x] = webpSubPixels(pix[y*width+x], webpPredictor(pix, width, x, y, bestMode))
+			})
+		}
+	}
+	copy(pix, residuals)
+	return modes
+}
+
+// webpEachTilePixel call f with the pixels in the tile
+func webpEachTilePixel(tx, ty, width, height int, f func(x, y int)) {
+	size := 1 << webpPredictorBits
+	for y := ty * size; y < (ty+1)*size && y < height; y++ {
+		for x := tx * size; x < (tx+1)*size && x < width; x++ {
+			f(x, y)
+		}
+	}
+}
+
+// webpPredictor return the prediction of the pixel by the mode,
+// the first row always uses the left pixel and the first column always uses the top pixel.
+func webpPredictor(pix []uint32, width, x, y, mode int) uint32 {
+	i := y*width + x
+	switch {
+	case x == 0 && y == 0:
+		return 0xff000000
+	case y == 0:
+		return pix[i-1]
+	case x == 0:
+		return pix[i-width]
+	}
+	// the top right of the last column is the first pixel of the current row
+	l, t, tl, tr := pix[i-1], pix[i-width], pix[i-width-1], pix[i-width+1]
+	switch mode {
+	case 0:
+		return 0xff000000
+	case 1:
+		return l
+	case 2:
+		return t
+	case 3:
+		return tr
+	case 4:
+		return tl
+	case 5:
+		return webpAverage(webpAverage(l, tr), t)
+	case 6:
+		return webpAverage(l, tl)
+	case 7:
+		return webpAverage(l, t)
+	case 8:
+		return webpAverage(tl, t)
+	case 9:
+		return webpAverage(t, tr)
+	case 10:
+		return webpAverage(webpAverage(l, tl), webpAverage(t, tr))
+	case 11:
+		return webpSelect(l, t, tl)
+	case 12:
+		return webpChannels(func(a, b, c int) int { return a + b - c }, l, t, tl)
+	default:
+		return webpChannels(func(a, b, c int) int { return a + (a-b)/2 }, webpAverage(l, t), tl, 0)
+	}
+}
+
+// webpAverage return the average of each channel of the pixels
+func webpAverage(a, b uint32) uint32 {
+	return (((a ^ b) & 0xfefefefe) >> 1) + (a & b)
+}
+
+// webpSelect return the left or the top pixel which is closer to the gradient of the pixels
+func webpSelect(l, t, tl uint32) uint32 {
+	distanceL, distanceT := 0, 0
+	for shift := 0; shift < 32; shift += 8 {
+		cl, ct, ctl := int(l>>shift&0xff), int(t>>shift&0xff), int(tl>>shift&0xff)
+		distanceL += webpAbs(ctl - ct)
+		distanceT += webpAbs(ctl - cl)
+	}
+	if distanceL < distanceT {
+		return l
+	}
+	return t
+}
+
+// webpChannels return the pixel of f applied to each channel, clamped to 0-255
+func webpChannels(f func(a, b, c int) int, a, b, c uint32) uint32 {
+	var p uint32
+	for shift := 0; shift < 32; shift += 8 {
+		v := f(int(a>>shift&0xff), int(b>>shift&0xff), int(c>>shift&0xff))
+		if v < 0 {
+			v = 0
+		}
+		if v > 0xff {
+			v = 0xff
+		}
+		p |= uint32(v) << shift
+	}
+	return p
+}
+
+// webpSubPixels return the difference of each channel modulo 256
+func webpSubPixels(a, b uint32) uint32 {
+	alphaGreen := 0x00ff00ff + a&0xff00ff00 - b&0xff00ff00
+	redBlue := 0xff00ff00 + a&0x00ff00ff - b&0x00ff00ff
+	return alphaGreen&0xff00ff00 | redBlue&0x00ff00ff
+}
+
+func webpAbs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+// webpToken a literal pixel, or a backward reference if the length is not 0
+type webpToken struct {
+	pixel    uint32
+	length   int
+	distance int
+}
+
+// writeWebpImage write the entropy coded pixels, the main image has the bit of the meta prefix codes
+func writeWebpImage(bw *webpBitWriter, pix []uint32, width int, isMain bool) {
+	// no color cache
+	bw.write(0, 1)
+	if isMain {
+		// no meta prefix codes
+		bw.write(0, 1)
+	}
+	tokens := webpBackwardReferences(pix, width)
+
+	counts := [5][]int{make([]int, 256+24), make([]int, 256), make([]int, 256), make([]int, 256), make([]int, 40)}
+	for _, t := range tokens {
+		if t.length == 0 {
+			counts[0][t.pixel>>8&0xff]++
+			counts[1][t.pixel>>16&0xff]++
+			counts[2][t.pixel&0xff]++
+			counts[3][t.pixel>>24]++
+			continue
+		}
+		code, _, _ := webpPrefix(t.length)
+		counts[0][256+code]++
+		code, _, _ = webpPrefix(t.distance)
+		counts[4][code]++
+	}
+	var codes [5]*webpHuffman
+	for i := range codes {
+		codes[i] = newWebpHuffman(counts[i], 15)
+		codes[i].writeLengths(bw)
+	}
+
+	for _, t := range tokens {
+		if t.length == 0 {
+			codes[0].write(bw, int(t.pixel>>8&0xff))
+			codes[1].write(bw, int(t.pixel>>16&0xff))
+			codes[2].write(bw, int(t.pixel&0xff))
+			codes[3].write(bw, int(t.pixel>>24))
+			continue
+		}
+		code, n, extra := webpPrefix(t.length)
+		codes[0].write(bw, 256+code)
+		bw.write(uint32(extra), uint(n))
+		code, n, extra = webpPrefix(t.distance)
+		codes[4].write(bw, code)
+		bw.write(uint32(extra), uint(n))
+	}
+}
+
+// webpBackwardReferences return the tokens of the pixels, the distances of the references are the distance codes
+func webpBackwardReferences(pix []uint32, width int) []webpToken {
+	// the short distance codes of the neighbors
+	shortCodes := map[int]int{}
+	for code := len(webpDistanceMap); code >= 1; code-- {
+		offset := int(webpDistanceMap[code-1])
+		distance := (offset>>4)*width + 8 - offset&0xf
+		if distance >= 1 {
+			shortCodes[distance] = code
+		}
+	}
+	distanceCode := func(distance int) int {
+		if code, ok := shortCodes[distance]; ok {
+			return code
+		}
+		return distance + len(webpDistanceMap)
+	}
+
+	var tokens []webpToken
+	head := make([]int, 1<<webpHashBits)
+	for i := range head {
+		head[i] = -1
+	}
+	hash := func(i int) int {
+		return int((pix[i]*0x1e35a7bd ^ pix[i+1]*0x9e3779b1) >> (32 - webpHashBits))
+	}
+	for i := 0; i < len(pix); {
+		bestLength, bestDistance := 0, 0
+		candidates := []int{i - 1, i - width}
+		if i+1 < len(pix) {
+			candidates = append(candidates, head[hash(i)])
+		}
+		for _, j := range candidates {
+			if j < 0 || j >= i || distanceCode(i-j) > webpMaxDistance {
+				continue
+			}
+			length := 0
+			for i+length < len(pix) && length < webpMaxLength && pix[j+length] == pix[i+length] {
+				length++
+			}
+			if length > bestLength {
+				bestLength, bestDistance = length, i-j
+			}
+		}
+		if bestLength < webpMinLength {
+			bestLength = 1
+			tokens = append(tokens, webpToken{pixel: pix[i]})
+		} else {
+			tokens = append(tokens, webpToken{length: bestLength, distance: distanceCode(bestDistance)})
+		}
+		for end := i + bestLength; i < end; i++ {
+			if i+1 < len(pix) {
+				head[hash(i)] = i
+			}
+		}
+	}
+	return tokens
+}
+
+// webpPrefix return the prefix code, the number of the extra bits and the extra bits of the length or the distance
+func webpPrefix(v int) (code, n, extra int) {
+	v--
+	if v < 4 {
+		return v, 0, 0
+	}
+	h := bits.Len(uint(v)) - 1
+	second := (v >> (h - 1)) & 1
+	return 2*h + second, h - 1, v & (1<<(h-1) - 1)
+}
+
+// webpHuffman the canonical prefix code of an alphabet
+type webpHuffman struct {
+	lengths []int
+	codes   []uint32
+	// single is true if only one symbol is used, the symbol is coded with no bits
+	single bool
+}
+
+// newWebpHuffman create the prefix code of the counts of the symbols, limited to the max length
+func newWebpHuffman(counts []int, maxLength int) *webpHuffman {
+	h := &webpHuffman{lengths: make([]int, len(counts)), codes: make([]uint32, len(counts))}
+	var symbols []int
+	for s, c := range counts {
+		if c > 0 {
+			symbols = append(symbols, s)
+		}
+	}
+	if len(symbols) <= 1 {
+		h.single = true
+		if len(symbols) == 0 {
+			symbols = []int{0}
+		}
+		h.lengths[symbols[0]] = 1
+		return h
+	}
+
+	weights := make([]int, len(counts))
+	copy(weights, counts)
+	for {
+		depths := webpHuffmanDepths(symbols, weights)
+		longest := 0
+		for _, d := range depths {
+			if d > longest {
+				longest = d
+			}
+		}
+		if longest <= maxLength {
+			for i, s := range symbols {
+				h.lengths[s] = depths[i]
+			}
+			break
+		}
+		// flatten the weights until the tree is short enough
+		for _, s := range symbols {
+			weights[s] = weights[s]/2 + 1
+		}
+	}
+
+	// canonical codes are in the order of the lengths and the symbols, and written from the first bit
+	var next [16]uint32
+	var lengthCounts [16]uint32
+	for _, l := range h.lengths {
+		lengthCounts[l]++
+	}
+	lengthCounts[0] = 0
+	code := uint32(0)
+	for l := 1; l < 16; l++ {
+		code = (code + lengthCounts[l-1]) << 1
+		next[l] = code
+	}
+	for s, l := range h.lengths {
+		if l > 0 {
+			h.codes[s] = bits.Reverse32(next[l]) >> (32 - l)
+			next[l]++
+		}
+	}
+	return h
+}
+
+// webpHuffmanDepths return the depths of the symbols in the Huffman tree of the weights
+func webpHuffmanDepths(symbols []int, weights []int) []int {
+	leaves := make([]int, len(symbols))
+	copy(leaves, symbols)
+	sort.SliceStable(leaves, func(i, j int) bool { return weights[leaves[i]] < weights[leaves[j]] })
+
+	// the leaves are sorted and the internal nodes are created in order, so the two queues are enough
+	n := len(leaves)
+	nodeWeights := make([]int, n, 2*n-1)
+	for i, s := range leaves {
+		nodeWeights[i] = weights[s]
+	}
+	parents := make([]int, 2*n-1)
+	leaf, internal := 0, n
+	pick := func() int {
+		if leaf < n && (internal >= len(nodeWeights) || nodeWeights[leaf] <= nodeWeights[internal]) {
+			leaf++
+			return leaf - 1
+		}
+		internal++
+		return internal - 1
+	}
+	for k := 0; k < n-1; k++ {
+		a, b := pick(), pick()
+		nodeWeights = append(nodeWeights, nodeWeights[a]+nodeWeights[b])
+		parents[a], parents[b] = len(nodeWeights)-1, len(nodeWeights)-1
+	}
+	nodeDepths := make([]int, len(nodeWeights))
+	for i := len(nodeWeights) - 2; i >= 0; i-- {
+		nodeDepths[i] = nodeDepths[parents[i]] + 1
+	}
+
+	depths := make([]int, len(symbols))
+	index := map[int]int{}
+	for i, s := range symbols {
+		index[s] = i
+	}
+	for i, s := range leaves {
+		depths[index[s]] = nodeDepths[i]
+	}
+	return depths
+}
+
+// write the code of the symbol
+func (h *webpHuffman) write(bw *webpBitWriter, symbol int) {
+	if h.single {
+		return
+	}
+	bw.write(h.codes[symbol], uint(h.lengths[symbol]))
+}
+
+// writeLengths write the code lengths coded by the code length code
+func (h *webpHuffman) writeLengths(bw *webpBitWriter) {
+	type lengthToken struct {
+		symbol, n, extra int
+	}
+	var tokens []lengthToken
+	for i := 0; i < len(h.lengths); {
+		v := h.lengths[i]
+		run := 1
+		for i+run < len(h.lengths) && h.lengths[i+run] == v {
+			run++
+		}
+		i += run
+		if v == 0 {
+			for ; run >= 11; run -= webpMinInt(run, 138) {
+				tokens = append(tokens, lengthToken{18, 7, webpMinInt(run, 138) - 11})
+			}
+			for ; run >= 3; run -= webpMinInt(run, 10) {
+				tokens = append(tokens, lengthToken{17, 3, webpMinInt(run, 10) - 3})
+			}
+		} else {
+			tokens = append(tokens, lengthToken{v, 0, 0})
+			run--
+			for ; run >= 3; run -= webpMinInt(run, 6) {
+				tokens = append(tokens, lengthToken{16, 2, webpMinInt(run, 6) - 3})
+			}
+		}
+		for ; run > 0; run-- {
+			tokens = append(tokens, lengthToken{v, 0, 0})
+		}
+	}
+
+	counts := make([]int, len(webpCodeLengthOrder))
+	for _, t := range tokens {
+		counts[t.symbol]++
+	}
+	lengthCode := newWebpHuffman(counts, 7)
+	n := 4
+	for i, s := range webpCodeLengthOrder {
+		if lengthCode.lengths[s] > 0 && i+1 > n {
+			n = i + 1
+		}
+	}
+
+	// normal code, not the simple code
+	bw.write(0, 1)
+	bw.write(uint32(n-4), 4)
+	for _, s := range webpCodeLengthOrder[:n] {
+		bw.write(uint32(lengthCode.lengths[s]), 3)
+	}
+	// all the symbols are coded
+	bw.write(0, 1)
+	for _, t := range tokens {
+		lengthCode.write(bw, t.symbol)
+		bw.write(uint32(t.extra), uint(t.n))
+	}
+}
+
+func webpMinInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// webpBitWriter write the bits from the least significant bit
+type webpBitWriter struct {
+	buf   []byte
+	bits  uint64
+	nBits uint
+}
+
+func (b *webpBitWriter) write(v uint32, n uint) {
+	b.bits |= uint64(v) << b.nBits
+	b.nBits += n
+	for b.nBits >= 8 {
+		b.buf = append(b.buf, byte(b.bits))
+		b.bits >>= 8
+		b.nBits -= 8
+	}
+}
+
+func (b *webpBitWriter) flush() {
+	if b.nBits > 0 {
+		b.buf = append(b.buf, byte(b.bits))
+		b.bits, b.nBits = 0, 0
+	}
+}
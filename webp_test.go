@@ -0,0 +1,106 @@
+package imgedit
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/draw"
+	"math/rand"
+	"testing"
+
+	"golang.org/x/image/webp"
+)
+
+func Test_webpEncode(t *testing.T) {
+	noise := image.NewNRGBA(image.Rect(0, 0, 37, 23))
+	rand.New(rand.NewSource(1)).Read(noise.Pix)
+	flat := image.NewRGBA(image.Rect(0, 0, 300, 200))
+	draw.Draw(flat, flat.Bounds(), image.NewUniform(color.RGBA{R: 10, G: 200, B: 30, A: 255}), image.Point{}, draw.Src)
+	type args struct {
+		m image.Image
+	}
+	tests := []struct {
+		name    string
+		args    args
+		wantErr bool
+	}{
+		{
+			name:    "png",
+			args:    args{m: GetPngImage()},
+			wantErr: false,
+		},
+		{
+			name:    "alpha png",
+			args:    args{m: GetAlphaPngImage()},
+			wantErr: false,
+		},
+		{
+			name:    "noise",
+			args:    args{m: noise},
+			wantErr: false,
+		},
+		{
+			name:    "flat",
+			args:    args{m: flat},
+			wantErr: false,
+		},
+		{
+			name:    "1px",
+			args:    args{m: image.NewNRGBA(image.Rect(5, 5, 6, 6))},
+			wantErr: false,
+		},
+		{
+			name:    "too large",
+			args:    args{m: image.NewNRGBA(image.Rect(0, 0, 1<<14+1, 1))},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := &bytes.Buffer{}
+			err := webpEncode(w, tt.args.m)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("webpEncode() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+			got, err := webp.Decode(w)
+			if err != nil {
+				t.Errorf("webp.Decode() error = %v", err)
+				return
+			}
+			b := tt.args.m.Bounds()
+			want := image.NewNRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+			draw.Draw(want, want.Bounds(), tt.args.m, b.Min, draw.Src)
+			if !bytes.Equal(got.(*image.NRGBA).Pix, want.Pix) {
+				t.Errorf("webpEncode() is not lossless")
+			}
+		})
+	}
+}
+
+func Test_webpPrefix(t *testing.T) {
+	tests := []struct {
+		name      string
+		v         int
+		wantCode  int
+		wantN     int
+		wantExtra int
+	}{
+		{name: "1", v: 1, wantCode: 0, wantN: 0, wantExtra: 0},
+		{name: "4", v: 4, wantCode: 3, wantN: 0, wantExtra: 0},
+		{name: "5", v: 5, wantCode: 4, wantN: 1, wantExtra: 0},
+		{name: "8", v: 8, wantCode: 5, wantN: 1, wantExtra: 1},
+		{name: "4096", v: 4096, wantCode: 23, wantN: 10, wantExtra: 1023},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, n, extra := webpPrefix(tt.v)
+			if code != tt.wantCode || n != tt.wantN || extra != tt.wantExtra {
+				t.Errorf("webpPrefix() = %v, %v, %v, want %v, %v, %v", code, n, extra, tt.wantCode, tt.wantN, tt.wantExtra)
+			}
+		})
+	}
+}